@@ -7,6 +7,7 @@ require (
 	github.com/antchfx/xpath v1.1.2 // indirect
 	github.com/bmatcuk/doublestar v1.1.5
 	github.com/disintegration/imaging v1.6.0
+	github.com/fsnotify/fsnotify v1.4.7
 	github.com/go-chi/chi v4.0.2+incompatible
 	github.com/gobuffalo/packr/v2 v2.0.2
 	github.com/golang-migrate/migrate/v4 v4.3.1
@@ -22,7 +23,7 @@ require (
 	github.com/spf13/viper v1.4.0
 	github.com/vektah/gqlparser v1.1.2
 	golang.org/x/crypto v0.0.0-20190701094942-4def268fd1a4
-	golang.org/x/image v0.0.0-20190118043309-183bebdce1b2 // indirect
+	golang.org/x/image v0.0.0-20190118043309-183bebdce1b2
 	golang.org/x/net v0.0.0-20190522155817-f3200d17e092
 	gopkg.in/yaml.v2 v2.2.2
 )
@@ -14,6 +14,9 @@ import (
 func main() {
 	manager.Initialize()
 	database.Initialize(config.GetDatabasePath())
+	manager.GetInstance().StartJobQueue()
+	manager.GetInstance().StartScheduler()
+	manager.GetInstance().RefreshWatcher()
 	api.Start()
 	blockForever()
 }
@@ -15,6 +15,15 @@ type Resolver struct{}
 func (r *Resolver) Gallery() models.GalleryResolver {
 	return &galleryResolver{r}
 }
+func (r *Resolver) Image() models.ImageResolver {
+	return &imageResolver{r}
+}
+func (r *Resolver) Job() models.JobResolver {
+	return &jobResolver{r}
+}
+func (r *Resolver) JobReportItem() models.JobReportItemResolver {
+	return &jobReportItemResolver{r}
+}
 func (r *Resolver) Mutation() models.MutationResolver {
 	return &mutationResolver{r}
 }
@@ -30,6 +39,9 @@ func (r *Resolver) Scene() models.SceneResolver {
 func (r *Resolver) SceneMarker() models.SceneMarkerResolver {
 	return &sceneMarkerResolver{r}
 }
+func (r *Resolver) Schedule() models.ScheduleResolver {
+	return &scheduleResolver{r}
+}
 func (r *Resolver) Studio() models.StudioResolver {
 	return &studioResolver{r}
 }
@@ -45,9 +57,13 @@ type queryResolver struct{ *Resolver }
 type subscriptionResolver struct{ *Resolver }
 
 type galleryResolver struct{ *Resolver }
+type imageResolver struct{ *Resolver }
+type jobResolver struct{ *Resolver }
+type jobReportItemResolver struct{ *Resolver }
 type performerResolver struct{ *Resolver }
 type sceneResolver struct{ *Resolver }
 type sceneMarkerResolver struct{ *Resolver }
+type scheduleResolver struct{ *Resolver }
 type studioResolver struct{ *Resolver }
 type tagResolver struct{ *Resolver }
 
@@ -79,9 +95,18 @@ func (r *queryResolver) ValidGalleriesForScene(ctx context.Context, scene_id *st
 
 	qb := models.NewGalleryQueryBuilder()
 	validGalleries, err := qb.ValidGalleriesForScenePath(scene.Path)
-	sceneGallery, _ := qb.FindBySceneID(sceneID, nil)
-	if sceneGallery != nil {
-		validGalleries = append(validGalleries, sceneGallery)
+	sceneGalleries, _ := qb.FindBySceneID(sceneID, nil)
+	for _, sceneGallery := range sceneGalleries {
+		found := false
+		for _, validGallery := range validGalleries {
+			if validGallery.ID == sceneGallery.ID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			validGalleries = append(validGalleries, sceneGallery)
+		}
 	}
 	return validGalleries, nil
 }
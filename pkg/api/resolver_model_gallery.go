@@ -2,11 +2,74 @@ package api
 
 import (
 	"context"
+
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
 )
 
 func (r *galleryResolver) Title(ctx context.Context, obj *models.Gallery) (*string, error) {
-	return nil, nil // TODO remove this from schema
+	if obj.Title.Valid {
+		return &obj.Title.String, nil
+	}
+	return nil, nil
+}
+
+func (r *galleryResolver) Details(ctx context.Context, obj *models.Gallery) (*string, error) {
+	if obj.Details.Valid {
+		return &obj.Details.String, nil
+	}
+	return nil, nil
+}
+
+func (r *galleryResolver) URL(ctx context.Context, obj *models.Gallery) (*string, error) {
+	if obj.URL.Valid {
+		return &obj.URL.String, nil
+	}
+	return nil, nil
+}
+
+func (r *galleryResolver) Date(ctx context.Context, obj *models.Gallery) (*string, error) {
+	if obj.Date.Valid {
+		result := utils.GetYMDFromDatabaseDate(obj.Date.String)
+		return &result, nil
+	}
+	return nil, nil
+}
+
+func (r *galleryResolver) Rating(ctx context.Context, obj *models.Gallery) (*int, error) {
+	if obj.Rating.Valid {
+		rating := int(obj.Rating.Int64)
+		return &rating, nil
+	}
+	return nil, nil
+}
+
+func (r *galleryResolver) Corrupt(ctx context.Context, obj *models.Gallery) (bool, error) {
+	return obj.Corrupt.Valid && obj.Corrupt.Bool, nil
+}
+
+func (r *galleryResolver) Studio(ctx context.Context, obj *models.Gallery) (*models.Studio, error) {
+	if !obj.StudioID.Valid {
+		return nil, nil
+	}
+
+	qb := models.NewStudioQueryBuilder()
+	return qb.FindByGalleryID(obj.ID)
+}
+
+func (r *galleryResolver) Tags(ctx context.Context, obj *models.Gallery) ([]*models.Tag, error) {
+	qb := models.NewTagQueryBuilder()
+	return qb.FindByGalleryID(obj.ID, nil)
+}
+
+func (r *galleryResolver) Performers(ctx context.Context, obj *models.Gallery) ([]*models.Performer, error) {
+	qb := models.NewPerformerQueryBuilder()
+	return qb.FindByGalleryID(obj.ID, nil)
+}
+
+func (r *galleryResolver) Scenes(ctx context.Context, obj *models.Gallery) ([]*models.Scene, error) {
+	qb := models.NewSceneQueryBuilder()
+	return qb.FindByGalleryID(obj.ID, nil)
 }
 
 func (r *galleryResolver) Files(ctx context.Context, obj *models.Gallery) ([]*models.GalleryFilesType, error) {
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash/pkg/api/urlbuilders"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *imageResolver) Path(ctx context.Context, obj *models.Image) (*string, error) {
+	if obj.Path.Valid {
+		return &obj.Path.String, nil
+	}
+	return nil, nil
+}
+
+func (r *imageResolver) Title(ctx context.Context, obj *models.Image) (*string, error) {
+	if obj.Title.Valid {
+		return &obj.Title.String, nil
+	}
+	return nil, nil
+}
+
+func (r *imageResolver) Rating(ctx context.Context, obj *models.Image) (*int, error) {
+	if obj.Rating.Valid {
+		rating := int(obj.Rating.Int64)
+		return &rating, nil
+	}
+	return nil, nil
+}
+
+func (r *imageResolver) Size(ctx context.Context, obj *models.Image) (*string, error) {
+	if obj.Size.Valid {
+		return &obj.Size.String, nil
+	}
+	return nil, nil
+}
+
+func (r *imageResolver) Width(ctx context.Context, obj *models.Image) (*int, error) {
+	if obj.Width.Valid {
+		width := int(obj.Width.Int64)
+		return &width, nil
+	}
+	return nil, nil
+}
+
+func (r *imageResolver) Height(ctx context.Context, obj *models.Image) (*int, error) {
+	if obj.Height.Valid {
+		height := int(obj.Height.Int64)
+		return &height, nil
+	}
+	return nil, nil
+}
+
+func (r *imageResolver) Paths(ctx context.Context, obj *models.Image) (*models.ImagePathsType, error) {
+	baseURL, _ := ctx.Value(BaseURLCtxKey).(string)
+	builder := urlbuilders.NewImageURLBuilder(baseURL, obj.ID)
+	imagePath := builder.GetImageURL()
+	thumbnailPath := builder.GetThumbnailURL()
+	return &models.ImagePathsType{
+		Image:     &imagePath,
+		Thumbnail: &thumbnailPath,
+	}, nil
+}
+
+func (r *imageResolver) Galleries(ctx context.Context, obj *models.Image) ([]*models.Gallery, error) {
+	qb := models.NewGalleryQueryBuilder()
+	return qb.FindByImageChecksum(obj.Checksum, nil)
+}
+
+func (r *imageResolver) Tags(ctx context.Context, obj *models.Image) ([]*models.Tag, error) {
+	qb := models.NewTagQueryBuilder()
+	return qb.FindByImageID(obj.ID, nil)
+}
+
+func (r *imageResolver) Performers(ctx context.Context, obj *models.Image) ([]*models.Performer, error) {
+	qb := models.NewPerformerQueryBuilder()
+	return qb.FindByImageID(obj.ID, nil)
+}
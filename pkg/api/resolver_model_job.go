@@ -0,0 +1,45 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/stashapp/stash/pkg/manager"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *jobResolver) Type(ctx context.Context, obj *models.Job) (string, error) {
+	return manager.JobStatus(obj.Type).String(), nil
+}
+
+func (r *jobResolver) State(ctx context.Context, obj *models.Job) (models.JobState, error) {
+	return models.JobState(obj.State), nil
+}
+
+func (r *jobResolver) Progress(ctx context.Context, obj *models.Job) (*float64, error) {
+	current := manager.GetInstance().GetCurrentJob()
+	if current == nil || current.ID != obj.ID {
+		return nil, nil
+	}
+
+	progress := manager.GetInstance().Status.GetStatus().Progress
+	if progress < 0 {
+		return nil, nil
+	}
+	return &progress, nil
+}
+
+func (r *jobResolver) Added(ctx context.Context, obj *models.Job) (*time.Time, error) {
+	return &obj.CreatedAt.Timestamp, nil
+}
+
+func (r *jobReportItemResolver) Type(ctx context.Context, obj *models.JobReportItem) (models.JobReportItemType, error) {
+	return models.JobReportItemType(obj.Type), nil
+}
+
+func (r *jobReportItemResolver) Message(ctx context.Context, obj *models.JobReportItem) (*string, error) {
+	if obj.Message.Valid {
+		return &obj.Message.String, nil
+	}
+	return nil, nil
+}
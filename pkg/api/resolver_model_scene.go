@@ -4,11 +4,36 @@ import (
 	"context"
 
 	"github.com/stashapp/stash/pkg/api/urlbuilders"
+	"github.com/stashapp/stash/pkg/ffmpeg"
 	"github.com/stashapp/stash/pkg/manager"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/utils"
 )
 
+func (r *sceneResolver) Checksum(ctx context.Context, obj *models.Scene) (*string, error) {
+	if obj.Checksum.Valid {
+		return &obj.Checksum.String, nil
+	}
+	return nil, nil
+}
+
+func (r *sceneResolver) Oshash(ctx context.Context, obj *models.Scene) (*string, error) {
+	return sceneFingerprint(obj.ID, models.FingerprintTypeOshash)
+}
+
+func (r *sceneResolver) Phash(ctx context.Context, obj *models.Scene) (*string, error) {
+	return sceneFingerprint(obj.ID, models.FingerprintTypePhash)
+}
+
+func sceneFingerprint(sceneID int, fingerprintType string) (*string, error) {
+	qb := models.NewSceneFingerprintQueryBuilder()
+	fingerprint, err := qb.Find(sceneID, fingerprintType, nil)
+	if err != nil || fingerprint == nil {
+		return nil, err
+	}
+	return &fingerprint.Fingerprint, nil
+}
+
 func (r *sceneResolver) Title(ctx context.Context, obj *models.Scene) (*string, error) {
 	if obj.Title.Valid {
 		return &obj.Title.String, nil
@@ -55,6 +80,7 @@ func (r *sceneResolver) File(ctx context.Context, obj *models.Scene) (*models.Sc
 		Duration:   &obj.Duration.Float64,
 		VideoCodec: &obj.VideoCodec.String,
 		AudioCodec: &obj.AudioCodec.String,
+		Format:     &obj.Format.String,
 		Width:      &width,
 		Height:     &height,
 		Framerate:  &obj.Framerate.Float64,
@@ -72,15 +98,69 @@ func (r *sceneResolver) Paths(ctx context.Context, obj *models.Scene) (*models.S
 	vttPath := builder.GetSpriteVTTURL()
 	chaptersVttPath := builder.GetChaptersVTTURL()
 	return &models.ScenePathsType{
-		Screenshot:  &screenshotPath,
-		Preview:     &previewPath,
-		Stream:      &streamPath,
-		Webp:        &webpPath,
-		Vtt:         &vttPath,
-		ChaptersVtt: &chaptersVttPath,
+		Screenshot:     &screenshotPath,
+		Preview:        &previewPath,
+		Stream:         &streamPath,
+		Webp:           &webpPath,
+		Vtt:            &vttPath,
+		ChaptersVtt:    &chaptersVttPath,
+		StreamVariants: getSceneStreamVariants(builder, obj),
 	}, nil
 }
 
+func getSceneStreamVariants(builder urlbuilders.SceneURLBuilder, scene *models.Scene) []*models.SceneStreamVariant {
+	var ret []*models.SceneStreamVariant
+
+	directStream := manager.IsDirectStreamable(scene)
+	if directStream {
+		ret = append(ret, &models.SceneStreamVariant{
+			Type:     models.SceneStreamVariantTypeDirect,
+			URL:      builder.GetStreamResolutionURL(models.StreamingResolutionEnumOriginal.String()),
+			MimeType: manager.GetVideoMimeType(scene.Path),
+		})
+	}
+
+	if hasTranscode, _ := manager.HasTranscode(scene); hasTranscode {
+		ret = append(ret, &models.SceneStreamVariant{
+			Type:     models.SceneStreamVariantTypeTranscode,
+			URL:      builder.GetStreamURL(),
+			MimeType: "video/mp4",
+		})
+	}
+
+	liveMimeType := ffmpeg.ContainerMimeType(manager.GetStreamingTranscodeProfile().Container)
+	for _, resolution := range manager.GetStreamingResolutions(scene) {
+		// the original resolution is streamed directly if possible
+		if directStream && resolution == models.StreamingResolutionEnumOriginal {
+			continue
+		}
+
+		mimeType := liveMimeType
+		if resolution == models.StreamingResolutionEnumOriginal && manager.IsRemuxStreamable(scene) {
+			// the video is copied into mp4 rather than transcoded
+			mimeType = "video/mp4"
+		}
+
+		resolution := resolution
+		ret = append(ret, &models.SceneStreamVariant{
+			Type:       models.SceneStreamVariantTypeLiveTranscode,
+			Resolution: &resolution,
+			URL:        builder.GetStreamResolutionURL(resolution.String()),
+			MimeType:   mimeType,
+		})
+	}
+
+	if manager.IsHLSStreamable(scene) {
+		ret = append(ret, &models.SceneStreamVariant{
+			Type:     models.SceneStreamVariantTypeHls,
+			URL:      builder.GetHLSStreamURL(),
+			MimeType: "application/vnd.apple.mpegurl",
+		})
+	}
+
+	return ret
+}
+
 func (r *sceneResolver) IsStreamable(ctx context.Context, obj *models.Scene) (bool, error) {
 	return manager.IsStreamable(obj)
 }
@@ -91,6 +171,15 @@ func (r *sceneResolver) SceneMarkers(ctx context.Context, obj *models.Scene) ([]
 }
 
 func (r *sceneResolver) Gallery(ctx context.Context, obj *models.Scene) (*models.Gallery, error) {
+	qb := models.NewGalleryQueryBuilder()
+	galleries, err := qb.FindBySceneID(obj.ID, nil)
+	if err != nil || len(galleries) == 0 {
+		return nil, err
+	}
+	return galleries[0], nil
+}
+
+func (r *sceneResolver) Galleries(ctx context.Context, obj *models.Scene) ([]*models.Gallery, error) {
 	qb := models.NewGalleryQueryBuilder()
 	return qb.FindBySceneID(obj.ID, nil)
 }
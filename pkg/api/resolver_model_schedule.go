@@ -0,0 +1,34 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/stashapp/stash/pkg/manager"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *scheduleResolver) Task(ctx context.Context, obj *models.Schedule) (models.ScheduleTask, error) {
+	return manager.JobTypeScheduleTask(manager.JobStatus(obj.Type))
+}
+
+func (r *scheduleResolver) Input(ctx context.Context, obj *models.Schedule) (*string, error) {
+	if obj.Input.Valid {
+		return &obj.Input.String, nil
+	}
+	return nil, nil
+}
+
+func (r *scheduleResolver) LastRun(ctx context.Context, obj *models.Schedule) (*time.Time, error) {
+	if obj.LastRunAt.Valid {
+		return &obj.LastRunAt.Timestamp, nil
+	}
+	return nil, nil
+}
+
+func (r *scheduleResolver) NextRun(ctx context.Context, obj *models.Schedule) (*time.Time, error) {
+	if obj.Enabled && obj.NextRunAt.Valid {
+		return &obj.NextRunAt.Timestamp, nil
+	}
+	return nil, nil
+}
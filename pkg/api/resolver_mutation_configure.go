@@ -14,13 +14,30 @@ import (
 
 func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input models.ConfigGeneralInput) (*models.ConfigGeneralResult, error) {
 	if len(input.Stashes) > 0 {
-		for _, stashPath := range input.Stashes {
-			exists, err := utils.DirExists(stashPath)
+		var stashes []*models.StashConfig
+		for _, stashInput := range input.Stashes {
+			exists, err := utils.DirExists(stashInput.Path)
 			if !exists {
 				return makeConfigGeneralResult(), err
 			}
+
+			if stashInput.ScanSchedule != nil && *stashInput.ScanSchedule != "" {
+				if _, err := utils.ParseCron(*stashInput.ScanSchedule); err != nil {
+					return makeConfigGeneralResult(), err
+				}
+			}
+
+			stash := &models.StashConfig{
+				Path:         stashInput.Path,
+				ExcludeVideo: stashInput.ExcludeVideo != nil && *stashInput.ExcludeVideo,
+				ExcludeImage: stashInput.ExcludeImage != nil && *stashInput.ExcludeImage,
+				SkipGenerate: stashInput.SkipGenerate != nil && *stashInput.SkipGenerate,
+				ReadOnly:     stashInput.ReadOnly != nil && *stashInput.ReadOnly,
+				ScanSchedule: stashInput.ScanSchedule,
+			}
+			stashes = append(stashes, stash)
 		}
-		config.Set(config.Stash, input.Stashes)
+		config.SetStashes(stashes)
 	}
 
 	if input.DatabasePath != nil {
@@ -38,6 +55,21 @@ func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input models.Co
 		config.Set(config.Generated, input.GeneratedPath)
 	}
 
+	if input.ParallelTasks != nil {
+		config.Set(config.ParallelTasks, *input.ParallelTasks)
+	}
+
+	migrateHash := false
+	if input.SceneFileNamingHash != nil && *input.SceneFileNamingHash != config.GetSceneFileNamingHash() {
+		config.Set(config.SceneFileNamingHash, input.SceneFileNamingHash.String())
+		// generated files are named with the previous hash until renamed
+		migrateHash = true
+	}
+
+	if input.CalculateMd5 != nil {
+		config.Set(config.CalculateMD5, *input.CalculateMd5)
+	}
+
 	if input.MaxTranscodeSize != nil {
 		config.Set(config.MaxTranscodeSize, input.MaxTranscodeSize.String())
 	}
@@ -46,6 +78,33 @@ func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input models.Co
 		config.Set(config.MaxStreamingTranscodeSize, input.MaxStreamingTranscodeSize.String())
 	}
 
+	if input.TranscodeProfile != nil {
+		if *input.TranscodeProfile != "" {
+			if err := manager.ValidateTranscodeProfile(*input.TranscodeProfile, false); err != nil {
+				return makeConfigGeneralResult(), err
+			}
+		}
+		config.Set(config.TranscodeProfile, *input.TranscodeProfile)
+	}
+
+	if input.StreamingTranscodeProfile != nil {
+		if *input.StreamingTranscodeProfile != "" {
+			if err := manager.ValidateTranscodeProfile(*input.StreamingTranscodeProfile, true); err != nil {
+				return makeConfigGeneralResult(), err
+			}
+		}
+		config.Set(config.StreamingTranscodeProfile, *input.StreamingTranscodeProfile)
+	}
+
+	if input.HlsTranscodeProfile != nil {
+		if *input.HlsTranscodeProfile != "" {
+			if err := manager.ValidateTranscodeProfile(*input.HlsTranscodeProfile, true); err != nil {
+				return makeConfigGeneralResult(), err
+			}
+		}
+		config.Set(config.HLSTranscodeProfile, *input.HlsTranscodeProfile)
+	}
+
 	if input.Username != nil {
 		config.Set(config.Username, input.Username)
 	}
@@ -76,11 +135,76 @@ func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input models.Co
 		config.Set(config.Exclude, input.Excludes)
 	}
 
+	if input.VideoExtensions != nil {
+		config.Set(config.VideoExtensions, input.VideoExtensions)
+	}
+
+	if input.GalleryExtensions != nil {
+		config.Set(config.GalleryExtensions, input.GalleryExtensions)
+	}
+
+	if input.ImageExtensions != nil {
+		config.Set(config.ImageExtensions, input.ImageExtensions)
+	}
+
+	if input.CreateGalleriesFromFolders != nil {
+		config.Set(config.CreateGalleriesFromFolders, *input.CreateGalleriesFromFolders)
+	}
+
+	if input.WatchStashPaths != nil {
+		config.Set(config.WatchStashPaths, *input.WatchStashPaths)
+	}
+
+	if input.CleanThreshold != nil {
+		if *input.CleanThreshold < 0 || *input.CleanThreshold > 100 {
+			return makeConfigGeneralResult(), fmt.Errorf("clean threshold must be between 0 and 100")
+		}
+		config.Set(config.CleanThreshold, *input.CleanThreshold)
+	}
+
+	previewOptions := models.GeneratePreviewOptionsInput{
+		PreviewSegments:        input.PreviewSegments,
+		PreviewSegmentDuration: input.PreviewSegmentDuration,
+		PreviewExcludeStart:    input.PreviewExcludeStart,
+		PreviewExcludeEnd:      input.PreviewExcludeEnd,
+		PreviewPreset:          input.PreviewPreset,
+		PreviewAudio:           input.PreviewAudio,
+	}
+	if err := manager.GetPreviewOptions(&previewOptions).Validate(); err != nil {
+		return makeConfigGeneralResult(), err
+	}
+
+	if input.PreviewSegments != nil {
+		config.Set(config.PreviewSegments, *input.PreviewSegments)
+	}
+	if input.PreviewSegmentDuration != nil {
+		config.Set(config.PreviewSegmentDuration, *input.PreviewSegmentDuration)
+	}
+	if input.PreviewExcludeStart != nil {
+		config.Set(config.PreviewExcludeStart, *input.PreviewExcludeStart)
+	}
+	if input.PreviewExcludeEnd != nil {
+		config.Set(config.PreviewExcludeEnd, *input.PreviewExcludeEnd)
+	}
+	if input.PreviewPreset != nil {
+		config.Set(config.PreviewPreset, input.PreviewPreset.String())
+	}
+	if input.PreviewAudio != nil {
+		config.Set(config.PreviewAudio, *input.PreviewAudio)
+	}
+
 	if err := config.Write(); err != nil {
 		return makeConfigGeneralResult(), err
 	}
 
 	manager.GetInstance().RefreshConfig()
+	manager.GetInstance().RefreshWatcher()
+
+	if migrateHash {
+		if _, err := manager.GetInstance().MigrateHash(); err != nil {
+			logger.Errorf("Error queueing renaming of generated files: %s", err.Error())
+		}
+	}
 
 	return makeConfigGeneralResult(), nil
 }
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *mutationResolver) GalleryUpdate(ctx context.Context, input models.GalleryUpdateInput) (*models.Gallery, error) {
+	// Start the transaction and save the gallery
+	tx := database.DB.MustBeginTx(ctx, nil)
+
+	ret, err := r.galleryUpdate(input, tx)
+
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	// Commit
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *mutationResolver) galleryUpdate(input models.GalleryUpdateInput, tx *sqlx.Tx) (*models.Gallery, error) {
+	// Populate gallery from the input
+	galleryID, _ := strconv.Atoi(input.ID)
+
+	updatedTime := time.Now()
+	updatedGallery := models.GalleryPartial{
+		ID:        galleryID,
+		UpdatedAt: &models.SQLiteTimestamp{Timestamp: updatedTime},
+	}
+	if input.Title != nil {
+		updatedGallery.Title = &sql.NullString{String: *input.Title, Valid: true}
+	}
+	if input.Details != nil {
+		updatedGallery.Details = &sql.NullString{String: *input.Details, Valid: true}
+	}
+	if input.URL != nil {
+		updatedGallery.URL = &sql.NullString{String: *input.URL, Valid: true}
+	}
+	if input.Date != nil {
+		updatedGallery.Date = &models.SQLiteDate{String: *input.Date, Valid: true}
+	}
+
+	if input.Rating != nil {
+		updatedGallery.Rating = &sql.NullInt64{Int64: int64(*input.Rating), Valid: true}
+	} else {
+		// rating must be nullable
+		updatedGallery.Rating = &sql.NullInt64{Valid: false}
+	}
+
+	if input.StudioID != nil {
+		studioID, _ := strconv.ParseInt(*input.StudioID, 10, 64)
+		updatedGallery.StudioID = &sql.NullInt64{Int64: studioID, Valid: true}
+	} else {
+		// studio must be nullable
+		updatedGallery.StudioID = &sql.NullInt64{Valid: false}
+	}
+
+	qb := models.NewGalleryQueryBuilder()
+	jqb := models.NewJoinsQueryBuilder()
+	gallery, err := qb.UpdatePartial(updatedGallery, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Save the performers
+	var performerJoins []models.PerformersGalleries
+	for _, pid := range input.PerformerIds {
+		performerID, _ := strconv.Atoi(pid)
+		performerJoin := models.PerformersGalleries{
+			PerformerID: performerID,
+			GalleryID:   galleryID,
+		}
+		performerJoins = append(performerJoins, performerJoin)
+	}
+	if err := jqb.UpdatePerformersGalleries(galleryID, performerJoins, tx); err != nil {
+		return nil, err
+	}
+
+	// Save the tags
+	var tagJoins []models.GalleriesTags
+	for _, tid := range input.TagIds {
+		tagID, _ := strconv.Atoi(tid)
+		tagJoin := models.GalleriesTags{
+			GalleryID: galleryID,
+			TagID:     tagID,
+		}
+		tagJoins = append(tagJoins, tagJoin)
+	}
+	if err := jqb.UpdateGalleriesTags(galleryID, tagJoins, tx); err != nil {
+		return nil, err
+	}
+
+	return gallery, nil
+}
+
+func (r *mutationResolver) BulkGalleryUpdate(ctx context.Context, input models.BulkGalleryUpdateInput) ([]*models.Gallery, error) {
+	// Populate gallery from the input
+	updatedTime := time.Now()
+
+	// Start the transaction and save the galleries
+	tx := database.DB.MustBeginTx(ctx, nil)
+	qb := models.NewGalleryQueryBuilder()
+	jqb := models.NewJoinsQueryBuilder()
+
+	updatedGallery := models.GalleryPartial{
+		UpdatedAt: &models.SQLiteTimestamp{Timestamp: updatedTime},
+	}
+	if input.Title != nil {
+		updatedGallery.Title = &sql.NullString{String: *input.Title, Valid: true}
+	}
+	if input.Details != nil {
+		updatedGallery.Details = &sql.NullString{String: *input.Details, Valid: true}
+	}
+	if input.URL != nil {
+		updatedGallery.URL = &sql.NullString{String: *input.URL, Valid: true}
+	}
+	if input.Date != nil {
+		updatedGallery.Date = &models.SQLiteDate{String: *input.Date, Valid: true}
+	}
+	if input.Rating != nil {
+		// a rating of 0 means unset the rating
+		if *input.Rating == 0 {
+			updatedGallery.Rating = &sql.NullInt64{Int64: 0, Valid: false}
+		} else {
+			updatedGallery.Rating = &sql.NullInt64{Int64: int64(*input.Rating), Valid: true}
+		}
+	}
+	if input.StudioID != nil {
+		// empty string means unset the studio
+		if *input.StudioID == "" {
+			updatedGallery.StudioID = &sql.NullInt64{Int64: 0, Valid: false}
+		} else {
+			studioID, _ := strconv.ParseInt(*input.StudioID, 10, 64)
+			updatedGallery.StudioID = &sql.NullInt64{Int64: studioID, Valid: true}
+		}
+	}
+
+	ret := []*models.Gallery{}
+
+	for _, galleryIDStr := range input.Ids {
+		galleryID, _ := strconv.Atoi(galleryIDStr)
+		updatedGallery.ID = galleryID
+
+		gallery, err := qb.UpdatePartial(updatedGallery, tx)
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+
+		ret = append(ret, gallery)
+
+		// Save the performers
+		if wasFieldIncluded(ctx, "performer_ids") {
+			var performerJoins []models.PerformersGalleries
+			for _, pid := range input.PerformerIds {
+				performerID, _ := strconv.Atoi(pid)
+				performerJoin := models.PerformersGalleries{
+					PerformerID: performerID,
+					GalleryID:   galleryID,
+				}
+				performerJoins = append(performerJoins, performerJoin)
+			}
+			if err := jqb.UpdatePerformersGalleries(galleryID, performerJoins, tx); err != nil {
+				_ = tx.Rollback()
+				return nil, err
+			}
+		}
+
+		// Save the tags
+		if wasFieldIncluded(ctx, "tag_ids") {
+			var tagJoins []models.GalleriesTags
+			for _, tid := range input.TagIds {
+				tagID, _ := strconv.Atoi(tid)
+				tagJoin := models.GalleriesTags{
+					GalleryID: galleryID,
+					TagID:     tagID,
+				}
+				tagJoins = append(tagJoins, tagJoin)
+			}
+			if err := jqb.UpdateGalleriesTags(galleryID, tagJoins, tx); err != nil {
+				_ = tx.Rollback()
+				return nil, err
+			}
+		}
+	}
+
+	// Commit
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
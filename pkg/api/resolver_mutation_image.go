@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *mutationResolver) ImageUpdate(ctx context.Context, input models.ImageUpdateInput) (*models.Image, error) {
+	// Start the transaction and save the image
+	tx := database.DB.MustBeginTx(ctx, nil)
+
+	ret, err := r.imageUpdate(input, tx)
+
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	// Commit
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *mutationResolver) imageUpdate(input models.ImageUpdateInput, tx *sqlx.Tx) (*models.Image, error) {
+	// Populate image from the input
+	imageID, _ := strconv.Atoi(input.ID)
+
+	updatedTime := time.Now()
+	updatedImage := models.ImagePartial{
+		ID:        imageID,
+		UpdatedAt: &models.SQLiteTimestamp{Timestamp: updatedTime},
+	}
+	if input.Title != nil {
+		updatedImage.Title = &sql.NullString{String: *input.Title, Valid: true}
+	}
+
+	if input.Rating != nil {
+		updatedImage.Rating = &sql.NullInt64{Int64: int64(*input.Rating), Valid: true}
+	} else {
+		// rating must be nullable
+		updatedImage.Rating = &sql.NullInt64{Valid: false}
+	}
+
+	qb := models.NewImageQueryBuilder()
+	jqb := models.NewJoinsQueryBuilder()
+	image, err := qb.Update(updatedImage, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Save the performers
+	var performerJoins []models.PerformersImages
+	for _, pid := range input.PerformerIds {
+		performerID, _ := strconv.Atoi(pid)
+		performerJoin := models.PerformersImages{
+			PerformerID: performerID,
+			ImageID:     imageID,
+		}
+		performerJoins = append(performerJoins, performerJoin)
+	}
+	if err := jqb.UpdatePerformersImages(imageID, performerJoins, tx); err != nil {
+		return nil, err
+	}
+
+	// Save the tags
+	var tagJoins []models.ImagesTags
+	for _, tid := range input.TagIds {
+		tagID, _ := strconv.Atoi(tid)
+		tagJoin := models.ImagesTags{
+			ImageID: imageID,
+			TagID:   tagID,
+		}
+		tagJoins = append(tagJoins, tagJoin)
+	}
+	if err := jqb.UpdateImagesTags(imageID, tagJoins, tx); err != nil {
+		return nil, err
+	}
+
+	return image, nil
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/stashapp/stash/pkg/manager"
+)
+
+func (r *mutationResolver) CancelJob(ctx context.Context, id string) (bool, error) {
+	jobID, err := strconv.Atoi(id)
+	if err != nil {
+		return false, err
+	}
+
+	return manager.GetInstance().CancelJob(jobID)
+}
+
+func (r *mutationResolver) ReorderJob(ctx context.Context, id string, position int) (bool, error) {
+	jobID, err := strconv.Atoi(id)
+	if err != nil {
+		return false, err
+	}
+
+	return manager.GetInstance().ReorderJob(jobID, position)
+}
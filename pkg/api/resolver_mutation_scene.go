@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -111,26 +112,23 @@ func (r *mutationResolver) sceneUpdate(input models.SceneUpdateInput, tx *sqlx.T
 		return nil, err
 	}
 
-	// Clear the existing gallery value
-	gqb := models.NewGalleryQueryBuilder()
-	err = gqb.ClearGalleryId(sceneID, tx)
-	if err != nil {
-		return nil, err
+	// Save the galleries. The deprecated gallery id replaces the
+	// galleries if the gallery ids are not set.
+	galleryIDs := input.GalleryIds
+	if galleryIDs == nil && input.GalleryID != nil {
+		galleryIDs = []string{*input.GalleryID}
 	}
-
-	if input.GalleryID != nil {
-		// Save the gallery
-		galleryID, _ := strconv.Atoi(*input.GalleryID)
-		updatedGallery := models.Gallery{
-			ID:        galleryID,
-			SceneID:   sql.NullInt64{Int64: int64(sceneID), Valid: true},
-			UpdatedAt: models.SQLiteTimestamp{Timestamp: updatedTime},
-		}
-		gqb := models.NewGalleryQueryBuilder()
-		_, err := gqb.Update(updatedGallery, tx)
-		if err != nil {
-			return nil, err
+	var galleryJoins []models.ScenesGalleries
+	for _, gid := range galleryIDs {
+		galleryID, _ := strconv.Atoi(gid)
+		galleryJoin := models.ScenesGalleries{
+			SceneID:   sceneID,
+			GalleryID: galleryID,
 		}
+		galleryJoins = append(galleryJoins, galleryJoin)
+	}
+	if err := jqb.UpdateScenesGalleries(sceneID, galleryJoins, tx); err != nil {
+		return nil, err
 	}
 
 	// Save the performers
@@ -168,7 +166,7 @@ func (r *mutationResolver) sceneUpdate(input models.SceneUpdateInput, tx *sqlx.T
 			return nil, err
 		}
 
-		err = manager.SetSceneScreenshot(scene.Checksum, coverImageData)
+		err = manager.SetSceneScreenshot(manager.GetSceneHash(scene), coverImageData)
 		if err != nil {
 			return nil, err
 		}
@@ -233,17 +231,24 @@ func (r *mutationResolver) BulkSceneUpdate(ctx context.Context, input models.Bul
 
 		ret = append(ret, scene)
 
-		if input.GalleryID != nil {
-			// Save the gallery
-			galleryID, _ := strconv.Atoi(*input.GalleryID)
-			updatedGallery := models.Gallery{
-				ID:        galleryID,
-				SceneID:   sql.NullInt64{Int64: int64(sceneID), Valid: true},
-				UpdatedAt: models.SQLiteTimestamp{Timestamp: updatedTime},
+		// Save the galleries
+		if wasFieldIncluded(ctx, "gallery_ids") {
+			var galleryJoins []models.ScenesGalleries
+			for _, gid := range input.GalleryIds {
+				galleryID, _ := strconv.Atoi(gid)
+				galleryJoin := models.ScenesGalleries{
+					SceneID:   sceneID,
+					GalleryID: galleryID,
+				}
+				galleryJoins = append(galleryJoins, galleryJoin)
 			}
-			gqb := models.NewGalleryQueryBuilder()
-			_, err := gqb.Update(updatedGallery, tx)
-			if err != nil {
+			if err := jqb.UpdateScenesGalleries(sceneID, galleryJoins, tx); err != nil {
+				_ = tx.Rollback()
+				return nil, err
+			}
+		} else if input.GalleryID != nil {
+			galleryID, _ := strconv.Atoi(*input.GalleryID)
+			if _, err := jqb.AddSceneGallery(sceneID, galleryID, tx); err != nil {
 				_ = tx.Rollback()
 				return nil, err
 			}
@@ -298,6 +303,18 @@ func (r *mutationResolver) SceneDestroy(ctx context.Context, input models.SceneD
 
 	sceneID, _ := strconv.Atoi(input.ID)
 	scene, err := qb.Find(sceneID)
+	if err != nil || scene == nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+
+	deleteFile := input.DeleteFile != nil && *input.DeleteFile
+	if deleteFile && manager.IsReadOnlyPath(scene.Path) {
+		_ = tx.Rollback()
+		return false, fmt.Errorf("cannot delete %s: stash path is read-only", scene.Path)
+	}
+
+	sceneHash := manager.GetSceneHash(scene)
 	err = manager.DestroyScene(sceneID, tx)
 
 	if err != nil {
@@ -312,18 +329,54 @@ func (r *mutationResolver) SceneDestroy(ctx context.Context, input models.SceneD
 	// if delete generated is true, then delete the generated files
 	// for the scene
 	if input.DeleteGenerated != nil && *input.DeleteGenerated {
-		manager.DeleteGeneratedSceneFiles(scene)
+		manager.DeleteGeneratedSceneFiles(sceneHash)
 	}
 
 	// if delete file is true, then delete the file as well
 	// if it fails, just log a message
-	if input.DeleteFile != nil && *input.DeleteFile {
+	if deleteFile {
 		manager.DeleteSceneFile(scene)
 	}
 
 	return true, nil
 }
 
+func (r *mutationResolver) SceneMerge(ctx context.Context, sourceIds []string, destinationID string) (*models.Scene, error) {
+	qb := models.NewSceneQueryBuilder()
+
+	destID, _ := strconv.Atoi(destinationID)
+	var sourceHashes []string
+	var sourceIDInts []int
+	for _, id := range sourceIds {
+		sourceID, _ := strconv.Atoi(id)
+		scene, err := qb.Find(sourceID)
+		if err != nil {
+			return nil, err
+		}
+		if scene != nil {
+			sourceHashes = append(sourceHashes, manager.GetSceneHash(scene))
+		}
+		sourceIDInts = append(sourceIDInts, sourceID)
+	}
+
+	tx := database.DB.MustBeginTx(ctx, nil)
+	if err := manager.MergeScenes(sourceIDInts, destID, tx); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// the generated files of the source scenes are no longer needed
+	for _, sceneHash := range sourceHashes {
+		manager.DeleteGeneratedSceneFiles(sceneHash)
+	}
+
+	return qb.Find(destID)
+}
+
 func (r *mutationResolver) SceneMarkerCreate(ctx context.Context, input models.SceneMarkerCreateInput) (*models.SceneMarker, error) {
 	primaryTagID, _ := strconv.Atoi(input.PrimaryTagID)
 	sceneID, _ := strconv.Atoi(input.SceneID)
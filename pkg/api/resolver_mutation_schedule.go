@@ -0,0 +1,196 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/manager"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *mutationResolver) ScheduleCreate(ctx context.Context, input models.ScheduleCreateInput) (*models.Schedule, error) {
+	currentTime := time.Now()
+	nextRun, err := manager.NextScheduleRun(input.Schedule, currentTime)
+	if err != nil {
+		return nil, err
+	}
+
+	taskInput, err := getScheduleTaskInput(input.Task, input.ScanInput, input.GenerateInput, input.AutoTagInput, input.CleanInput)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateScheduleTaskInput(input.Task, taskInput); err != nil {
+		return nil, err
+	}
+
+	newSchedule := models.Schedule{
+		Name:      input.Name,
+		Schedule:  input.Schedule,
+		Type:      int(manager.ScheduleTaskJobType(input.Task)),
+		Input:     taskInput,
+		Enabled:   input.Enabled == nil || *input.Enabled,
+		NextRunAt: models.NullSQLiteTimestamp{Timestamp: nextRun, Valid: true},
+		CreatedAt: models.SQLiteTimestamp{Timestamp: currentTime},
+		UpdatedAt: models.SQLiteTimestamp{Timestamp: currentTime},
+	}
+
+	// Start the transaction and save the schedule
+	tx := database.DB.MustBeginTx(ctx, nil)
+	qb := models.NewScheduleQueryBuilder()
+	schedule, err := qb.Create(newSchedule, tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	// Commit
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+func (r *mutationResolver) ScheduleUpdate(ctx context.Context, input models.ScheduleUpdateInput) (*models.Schedule, error) {
+	scheduleID, err := strconv.Atoi(input.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start the transaction and save the schedule
+	tx := database.DB.MustBeginTx(ctx, nil)
+	qb := models.NewScheduleQueryBuilder()
+	schedule, err := qb.Find(scheduleID, tx)
+	if err != nil || schedule == nil {
+		_ = tx.Rollback()
+		if err == nil {
+			err = fmt.Errorf("schedule %s not found", input.ID)
+		}
+		return nil, err
+	}
+
+	if input.Name != nil {
+		schedule.Name = *input.Name
+	}
+	if input.Schedule != nil {
+		schedule.Schedule = *input.Schedule
+	}
+	if input.Enabled != nil {
+		schedule.Enabled = *input.Enabled
+	}
+
+	task, err := manager.JobTypeScheduleTask(manager.JobStatus(schedule.Type))
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	taskChanged := input.Task != nil && *input.Task != task
+	if taskChanged {
+		task = *input.Task
+	}
+
+	// the existing input is kept if the task is unchanged and no input for
+	// it is given
+	taskInput, err := getScheduleTaskInput(task, input.ScanInput, input.GenerateInput, input.AutoTagInput, input.CleanInput)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+	if taskChanged || taskInput.Valid {
+		if err := validateScheduleTaskInput(task, taskInput); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+		schedule.Type = int(manager.ScheduleTaskJobType(task))
+		schedule.Input = taskInput
+	}
+
+	currentTime := time.Now()
+	nextRun, err := manager.NextScheduleRun(schedule.Schedule, currentTime)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+	schedule.NextRunAt = models.NullSQLiteTimestamp{Timestamp: nextRun, Valid: true}
+	schedule.UpdatedAt = models.SQLiteTimestamp{Timestamp: currentTime}
+
+	schedule, err = qb.Update(*schedule, tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	// Commit
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+func (r *mutationResolver) ScheduleDestroy(ctx context.Context, id string) (bool, error) {
+	qb := models.NewScheduleQueryBuilder()
+	tx := database.DB.MustBeginTx(ctx, nil)
+	if err := qb.Destroy(id, tx); err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// getScheduleTaskInput returns the input given for the task as JSON, or an
+// invalid string if no input was given for it.
+func getScheduleTaskInput(task models.ScheduleTask, scanInput *models.ScanMetadataInput, generateInput *models.GenerateMetadataInput, autoTagInput *models.AutoTagMetadataInput, cleanInput *models.CleanMetadataInput) (sql.NullString, error) {
+	var input interface{}
+	switch task {
+	case models.ScheduleTaskScan:
+		if scanInput != nil {
+			input = scanInput
+		}
+	case models.ScheduleTaskGenerate:
+		if generateInput != nil {
+			input = generateInput
+		}
+	case models.ScheduleTaskAutoTag:
+		if autoTagInput != nil {
+			input = autoTagInput
+		}
+	case models.ScheduleTaskClean:
+		if cleanInput != nil {
+			input = cleanInput
+		}
+	}
+
+	if input == nil {
+		return sql.NullString{}, nil
+	}
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+func validateScheduleTaskInput(task models.ScheduleTask, taskInput sql.NullString) error {
+	if taskInput.Valid {
+		return nil
+	}
+
+	switch task {
+	case models.ScheduleTaskGenerate:
+		return fmt.Errorf("generateInput is required for generate tasks")
+	case models.ScheduleTaskAutoTag:
+		return fmt.Errorf("autoTagInput is required for auto-tag tasks")
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 
+	"github.com/stashapp/stash/pkg/manager"
 	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/utils"
@@ -34,18 +35,37 @@ func makeConfigGeneralResult() *models.ConfigGeneralResult {
 	maxStreamingTranscodeSize := config.GetMaxStreamingTranscodeSize()
 
 	return &models.ConfigGeneralResult{
-		Stashes:                   config.GetStashPaths(),
-		DatabasePath:              config.GetDatabasePath(),
-		GeneratedPath:             config.GetGeneratedPath(),
-		MaxTranscodeSize:          &maxTranscodeSize,
-		MaxStreamingTranscodeSize: &maxStreamingTranscodeSize,
-		Username:                  config.GetUsername(),
-		Password:                  config.GetPasswordHash(),
-		LogFile:                   &logFile,
-		LogOut:                    config.GetLogOut(),
-		LogLevel:                  config.GetLogLevel(),
-		LogAccess:                 config.GetLogAccess(),
-		Excludes:                  config.GetExcludes(),
+		Stashes:                    config.GetStashes(),
+		DatabasePath:               config.GetDatabasePath(),
+		GeneratedPath:              config.GetGeneratedPath(),
+		ParallelTasks:              config.GetParallelTasks(),
+		SceneFileNamingHash:        config.GetSceneFileNamingHash(),
+		CalculateMd5:               config.GetCalculateMD5(),
+		MaxTranscodeSize:           &maxTranscodeSize,
+		MaxStreamingTranscodeSize:  &maxStreamingTranscodeSize,
+		TranscodeProfiles:          manager.GetTranscodeProfiles(),
+		TranscodeProfile:           manager.GetTranscodeProfile().Name,
+		StreamingTranscodeProfile:  manager.GetStreamingTranscodeProfile().Name,
+		HlsTranscodeProfile:        manager.GetHLSTranscodeProfile().Name,
+		Username:                   config.GetUsername(),
+		Password:                   config.GetPasswordHash(),
+		LogFile:                    &logFile,
+		LogOut:                     config.GetLogOut(),
+		LogLevel:                   config.GetLogLevel(),
+		LogAccess:                  config.GetLogAccess(),
+		Excludes:                   config.GetExcludes(),
+		VideoExtensions:            config.GetVideoExtensions(),
+		GalleryExtensions:          config.GetGalleryExtensions(),
+		ImageExtensions:            config.GetImageExtensions(),
+		CreateGalleriesFromFolders: config.GetCreateGalleriesFromFolders(),
+		WatchStashPaths:            config.GetWatchStashPaths(),
+		CleanThreshold:             config.GetCleanThreshold(),
+		PreviewSegments:            config.GetPreviewSegments(),
+		PreviewSegmentDuration:     config.GetPreviewSegmentDuration(),
+		PreviewExcludeStart:        config.GetPreviewExcludeStart(),
+		PreviewExcludeEnd:          config.GetPreviewExcludeEnd(),
+		PreviewPreset:              config.GetPreviewPreset(),
+		PreviewAudio:               config.GetPreviewAudio(),
 	}
 }
 
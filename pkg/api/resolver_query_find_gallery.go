@@ -12,9 +12,12 @@ func (r *queryResolver) FindGallery(ctx context.Context, id string) (*models.Gal
 	return qb.Find(idInt)
 }
 
-func (r *queryResolver) FindGalleries(ctx context.Context, filter *models.FindFilterType) (*models.FindGalleriesResultType, error) {
+func (r *queryResolver) FindGalleries(ctx context.Context, galleryFilter *models.GalleryFilterType, filter *models.FindFilterType) (*models.FindGalleriesResultType, error) {
 	qb := models.NewGalleryQueryBuilder()
-	galleries, total := qb.Query(filter)
+	galleries, total, err := qb.Query(galleryFilter, filter)
+	if err != nil {
+		return nil, err
+	}
 	return &models.FindGalleriesResultType{
 		Count:     total,
 		Galleries: galleries,
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *queryResolver) FindImage(ctx context.Context, id string) (*models.Image, error) {
+	qb := models.NewImageQueryBuilder()
+	idInt, _ := strconv.Atoi(id)
+	return qb.Find(idInt)
+}
+
+func (r *queryResolver) FindImages(ctx context.Context, imageFilter *models.ImageFilterType, filter *models.FindFilterType) (*models.FindImagesResultType, error) {
+	qb := models.NewImageQueryBuilder()
+	images, total, err := qb.Query(imageFilter, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &models.FindImagesResultType{
+		Count:  total,
+		Images: images,
+	}, nil
+}
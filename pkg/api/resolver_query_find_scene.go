@@ -21,6 +21,14 @@ func (r *queryResolver) FindScene(ctx context.Context, id *string, checksum *str
 	return scene, err
 }
 
+func (r *queryResolver) FindDuplicateScenes(ctx context.Context, distance *int) ([][]*models.Scene, error) {
+	phashDistance := -1
+	if distance != nil {
+		phashDistance = *distance
+	}
+	return manager.FindDuplicateScenes(phashDistance)
+}
+
 func (r *queryResolver) FindScenes(ctx context.Context, sceneFilter *models.SceneFilterType, sceneIds []int, filter *models.FindFilterType) (*models.FindScenesResultType, error) {
 	qb := models.NewSceneQueryBuilder()
 	scenes, total := qb.Query(sceneFilter, filter)
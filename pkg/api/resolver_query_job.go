@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/stashapp/stash/pkg/manager"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *queryResolver) JobQueue(ctx context.Context) ([]*models.Job, error) {
+	return manager.GetInstance().GetJobQueue()
+}
+
+func (r *queryResolver) JobHistory(ctx context.Context, count *int) ([]*models.Job, error) {
+	historyCount := 0
+	if count != nil {
+		historyCount = *count
+	}
+
+	return manager.GetInstance().GetJobHistory(historyCount)
+}
+
+func (r *queryResolver) JobReport(ctx context.Context, id string) (*models.JobReport, error) {
+	jobID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := manager.GetInstance().GetJob(jobID)
+	if err != nil || job == nil {
+		return nil, err
+	}
+
+	items, err := manager.GetInstance().GetJobReportItems(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := models.JobReport{
+		Job:   job,
+		Items: items,
+	}
+
+	if job.StartedAt.Valid {
+		ret.Started = &job.StartedAt.Timestamp
+	}
+	if job.FinishedAt.Valid {
+		ret.Finished = &job.FinishedAt.Timestamp
+	}
+	if job.StartedAt.Valid && job.FinishedAt.Valid {
+		elapsed := job.FinishedAt.Timestamp.Sub(job.StartedAt.Timestamp).Seconds()
+		ret.Elapsed = &elapsed
+	}
+
+	for _, item := range items {
+		switch models.JobReportItemType(item.Type) {
+		case models.JobReportItemTypeAdded:
+			ret.Added++
+		case models.JobReportItemTypeMoved:
+			ret.Moved++
+		case models.JobReportItemTypeModified:
+			ret.Modified++
+		case models.JobReportItemTypeDuplicate:
+			ret.Duplicates++
+		case models.JobReportItemTypeRemoved:
+			ret.Removed++
+		case models.JobReportItemTypeGenerated:
+			ret.Generated++
+		case models.JobReportItemTypeError:
+			ret.Errors++
+		}
+	}
+
+	return &ret, nil
+}
@@ -8,46 +8,61 @@ import (
 )
 
 func (r *queryResolver) MetadataScan(ctx context.Context, input models.ScanMetadataInput) (string, error) {
-	manager.GetInstance().Scan(input.UseFileMetadata)
-	return "todo", nil
+	return manager.GetInstance().Scan(input)
 }
 
 func (r *queryResolver) MetadataImport(ctx context.Context) (string, error) {
-	manager.GetInstance().Import()
-	return "todo", nil
+	return manager.GetInstance().Import()
 }
 
 func (r *queryResolver) MetadataExport(ctx context.Context) (string, error) {
-	manager.GetInstance().Export()
-	return "todo", nil
+	return manager.GetInstance().Export()
 }
 
 func (r *queryResolver) MetadataGenerate(ctx context.Context, input models.GenerateMetadataInput) (string, error) {
-	manager.GetInstance().Generate(input.Sprites, input.Previews, input.Markers, input.Transcodes)
-	return "todo", nil
+	return manager.GetInstance().Generate(input)
 }
 
 func (r *queryResolver) MetadataAutoTag(ctx context.Context, input models.AutoTagMetadataInput) (string, error) {
-	manager.GetInstance().AutoTag(input.Performers, input.Studios, input.Tags)
-	return "todo", nil
+	return manager.GetInstance().AutoTag(input)
 }
 
-func (r *queryResolver) MetadataClean(ctx context.Context) (string, error) {
-	manager.GetInstance().Clean()
-	return "todo", nil
+func (r *queryResolver) MetadataClean(ctx context.Context, input *models.CleanMetadataInput) (string, error) {
+	if input == nil {
+		input = &models.CleanMetadataInput{}
+	}
+	return manager.GetInstance().Clean(*input)
+}
+
+func (r *queryResolver) MetadataAutoLinkGalleries(ctx context.Context) (string, error) {
+	return manager.GetInstance().AutoLinkGalleries()
+}
+
+func (r *queryResolver) MetadataMigrateHash(ctx context.Context) (string, error) {
+	return manager.GetInstance().MigrateHash()
 }
 
 func (r *queryResolver) JobStatus(ctx context.Context) (*models.MetadataUpdateStatus, error) {
-	status := manager.GetInstance().Status
-	ret := models.MetadataUpdateStatus{
+	return makeMetadataUpdateStatus(manager.GetInstance().Status.GetStatus()), nil
+}
+
+func makeMetadataUpdateStatus(status manager.TaskStatus) *models.MetadataUpdateStatus {
+	ret := &models.MetadataUpdateStatus{
 		Progress: status.Progress,
 		Status:   status.Status.String(),
 		Message:  "",
 	}
 
-	return &ret, nil
+	if status.SubProgress >= 0 {
+		ret.CurrentFile = &status.CurrentFile
+		ret.SubProgress = &status.SubProgress
+		eta := status.SubRemaining.Seconds()
+		ret.Eta = &eta
+	}
+
+	return ret
 }
 
 func (r *queryResolver) StopJob(ctx context.Context) (bool, error) {
-	return manager.GetInstance().Status.Stop(), nil
+	return manager.GetInstance().StopJob(), nil
 }
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *queryResolver) AllSchedules(ctx context.Context) ([]*models.Schedule, error) {
+	qb := models.NewScheduleQueryBuilder()
+	return qb.All(nil)
+}
+
+func (r *queryResolver) FindSchedule(ctx context.Context, id string) (*models.Schedule, error) {
+	scheduleID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, err
+	}
+
+	qb := models.NewScheduleQueryBuilder()
+	return qb.Find(scheduleID, nil)
+}
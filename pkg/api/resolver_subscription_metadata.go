@@ -18,14 +18,9 @@ func (r *subscriptionResolver) MetadataUpdate(ctx context.Context) (<-chan *mode
 		for {
 			select {
 			case _ = <-ticker.C:
-				thisStatus := manager.GetInstance().Status
+				thisStatus := manager.GetInstance().Status.GetStatus()
 				if thisStatus != lastStatus {
-					ret := models.MetadataUpdateStatus{
-						Progress: thisStatus.Progress,
-						Status:   thisStatus.Status.String(),
-						Message:  "",
-					}
-					msg <- &ret
+					msg <- makeMetadataUpdateStatus(thisStatus)
 				}
 				lastStatus = thisStatus
 			case <-ctx.Done():
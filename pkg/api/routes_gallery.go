@@ -3,7 +3,9 @@ package api
 import (
 	"context"
 	"github.com/go-chi/chi"
+	"github.com/stashapp/stash/pkg/manager"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
 	"net/http"
 	"strconv"
 )
@@ -27,6 +29,17 @@ func (rs galleryRoutes) File(w http.ResponseWriter, r *http.Request) {
 	thumb := r.URL.Query().Get("thumb")
 	w.Header().Add("Cache-Control", "max-age=604800000") // 1 Week
 	if thumb == "true" {
+		// serve the generated thumbnail if there is one. The stored images
+		// are replaced when a scan finds that the gallery has changed.
+		qb := models.NewGalleryImageQueryBuilder()
+		image, _ := qb.Find(gallery.ID, fileIndex, nil)
+		if image != nil {
+			thumbnailPath := manager.GetInstance().Paths.Gallery.GetThumbnailPath(image.Checksum)
+			if exists, _ := utils.FileExists(thumbnailPath); exists {
+				http.ServeFile(w, r, thumbnailPath)
+				return
+			}
+		}
 		_, _ = w.Write(gallery.GetThumbnail(fileIndex))
 	} else {
 		_, _ = w.Write(gallery.GetImage(fileIndex))
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+type imageRoutes struct{}
+
+func (rs imageRoutes) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Route("/{imageId}", func(r chi.Router) {
+		r.Use(ImageCtx)
+		r.Get("/", rs.Image)
+		r.Get("/thumbnail", rs.Thumbnail)
+	})
+
+	return r
+}
+
+func (rs imageRoutes) Image(w http.ResponseWriter, r *http.Request) {
+	image := r.Context().Value(imageKey).(*models.Image)
+	data, err := image.GetData()
+	if err != nil {
+		http.Error(w, http.StatusText(404), 404)
+		return
+	}
+
+	w.Header().Add("Cache-Control", "max-age=604800000") // 1 Week
+	_, _ = w.Write(data)
+}
+
+// Thumbnail serves the generated thumbnail of the image. The thumbnail is
+// generated and cached if it does not exist.
+func (rs imageRoutes) Thumbnail(w http.ResponseWriter, r *http.Request) {
+	image := r.Context().Value(imageKey).(*models.Image)
+	thumbnailPath := manager.GetInstance().Paths.Gallery.GetThumbnailPath(image.Checksum)
+	if exists, _ := utils.FileExists(thumbnailPath); exists {
+		http.ServeFile(w, r, thumbnailPath)
+		return
+	}
+
+	data, err := image.GetData()
+	if err != nil {
+		http.Error(w, http.StatusText(404), 404)
+		return
+	}
+
+	thumbnail, err := models.GetGalleryThumbnail(data)
+	if err != nil {
+		// serve the full image if it cannot be decoded
+		thumbnail = data
+	} else if err := ioutil.WriteFile(thumbnailPath, thumbnail, 0644); err != nil {
+		logger.Warnf("error writing thumbnail of image %d: %s", image.ID, err.Error())
+	}
+
+	w.Header().Add("Cache-Control", "max-age=604800000") // 1 Week
+	_, _ = w.Write(thumbnail)
+}
+
+func ImageCtx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		imageID, err := strconv.Atoi(chi.URLParam(r, "imageId"))
+		if err != nil {
+			http.Error(w, http.StatusText(404), 404)
+			return
+		}
+
+		qb := models.NewImageQueryBuilder()
+		image, err := qb.Find(imageID)
+		if err != nil || image == nil {
+			http.Error(w, http.StatusText(404), 404)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), imageKey, image)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
 
@@ -25,6 +26,9 @@ func (rs sceneRoutes) Routes() chi.Router {
 		r.Use(SceneCtx)
 		r.Get("/stream", rs.Stream)
 		r.Get("/stream.mp4", rs.Stream)
+		r.Get("/stream.m3u8", rs.StreamHLS)
+		r.Get("/hls/{resolution}.m3u8", rs.StreamHLSPlaylist)
+		r.Get("/hls/{resolution}/{segment}.ts", rs.StreamHLSSegment)
 		r.Get("/screenshot", rs.Screenshot)
 		r.Get("/preview", rs.Preview)
 		r.Get("/webp", rs.Webp)
@@ -44,18 +48,44 @@ func (rs sceneRoutes) Routes() chi.Router {
 func (rs sceneRoutes) Stream(w http.ResponseWriter, r *http.Request) {
 	scene := r.Context().Value(sceneKey).(*models.Scene)
 
-	// detect if not a streamable file and try to transcode it instead
-	filepath := manager.GetInstance().Paths.Scene.GetStreamPath(scene.Path, scene.Checksum)
-	videoCodec := scene.VideoCodec.String
-	hasTranscode, _ := manager.HasTranscode(scene)
-	if ffmpeg.IsValidCodec(videoCodec) || hasTranscode {
-		manager.RegisterStream(filepath, &w)
-		http.ServeFile(w, r, filepath)
-		manager.WaitAndDeregisterStream(filepath, &w, r)
+	r.ParseForm()
+
+	// a resolution parameter selects a live transcode at that resolution,
+	// bounded by the max streaming size. The original resolution of a
+	// streamable file is served directly.
+	var resolution models.StreamingResolutionEnum
+	if param := r.Form.Get("resolution"); param != "" {
+		resolution = models.StreamingResolutionEnum(strings.ToUpper(param))
+		if !resolution.IsValid() {
+			http.Error(w, "invalid resolution: "+param, http.StatusBadRequest)
+			return
+		}
+	}
+
+	directStream := manager.IsDirectStreamable(scene)
+	if resolution == models.StreamingResolutionEnumOriginal && directStream {
+		manager.RegisterStream(scene.Path, &w)
+		http.ServeFile(w, r, scene.Path)
+		manager.WaitAndDeregisterStream(scene.Path, &w, r)
 
 		return
 	}
 
+	if resolution == "" {
+		// detect if not a streamable file and try to transcode it instead
+		filepath := manager.GetInstance().Paths.Scene.GetStreamPath(scene.Path, manager.GetSceneHash(scene))
+		hasTranscode, _ := manager.HasTranscode(scene)
+		if directStream || hasTranscode {
+			manager.RegisterStream(filepath, &w)
+			http.ServeFile(w, r, filepath)
+			manager.WaitAndDeregisterStream(filepath, &w, r)
+
+			return
+		}
+
+		resolution = config.GetMaxStreamingTranscodeSize()
+	}
+
 	// needs to be transcoded
 	videoFile, err := ffmpeg.NewVideoFile(manager.GetInstance().FFProbePath, scene.Path)
 	if err != nil {
@@ -64,21 +94,32 @@ func (rs sceneRoutes) Stream(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// start stream based on query param, if provided
-	r.ParseForm()
 	startTime := r.Form.Get("start")
 
 	encoder := ffmpeg.NewEncoder(manager.GetInstance().FFMPEGPath)
-
-	stream, process, err := encoder.StreamTranscode(*videoFile, startTime, config.GetMaxStreamingTranscodeSize())
+	resolution = manager.GetStreamingResolution(resolution)
+
+	var stream io.ReadCloser
+	var process *os.Process
+	var contentType string
+	if resolution == models.StreamingResolutionEnumOriginal && manager.IsRemuxStreamable(scene) {
+		// the video can be played in mp4, so only the container is changed
+		logger.Info("[stream] remuxing video file")
+		stream, process, err = encoder.StreamRemux(*videoFile, startTime)
+		contentType = "video/mp4"
+	} else {
+		logger.Info("[stream] transcoding video file")
+		profile := manager.GetStreamingTranscodeProfile()
+		stream, process, err = encoder.StreamTranscode(*videoFile, startTime, resolution, profile)
+		contentType = ffmpeg.ContainerMimeType(profile.Container)
+	}
 	if err != nil {
 		logger.Errorf("[stream] error transcoding video file: %s", err.Error())
 		return
 	}
 
+	w.Header().Set("Content-Type", contentType)
 	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "video/webm")
-
-	logger.Info("[stream] transcoding video file")
 
 	// handle if client closes the connection
 	notify := r.Context().Done()
@@ -94,21 +135,75 @@ func (rs sceneRoutes) Stream(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (rs sceneRoutes) StreamHLS(w http.ResponseWriter, r *http.Request) {
+	scene := r.Context().Value(sceneKey).(*models.Scene)
+
+	playlist, err := manager.GetHLSMasterPlaylist(scene)
+	if err != nil {
+		logger.Errorf("[stream] error creating HLS playlist: %s", err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+	_, _ = io.WriteString(w, playlist)
+}
+
+func (rs sceneRoutes) StreamHLSPlaylist(w http.ResponseWriter, r *http.Request) {
+	scene := r.Context().Value(sceneKey).(*models.Scene)
+
+	resolution := models.StreamingResolutionEnum(chi.URLParam(r, "resolution"))
+	if !resolution.IsValid() || !manager.IsValidHLSResolution(scene, resolution) {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	playlist, err := manager.GetHLSMediaPlaylist(scene, resolution)
+	if err != nil {
+		logger.Errorf("[stream] error creating HLS playlist: %s", err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+	_, _ = io.WriteString(w, playlist)
+}
+
+func (rs sceneRoutes) StreamHLSSegment(w http.ResponseWriter, r *http.Request) {
+	scene := r.Context().Value(sceneKey).(*models.Scene)
+
+	resolution := models.StreamingResolutionEnum(chi.URLParam(r, "resolution"))
+	segment, err := strconv.Atoi(chi.URLParam(r, "segment"))
+	if !resolution.IsValid() || !manager.IsValidHLSResolution(scene, resolution) || err != nil {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	filepath, err := manager.GetHLSSegment(scene, resolution, segment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "video/mp2t")
+	http.ServeFile(w, r, filepath)
+}
+
 func (rs sceneRoutes) Screenshot(w http.ResponseWriter, r *http.Request) {
 	scene := r.Context().Value(sceneKey).(*models.Scene)
-	filepath := manager.GetInstance().Paths.Scene.GetScreenshotPath(scene.Checksum)
+	filepath := manager.GetInstance().Paths.Scene.GetScreenshotPath(manager.GetSceneHash(scene))
 	http.ServeFile(w, r, filepath)
 }
 
 func (rs sceneRoutes) Preview(w http.ResponseWriter, r *http.Request) {
 	scene := r.Context().Value(sceneKey).(*models.Scene)
-	filepath := manager.GetInstance().Paths.Scene.GetStreamPreviewPath(scene.Checksum)
+	filepath := manager.GetInstance().Paths.Scene.GetStreamPreviewPath(manager.GetSceneHash(scene))
 	http.ServeFile(w, r, filepath)
 }
 
 func (rs sceneRoutes) Webp(w http.ResponseWriter, r *http.Request) {
 	scene := r.Context().Value(sceneKey).(*models.Scene)
-	filepath := manager.GetInstance().Paths.Scene.GetStreamPreviewImagePath(scene.Checksum)
+	filepath := manager.GetInstance().Paths.Scene.GetStreamPreviewImagePath(manager.GetSceneHash(scene))
 	http.ServeFile(w, r, filepath)
 }
 
@@ -164,14 +259,14 @@ func (rs sceneRoutes) ChapterVtt(w http.ResponseWriter, r *http.Request) {
 func (rs sceneRoutes) VttThumbs(w http.ResponseWriter, r *http.Request) {
 	scene := r.Context().Value(sceneKey).(*models.Scene)
 	w.Header().Set("Content-Type", "text/vtt")
-	filepath := manager.GetInstance().Paths.Scene.GetSpriteVttFilePath(scene.Checksum)
+	filepath := manager.GetInstance().Paths.Scene.GetSpriteVttFilePath(manager.GetSceneHash(scene))
 	http.ServeFile(w, r, filepath)
 }
 
 func (rs sceneRoutes) VttSprite(w http.ResponseWriter, r *http.Request) {
 	scene := r.Context().Value(sceneKey).(*models.Scene)
 	w.Header().Set("Content-Type", "image/jpeg")
-	filepath := manager.GetInstance().Paths.Scene.GetSpriteImageFilePath(scene.Checksum)
+	filepath := manager.GetInstance().Paths.Scene.GetSpriteImageFilePath(manager.GetSceneHash(scene))
 	http.ServeFile(w, r, filepath)
 }
 
@@ -185,7 +280,7 @@ func (rs sceneRoutes) SceneMarkerStream(w http.ResponseWriter, r *http.Request)
 		http.Error(w, http.StatusText(404), 404)
 		return
 	}
-	filepath := manager.GetInstance().Paths.SceneMarkers.GetStreamPath(scene.Checksum, int(sceneMarker.Seconds))
+	filepath := manager.GetInstance().Paths.SceneMarkers.GetStreamPath(manager.GetSceneHash(scene), int(sceneMarker.Seconds))
 	http.ServeFile(w, r, filepath)
 }
 
@@ -199,7 +294,7 @@ func (rs sceneRoutes) SceneMarkerPreview(w http.ResponseWriter, r *http.Request)
 		http.Error(w, http.StatusText(404), 404)
 		return
 	}
-	filepath := manager.GetInstance().Paths.SceneMarkers.GetStreamPreviewImagePath(scene.Checksum, int(sceneMarker.Seconds))
+	filepath := manager.GetInstance().Paths.SceneMarkers.GetStreamPreviewImagePath(manager.GetSceneHash(scene), int(sceneMarker.Seconds))
 
 	// If the image doesn't exist, send the placeholder
 	exists, _ := utils.FileExists(filepath)
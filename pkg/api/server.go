@@ -106,6 +106,7 @@ func Start() {
 	r.Handle("/playground", handler.Playground("GraphQL playground", "/graphql"))
 
 	r.Mount("/gallery", galleryRoutes{}.Routes())
+	r.Mount("/image", imageRoutes{}.Routes())
 	r.Mount("/performer", performerRoutes{}.Routes())
 	r.Mount("/scene", sceneRoutes{}.Routes())
 	r.Mount("/studio", studioRoutes{}.Routes())
@@ -174,7 +175,13 @@ func Start() {
 
 		_ = os.Mkdir(downloads, 0755)
 
-		config.Set(config.Stash, stash)
+		config.SetStashes([]*models.StashConfig{{
+			Path:         stash,
+			ExcludeVideo: r.Form.Get("stash_exclude_video") != "",
+			ExcludeImage: r.Form.Get("stash_exclude_image") != "",
+			SkipGenerate: r.Form.Get("stash_skip_generate") != "",
+			ReadOnly:     r.Form.Get("stash_read_only") != "",
+		}})
 		config.Set(config.Generated, generated)
 		config.Set(config.Metadata, metadata)
 		config.Set(config.Cache, cache)
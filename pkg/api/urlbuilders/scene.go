@@ -21,6 +21,14 @@ func (b SceneURLBuilder) GetStreamURL() string {
 	return b.BaseURL + "/scene/" + b.SceneID + "/stream.mp4"
 }
 
+func (b SceneURLBuilder) GetStreamResolutionURL(resolution string) string {
+	return b.BaseURL + "/scene/" + b.SceneID + "/stream?resolution=" + resolution
+}
+
+func (b SceneURLBuilder) GetHLSStreamURL() string {
+	return b.BaseURL + "/scene/" + b.SceneID + "/stream.m3u8"
+}
+
 func (b SceneURLBuilder) GetStreamPreviewURL() string {
 	return b.BaseURL + "/scene/" + b.SceneID + "/preview"
 }
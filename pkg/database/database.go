@@ -1,10 +1,10 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
-	"os"
 	"regexp"
 
 	"github.com/gobuffalo/packr/v2"
@@ -17,7 +17,7 @@ import (
 )
 
 var DB *sqlx.DB
-var appSchemaVersion uint = 2
+var appSchemaVersion uint = 14
 
 const sqlite3Driver = "sqlite3_regexp"
 
@@ -39,20 +39,86 @@ func Initialize(databasePath string) {
 	DB = conn
 }
 
-func Reset(databasePath string) error {
-	err := DB.Close()
+// preservedTables are kept by Reset, so that the job queue, job reports and
+// schedules survive an import.
+var preservedTables = []string{
+	"jobs",
+	"job_report_items",
+	"schedules",
+	"schema_migrations",
+	"sqlite_sequence",
+}
+
+// Reset removes the library data from the database, keeping the preserved
+// tables.
+func Reset() error {
+	ctx := context.TODO()
+	conn, err := DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
 
+	tables, err := listTables(ctx, conn)
 	if err != nil {
-		return errors.New("Error closing database: " + err.Error())
+		return errors.New("Error listing tables: " + err.Error())
+	}
+
+	// foreign keys cannot be changed inside a transaction, and the tables are
+	// cleared in no particular order
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return err
 	}
+	defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
 
-	err = os.Remove(databasePath)
+	tx, err := conn.BeginTx(ctx, nil)
 	if err != nil {
-		return errors.New("Error removing database: " + err.Error())
+		return err
 	}
 
-	Initialize(databasePath)
-	return nil
+	hasSequence := utils.StrInclude(tables, "sqlite_sequence")
+	for _, table := range tables {
+		if utils.StrInclude(preservedTables, table) {
+			continue
+		}
+
+		if _, err := tx.Exec("DELETE FROM `" + table + "`"); err != nil {
+			_ = tx.Rollback()
+			return errors.New("Error clearing " + table + ": " + err.Error())
+		}
+		if hasSequence {
+			if _, err := tx.Exec("DELETE FROM sqlite_sequence WHERE name = ?", table); err != nil {
+				_ = tx.Rollback()
+				return err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// reclaim the space of the removed rows
+	_, err = conn.ExecContext(ctx, "VACUUM")
+	return err
+}
+
+func listTables(ctx context.Context, conn *sql.Conn) ([]string, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT name FROM sqlite_master WHERE type = 'table'")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ret []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		ret = append(ret, name)
+	}
+	return ret, rows.Err()
 }
 
 // Migrate the database
@@ -0,0 +1,25 @@
+ALTER TABLE `galleries` ADD COLUMN `title` varchar(255);
+ALTER TABLE `galleries` ADD COLUMN `details` text;
+ALTER TABLE `galleries` ADD COLUMN `url` varchar(255);
+ALTER TABLE `galleries` ADD COLUMN `date` date;
+ALTER TABLE `galleries` ADD COLUMN `rating` tinyint;
+ALTER TABLE `galleries` ADD COLUMN `studio_id` integer REFERENCES `studios`(`id`);
+CREATE INDEX `index_galleries_on_studio_id` on `galleries` (`studio_id`);
+
+CREATE TABLE `performers_galleries` (
+  `performer_id` integer,
+  `gallery_id` integer,
+  foreign key(`performer_id`) references `performers`(`id`),
+  foreign key(`gallery_id`) references `galleries`(`id`) on delete CASCADE
+);
+CREATE INDEX `index_performers_galleries_on_gallery_id` on `performers_galleries` (`gallery_id`);
+CREATE INDEX `index_performers_galleries_on_performer_id` on `performers_galleries` (`performer_id`);
+
+CREATE TABLE `galleries_tags` (
+  `gallery_id` integer,
+  `tag_id` integer,
+  foreign key(`gallery_id`) references `galleries`(`id`) on delete CASCADE,
+  foreign key(`tag_id`) references `tags`(`id`)
+);
+CREATE INDEX `index_galleries_tags_on_tag_id` on `galleries_tags` (`tag_id`);
+CREATE INDEX `index_galleries_tags_on_gallery_id` on `galleries_tags` (`gallery_id`);
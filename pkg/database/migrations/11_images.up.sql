@@ -0,0 +1,33 @@
+CREATE TABLE `images` (
+  `id` integer not null primary key autoincrement,
+  `path` varchar(510),
+  `checksum` varchar(255) not null,
+  `title` varchar(255),
+  `rating` tinyint,
+  `size` varchar(255),
+  `width` integer,
+  `height` integer,
+  `file_mod_time` datetime,
+  `created_at` datetime not null,
+  `updated_at` datetime not null
+);
+CREATE UNIQUE INDEX `images_path_unique` on `images` (`path`);
+CREATE UNIQUE INDEX `images_checksum_unique` on `images` (`checksum`);
+
+CREATE TABLE `performers_images` (
+  `performer_id` integer,
+  `image_id` integer,
+  foreign key(`performer_id`) references `performers`(`id`),
+  foreign key(`image_id`) references `images`(`id`) on delete CASCADE
+);
+CREATE INDEX `index_performers_images_on_image_id` on `performers_images` (`image_id`);
+CREATE INDEX `index_performers_images_on_performer_id` on `performers_images` (`performer_id`);
+
+CREATE TABLE `images_tags` (
+  `image_id` integer,
+  `tag_id` integer,
+  foreign key(`image_id`) references `images`(`id`) on delete CASCADE,
+  foreign key(`tag_id`) references `tags`(`id`)
+);
+CREATE INDEX `index_images_tags_on_tag_id` on `images_tags` (`tag_id`);
+CREATE INDEX `index_images_tags_on_image_id` on `images_tags` (`image_id`);
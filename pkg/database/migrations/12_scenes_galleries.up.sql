@@ -0,0 +1,41 @@
+CREATE TABLE `scenes_galleries` (
+  `scene_id` integer,
+  `gallery_id` integer,
+  foreign key(`scene_id`) references `scenes`(`id`) on delete CASCADE,
+  foreign key(`gallery_id`) references `galleries`(`id`) on delete CASCADE
+);
+CREATE INDEX `index_scenes_galleries_on_scene_id` on `scenes_galleries` (`scene_id`);
+CREATE INDEX `index_scenes_galleries_on_gallery_id` on `scenes_galleries` (`gallery_id`);
+
+INSERT INTO `scenes_galleries` (`scene_id`, `gallery_id`)
+  SELECT `scene_id`, `id` FROM `galleries` WHERE `scene_id` IS NOT NULL;
+
+-- galleries are recreated without the scene_id column
+CREATE TABLE `galleries_new` (
+  `id` integer not null primary key autoincrement,
+  `path` varchar(510) not null,
+  `checksum` varchar(255) not null,
+  `title` varchar(255),
+  `details` text,
+  `url` varchar(255),
+  `date` date,
+  `rating` tinyint,
+  `studio_id` integer,
+  `size` varchar(255),
+  `file_mod_time` datetime,
+  `corrupt` boolean not null default '0',
+  `created_at` datetime not null,
+  `updated_at` datetime not null,
+  foreign key(`studio_id`) references `studios`(`id`)
+);
+
+INSERT INTO `galleries_new` (`id`, `path`, `checksum`, `title`, `details`, `url`, `date`, `rating`, `studio_id`, `size`, `file_mod_time`, `corrupt`, `created_at`, `updated_at`)
+  SELECT `id`, `path`, `checksum`, `title`, `details`, `url`, `date`, `rating`, `studio_id`, `size`, `file_mod_time`, `corrupt`, `created_at`, `updated_at` FROM `galleries`;
+
+DROP TABLE `galleries`;
+ALTER TABLE `galleries_new` RENAME TO `galleries`;
+
+CREATE UNIQUE INDEX `galleries_path_unique` on `galleries` (`path`);
+CREATE UNIQUE INDEX `galleries_checksum_unique` on `galleries` (`checksum`);
+CREATE INDEX `index_galleries_on_size` on `galleries` (`size`);
+CREATE INDEX `index_galleries_on_studio_id` on `galleries` (`studio_id`);
@@ -0,0 +1 @@
+ALTER TABLE `scenes` ADD COLUMN `format` varchar(255);
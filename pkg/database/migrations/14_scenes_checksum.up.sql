@@ -0,0 +1,39 @@
+-- scenes is recreated with a nullable, non-unique checksum, since the MD5
+-- checksum of scene files is only calculated when it is needed, and
+-- duplicate scene files share the same checksum
+CREATE TABLE `scenes_new` (
+  `id` integer not null primary key autoincrement,
+  `path` varchar(510) not null,
+  `checksum` varchar(255),
+  `title` varchar(255),
+  `details` text,
+  `url` varchar(255),
+  `date` date,
+  `rating` tinyint,
+  `size` varchar(255),
+  `duration` float,
+  `video_codec` varchar(255),
+  `audio_codec` varchar(255),
+  `width` tinyint,
+  `height` tinyint,
+  `framerate` float,
+  `bitrate` integer,
+  `studio_id` integer,
+  `created_at` datetime not null,
+  `updated_at` datetime not null,
+  `cover` blob,
+  `file_mod_time` datetime,
+  `format` varchar(255),
+  foreign key(`studio_id`) references `studios`(`id`) on delete CASCADE
+);
+
+INSERT INTO `scenes_new` (`id`, `path`, `checksum`, `title`, `details`, `url`, `date`, `rating`, `size`, `duration`, `video_codec`, `audio_codec`, `width`, `height`, `framerate`, `bitrate`, `studio_id`, `created_at`, `updated_at`, `cover`, `file_mod_time`, `format`)
+  SELECT `id`, `path`, `checksum`, `title`, `details`, `url`, `date`, `rating`, `size`, `duration`, `video_codec`, `audio_codec`, `width`, `height`, `framerate`, `bitrate`, `studio_id`, `created_at`, `updated_at`, `cover`, `file_mod_time`, `format` FROM `scenes`;
+
+DROP TABLE `scenes`;
+ALTER TABLE `scenes_new` RENAME TO `scenes`;
+
+CREATE UNIQUE INDEX `scenes_path_unique` on `scenes` (`path`);
+CREATE INDEX `index_scenes_on_studio_id` on `scenes` (`studio_id`);
+CREATE INDEX `index_scenes_on_size` on `scenes` (`size`);
+CREATE INDEX `index_scenes_on_checksum` on `scenes` (`checksum`);
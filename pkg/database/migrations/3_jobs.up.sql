@@ -0,0 +1,10 @@
+CREATE TABLE `jobs` (
+  `id` integer not null primary key autoincrement,
+  `type` integer not null,
+  `state` varchar(255) not null,
+  `position` integer not null,
+  `input` text,
+  `created_at` datetime not null,
+  `updated_at` datetime not null
+);
+CREATE INDEX `index_jobs_on_state` on `jobs` (`state`);
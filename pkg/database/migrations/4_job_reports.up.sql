@@ -0,0 +1,11 @@
+ALTER TABLE `jobs` ADD COLUMN `started_at` datetime;
+ALTER TABLE `jobs` ADD COLUMN `finished_at` datetime;
+CREATE TABLE `job_report_items` (
+  `id` integer not null primary key autoincrement,
+  `job_id` integer not null,
+  `type` varchar(255) not null,
+  `path` text not null,
+  `message` text,
+  foreign key(`job_id`) references `jobs`(`id`) on delete CASCADE
+);
+CREATE INDEX `index_job_report_items_on_job_id` on `job_report_items` (`job_id`);
@@ -0,0 +1,5 @@
+ALTER TABLE `scenes` ADD COLUMN `file_mod_time` datetime;
+ALTER TABLE `galleries` ADD COLUMN `size` varchar(255);
+ALTER TABLE `galleries` ADD COLUMN `file_mod_time` datetime;
+CREATE INDEX `index_scenes_on_size` on `scenes` (`size`);
+CREATE INDEX `index_galleries_on_size` on `galleries` (`size`);
@@ -0,0 +1,9 @@
+CREATE TABLE `scene_fingerprints` (
+  `id` integer not null primary key autoincrement,
+  `scene_id` integer not null,
+  `type` varchar(255) not null,
+  `fingerprint` varchar(255) not null,
+  foreign key(`scene_id`) references `scenes`(`id`) on delete CASCADE
+);
+CREATE UNIQUE INDEX `index_scene_fingerprints_on_scene_id_type` on `scene_fingerprints` (`scene_id`, `type`);
+CREATE INDEX `index_scene_fingerprints_on_type_fingerprint` on `scene_fingerprints` (`type`, `fingerprint`);
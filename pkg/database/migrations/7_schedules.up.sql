@@ -0,0 +1,12 @@
+CREATE TABLE `schedules` (
+  `id` integer not null primary key autoincrement,
+  `name` varchar(255) not null,
+  `schedule` varchar(255) not null,
+  `type` integer not null,
+  `input` text,
+  `enabled` boolean not null default '1',
+  `last_run_at` datetime,
+  `next_run_at` datetime,
+  `created_at` datetime not null,
+  `updated_at` datetime not null
+);
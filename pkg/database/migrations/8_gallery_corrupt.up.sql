@@ -0,0 +1 @@
+ALTER TABLE `galleries` ADD COLUMN `corrupt` boolean not null default '0';
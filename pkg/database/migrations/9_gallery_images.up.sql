@@ -0,0 +1,12 @@
+CREATE TABLE `gallery_images` (
+  `id` integer not null primary key autoincrement,
+  `gallery_id` integer not null,
+  `file_index` integer not null,
+  `name` varchar(255) not null,
+  `checksum` varchar(255) not null,
+  `width` integer,
+  `height` integer,
+  foreign key(`gallery_id`) references `galleries`(`id`) on delete CASCADE
+);
+CREATE UNIQUE INDEX `index_gallery_images_on_gallery_id_file_index` on `gallery_images` (`gallery_id`, `file_index`);
+CREATE INDEX `index_gallery_images_on_checksum` on `gallery_images` (`checksum`);
@@ -0,0 +1,99 @@
+package ffmpeg
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+type Container string
+
+const (
+	Mp4      Container = "mp4"
+	M4v      Container = "m4v"
+	Mov      Container = "mov"
+	Webm     Container = "webm"
+	Matroska Container = "matroska"
+	Avi      Container = "avi"
+	Wmv      Container = "asf"
+	Flv      Container = "flv"
+	Mpegts   Container = "mpegts"
+)
+
+// containerExtensions are the containers of file extensions, used when the
+// format name reported by ffprobe is shared by several containers.
+var containerExtensions = map[string]Container{
+	".mp4":  Mp4,
+	".m4v":  M4v,
+	".mov":  Mov,
+	".webm": Webm,
+	".mkv":  Matroska,
+	".avi":  Avi,
+	".wmv":  Wmv,
+	".flv":  Flv,
+	".ts":   Mpegts,
+}
+
+// MatchContainer returns the container of the file from the format name
+// reported by ffprobe, such as "mov,mp4,m4a,3gp,3g2,mj2". The file extension
+// is used to tell apart the containers of a format, or if the format is
+// unknown.
+func MatchContainer(format string, path string) Container {
+	ext := Container("")
+	if c, ok := containerExtensions[strings.ToLower(filepath.Ext(path))]; ok {
+		ext = c
+	}
+
+	switch {
+	case format == "":
+		return ext
+	case strings.HasPrefix(format, "mov,mp4"):
+		if ext == Mov || ext == M4v {
+			return ext
+		}
+		return Mp4
+	case strings.HasPrefix(format, "matroska"):
+		if ext == Webm {
+			return Webm
+		}
+		return Matroska
+	}
+
+	// other formats have a single container
+	return Container(strings.Split(format, ",")[0])
+}
+
+// containerVideoCodecs and containerAudioCodecs are the codecs that browsers
+// can play in each container. An empty audio codec is a file without audio.
+var containerVideoCodecs = map[Container][]string{
+	Mp4:  {"h264", "h265"},
+	M4v:  {"h264", "h265"},
+	Mov:  {"h264", "h265"},
+	Webm: {"vp8", "vp9"},
+}
+
+var containerAudioCodecs = map[Container][]string{
+	Mp4:  {"", "aac", "mp3"},
+	M4v:  {"", "aac", "mp3"},
+	Mov:  {"", "aac", "mp3"},
+	Webm: {"", "vorbis", "opus"},
+}
+
+// IsValidVideoForContainer returns true if browsers can play the video codec
+// in the container.
+func IsValidVideoForContainer(videoCodec string, container Container) bool {
+	return utils.StrInclude(containerVideoCodecs[container], videoCodec)
+}
+
+// IsValidAudioForContainer returns true if browsers can play the audio codec
+// in the container.
+func IsValidAudioForContainer(audioCodec string, container Container) bool {
+	return utils.StrInclude(containerAudioCodecs[container], audioCodec)
+}
+
+// IsStreamable returns true if browsers can play the file directly, without
+// remuxing or transcoding it.
+func IsStreamable(videoCodec string, audioCodec string, container Container) bool {
+	return IsValidVideoForContainer(videoCodec, container) && IsValidAudioForContainer(audioCodec, container)
+}
@@ -0,0 +1,48 @@
+package ffmpeg
+
+import "testing"
+
+func TestMatchContainer(t *testing.T) {
+	scenarios := []struct {
+		format   string
+		path     string
+		expected Container
+	}{
+		{"mov,mp4,m4a,3gp,3g2,mj2", "video.mp4", Mp4},
+		{"mov,mp4,m4a,3gp,3g2,mj2", "video.MOV", Mov},
+		{"matroska,webm", "video.mkv", Matroska},
+		{"matroska,webm", "video.webm", Webm},
+		{"avi", "video.avi", Avi},
+		{"", "video.mkv", Matroska},
+		{"", "video.unknown", ""},
+	}
+
+	for _, s := range scenarios {
+		if container := MatchContainer(s.format, s.path); container != s.expected {
+			t.Errorf("%s %s: was expecting %s, found %s", s.format, s.path, s.expected, container)
+		}
+	}
+}
+
+func TestIsStreamable(t *testing.T) {
+	scenarios := []struct {
+		videoCodec string
+		audioCodec string
+		container  Container
+		expected   bool
+	}{
+		{"h264", "aac", Mp4, true},
+		{"h264", "", Mp4, true},
+		{"h264", "ac3", Mp4, false},
+		{"h264", "aac", Matroska, false},
+		{"vp9", "opus", Webm, true},
+		{"vp9", "aac", Webm, false},
+		{"mpeg4", "mp3", Avi, false},
+	}
+
+	for _, s := range scenarios {
+		if streamable := IsStreamable(s.videoCodec, s.audioCodec, s.container); streamable != s.expected {
+			t.Errorf("%s/%s in %s: was expecting %v, found %v", s.videoCodec, s.audioCodec, s.container, s.expected, streamable)
+		}
+	}
+}
@@ -1,9 +1,13 @@
 package ffmpeg
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
 	"os"
 	"os/exec"
 	"strings"
@@ -15,6 +19,14 @@ import (
 
 type Encoder struct {
 	Path string
+
+	// Context kills the running ffmpeg process when it is done. Processes
+	// run to completion if nil.
+	Context context.Context
+
+	// Progress is called with the progress of each encode, from 0 to 1,
+	// and the estimated time remaining. Progress is not reported if nil.
+	Progress func(progress float64, remaining time.Duration)
 }
 
 var (
@@ -84,7 +96,13 @@ func KillRunningEncoders(path string) {
 }
 
 func (e *Encoder) run(probeResult VideoFile, args []string) (string, error) {
-	cmd := exec.Command(e.Path, args...)
+	if e.Progress != nil {
+		// write the progress as key=value lines, which are printed whatever
+		// the log level
+		args = append([]string{"-progress", "pipe:2"}, args...)
+	}
+
+	cmd := e.command(args)
 
 	stderr, err := cmd.StderrPipe()
 	if err != nil {
@@ -96,28 +114,32 @@ func (e *Encoder) run(probeResult VideoFile, args []string) (string, error) {
 		logger.Error("FFMPEG stdout not available: " + err.Error())
 	}
 
+	start := time.Now()
 	if err = cmd.Start(); err != nil {
 		return "", err
 	}
 
-	buf := make([]byte, 80)
 	var errBuilder strings.Builder
-	for {
-		n, err := stderr.Read(buf)
-		if n > 0 {
-			data := string(buf[0:n])
-			time := GetTimeFromRegex(data)
-			if time > 0 && probeResult.Duration > 0 {
-				progress := time / probeResult.Duration
-				logger.Infof("Progress %.2f", progress)
+	scanner := bufio.NewScanner(stderr)
+	scanner.Buffer(make([]byte, 4096), 1024*1024)
+	scanner.Split(scanStderrLines)
+	for scanner.Scan() {
+		line := scanner.Text()
+		encodedTime := GetTimeFromRegex(line)
+		if encodedTime > 0 && probeResult.Duration > 0 {
+			progress := math.Min(encodedTime/probeResult.Duration, 1)
+			logger.Debugf("Progress %.2f", progress)
+			if e.Progress != nil {
+				e.Progress(progress, estimateRemaining(start, progress))
 			}
-
-			errBuilder.WriteString(data)
 		}
-		if err != nil {
-			break
+
+		if e.Progress == nil || !ProgressLineRegex.MatchString(line) {
+			errBuilder.WriteString(line + "\n")
 		}
 	}
+	// keep draining if a line was too long, so that ffmpeg is not blocked
+	_, _ = io.Copy(ioutil.Discard, stderr)
 
 	stdoutData, _ := ioutil.ReadAll(stdout)
 	stdoutString := string(stdoutData)
@@ -125,6 +147,11 @@ func (e *Encoder) run(probeResult VideoFile, args []string) (string, error) {
 	registerRunningEncoder(probeResult.Path, cmd.Process)
 	err = waitAndDeregister(probeResult.Path, cmd)
 
+	if e.Context != nil && e.Context.Err() != nil {
+		// the process was killed
+		return stdoutString, e.Context.Err()
+	}
+
 	if err != nil {
 		// error message should be in the stderr stream
 		logger.Errorf("ffmpeg error when running command <%s>: %s", strings.Join(cmd.Args, " "), errBuilder.String())
@@ -134,8 +161,37 @@ func (e *Encoder) run(probeResult VideoFile, args []string) (string, error) {
 	return stdoutString, nil
 }
 
+func (e *Encoder) command(args []string) *exec.Cmd {
+	if e.Context != nil {
+		return exec.CommandContext(e.Context, e.Path, args...)
+	}
+	return exec.Command(e.Path, args...)
+}
+
+// scanStderrLines splits ffmpeg output into lines, which may end with a
+// carriage return when ffmpeg overwrites the stats line.
+func scanStderrLines(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// estimateRemaining returns the time remaining until an encode that started
+// at start completes, from its progress so far.
+func estimateRemaining(start time.Time, progress float64) time.Duration {
+	if progress <= 0 {
+		return 0
+	}
+	elapsed := time.Since(start)
+	return time.Duration(float64(elapsed) * (1 - progress) / progress)
+}
+
 func (e *Encoder) stream(probeResult VideoFile, args []string) (io.ReadCloser, *os.Process, error) {
-	cmd := exec.Command(e.Path, args...)
+	cmd := e.command(args)
 
 	stdout, err := cmd.StdoutPipe()
 	if nil != err {
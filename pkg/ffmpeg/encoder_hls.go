@@ -0,0 +1,45 @@
+package ffmpeg
+
+import (
+	"strconv"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+type HLSSegmentOptions struct {
+	// Start and Duration of the segment in seconds
+	Start      float64
+	Duration   float64
+	Resolution models.StreamingResolutionEnum
+	// Profile must encode H.264. Its container is ignored.
+	Profile    models.TranscodeProfile
+	OutputPath string
+}
+
+// HLSSegment transcodes a segment of the video to an MPEG-TS file with the
+// profile. The timestamps of the output are offset by the segment start, so
+// that consecutive segments play back as a single stream.
+func (e *Encoder) HLSSegment(probeResult VideoFile, options HLSSegmentOptions) error {
+	scale := calculateTranscodeScale(probeResult, options.Resolution)
+	start := strconv.FormatFloat(options.Start, 'f', 3, 64)
+
+	profile := options.Profile
+	profile.Container = "mpegts"
+
+	args := transcodeProfileInputArgs(profile)
+	args = append(args,
+		"-v", "error",
+		"-ss", start,
+		"-i", probeResult.Path,
+		"-t", strconv.FormatFloat(options.Duration, 'f', 3, 64),
+		"-max_muxing_queue_size", "1024", // https://trac.ffmpeg.org/ticket/6375
+		"-y",
+		"-force_key_frames", "expr:gte(t,0)",
+		"-output_ts_offset", start,
+	)
+	args = append(args, transcodeProfileArgs(profile, scale)...)
+	args = append(args, options.OutputPath)
+
+	_, err := e.run(probeResult, args)
+	return err
+}
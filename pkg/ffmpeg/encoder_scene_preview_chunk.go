@@ -8,36 +8,47 @@ import (
 )
 
 type ScenePreviewChunkOptions struct {
-	Time       int
+	Time       float64
+	Duration   float64
 	Width      int
+	Preset     string
+	Audio      bool
 	OutputPath string
 }
 
-func (e *Encoder) ScenePreviewVideoChunk(probeResult VideoFile, options ScenePreviewChunkOptions) {
+func (e *Encoder) ScenePreviewVideoChunk(probeResult VideoFile, options ScenePreviewChunkOptions) error {
 	args := []string{
 		"-v", "error",
-		"-ss", strconv.Itoa(options.Time),
+		"-ss", strconv.FormatFloat(options.Time, 'f', 3, 64),
 		"-i", probeResult.Path,
-		"-t", "0.75",
+		"-t", strconv.FormatFloat(options.Duration, 'f', 3, 64),
 		"-max_muxing_queue_size", "1024", // https://trac.ffmpeg.org/ticket/6375
 		"-y",
 		"-c:v", "libx264",
 		"-pix_fmt", "yuv420p",
 		"-profile:v", "high",
 		"-level", "4.2",
-		"-preset", "veryslow",
+		"-preset", options.Preset,
 		"-crf", "21",
 		"-threads", "4",
 		"-vf", fmt.Sprintf("scale=%v:-2", options.Width),
-		"-c:a", "aac",
-		"-b:a", "128k",
+	}
+
+	if options.Audio {
+		args = append(args, "-c:a", "aac", "-b:a", "128k")
+	} else {
+		args = append(args, "-an")
+	}
+
+	args = append(args,
 		"-strict", "-2",
 		options.OutputPath,
-	}
-	_, _ = e.run(probeResult, args)
+	)
+	_, err := e.run(probeResult, args)
+	return err
 }
 
-func (e *Encoder) ScenePreviewVideoChunkCombine(probeResult VideoFile, concatFilePath string, outputPath string) {
+func (e *Encoder) ScenePreviewVideoChunkCombine(probeResult VideoFile, concatFilePath string, outputPath string) error {
 	args := []string{
 		"-v", "error",
 		"-f", "concat",
@@ -46,7 +57,8 @@ func (e *Encoder) ScenePreviewVideoChunkCombine(probeResult VideoFile, concatFil
 		"-c", "copy",
 		outputPath,
 	}
-	_, _ = e.run(probeResult, args)
+	_, err := e.run(probeResult, args)
+	return err
 }
 
 func (e *Encoder) ScenePreviewVideoToImage(probeResult VideoFile, width int, videoPreviewPath string, outputPath string) error {
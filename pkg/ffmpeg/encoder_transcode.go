@@ -11,22 +11,29 @@ import (
 type TranscodeOptions struct {
 	OutputPath       string
 	MaxTranscodeSize models.StreamingResolutionEnum
+	Profile          models.TranscodeProfile
 }
 
-func calculateTranscodeScale(probeResult VideoFile, maxTranscodeSize models.StreamingResolutionEnum) string {
-	maxSize := 0
-	switch maxTranscodeSize {
+// StreamingResolutionSize returns the size of the smaller dimension of the
+// streaming resolution, or 0 for the original resolution.
+func StreamingResolutionSize(resolution models.StreamingResolutionEnum) int {
+	switch resolution {
 	case models.StreamingResolutionEnumLow:
-		maxSize = 240
+		return 240
 	case models.StreamingResolutionEnumStandard:
-		maxSize = 480
+		return 480
 	case models.StreamingResolutionEnumStandardHd:
-		maxSize = 720
+		return 720
 	case models.StreamingResolutionEnumFullHd:
-		maxSize = 1080
+		return 1080
 	case models.StreamingResolutionEnumFourK:
-		maxSize = 2160
+		return 2160
 	}
+	return 0
+}
+
+func calculateTranscodeScale(probeResult VideoFile, maxTranscodeSize models.StreamingResolutionEnum) string {
+	maxSize := StreamingResolutionSize(maxTranscodeSize)
 
 	// get the smaller dimension of the video file
 	videoSize := probeResult.Height
@@ -51,44 +58,94 @@ func calculateTranscodeScale(probeResult VideoFile, maxTranscodeSize models.Stre
 	return strconv.Itoa(maxSize) + ":-2"
 }
 
-func (e *Encoder) Transcode(probeResult VideoFile, options TranscodeOptions) {
+func (e *Encoder) Transcode(probeResult VideoFile, options TranscodeOptions) error {
 	scale := calculateTranscodeScale(probeResult, options.MaxTranscodeSize)
-	args := []string{
-		"-i", probeResult.Path,
-		"-c:v", "libx264",
-		"-pix_fmt", "yuv420p",
-		"-profile:v", "high",
-		"-level", "4.2",
-		"-preset", "superfast",
-		"-crf", "23",
-		"-vf", "scale=" + scale,
+	args := transcodeProfileInputArgs(options.Profile)
+	args = append(args, "-i", probeResult.Path)
+	args = append(args, transcodeProfileArgs(options.Profile, scale)...)
+	args = append(args, options.OutputPath)
+	_, err := e.run(probeResult, args)
+	return err
+}
+
+// remuxMaps selects the first video and audio streams. Other streams, such as
+// subtitles, cannot always be copied into mp4.
+var remuxMaps = []string{"-map", "0:v:0", "-map", "0:a:0?"}
+
+// TranscodeAudio copies the video stream into an mp4 file, transcoding only
+// the audio to AAC. The video codec must be valid for mp4.
+func (e *Encoder) TranscodeAudio(probeResult VideoFile, options TranscodeOptions) error {
+	args := []string{"-i", probeResult.Path}
+	args = append(args, remuxMaps...)
+	args = append(args,
+		"-c:v", "copy",
 		"-c:a", "aac",
 		"-strict", "-2",
+		"-movflags", "+faststart",
+		"-f", "mp4",
 		options.OutputPath,
-	}
-	_, _ = e.run(probeResult, args)
+	)
+	_, err := e.run(probeResult, args)
+	return err
 }
 
-func (e *Encoder) StreamTranscode(probeResult VideoFile, startTime string, maxTranscodeSize models.StreamingResolutionEnum) (io.ReadCloser, *os.Process, error) {
-	scale := calculateTranscodeScale(probeResult, maxTranscodeSize)
+// Remux copies the video and audio streams into an mp4 file. The codecs must
+// be valid for mp4.
+func (e *Encoder) Remux(probeResult VideoFile, options TranscodeOptions) error {
+	args := []string{"-i", probeResult.Path}
+	args = append(args, remuxMaps...)
+	args = append(args,
+		"-c", "copy",
+		"-movflags", "+faststart",
+		"-f", "mp4",
+		options.OutputPath,
+	)
+	_, err := e.run(probeResult, args)
+	return err
+}
+
+// StreamRemux streams the video copied into a fragmented mp4. The audio is
+// copied if it is valid for mp4, or transcoded to AAC. The video codec must
+// be valid for mp4.
+func (e *Encoder) StreamRemux(probeResult VideoFile, startTime string) (io.ReadCloser, *os.Process, error) {
 	args := []string{}
 
 	if startTime != "" {
 		args = append(args, "-ss", startTime)
 	}
 
+	args = append(args, "-i", probeResult.Path)
+	args = append(args, remuxMaps...)
+	args = append(args, "-c:v", "copy")
+	if IsValidAudioForContainer(probeResult.AudioCodec, Mp4) {
+		args = append(args, "-c:a", "copy")
+	} else {
+		args = append(args, "-c:a", "aac")
+	}
 	args = append(args,
-		"-i", probeResult.Path,
-		"-c:v", "libvpx-vp9",
-		"-vf", "scale="+scale,
-		"-deadline", "realtime",
-		"-cpu-used", "5",
-		"-row-mt", "1",
-		"-crf", "30",
-		"-b:v", "0",
-		"-f", "webm",
+		"-movflags", "frag_keyframe+empty_moov",
+		"-f", "mp4",
 		"pipe:",
 	)
 
 	return e.stream(probeResult, args)
 }
+
+func (e *Encoder) StreamTranscode(probeResult VideoFile, startTime string, maxTranscodeSize models.StreamingResolutionEnum, profile models.TranscodeProfile) (io.ReadCloser, *os.Process, error) {
+	scale := calculateTranscodeScale(probeResult, maxTranscodeSize)
+	args := transcodeProfileInputArgs(profile)
+
+	if startTime != "" {
+		args = append(args, "-ss", startTime)
+	}
+
+	args = append(args, "-i", probeResult.Path)
+	args = append(args, transcodeProfileArgs(profile, scale)...)
+	if profile.Container == "mp4" {
+		// mp4 can only be written to a pipe when fragmented
+		args = append(args, "-movflags", "frag_keyframe+empty_moov")
+	}
+	args = append(args, "pipe:")
+
+	return e.stream(probeResult, args)
+}
@@ -12,17 +12,6 @@ import (
 	"time"
 )
 
-var ValidCodecs = []string{"h264", "h265", "vp8", "vp9"}
-
-func IsValidCodec(codecName string) bool {
-	for _, c := range ValidCodecs {
-		if c == codecName {
-			return true
-		}
-	}
-	return false
-}
-
 type VideoFile struct {
 	JSON        FFProbeJSON
 	AudioStream *FFProbeStream
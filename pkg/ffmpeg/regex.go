@@ -8,6 +8,10 @@ import (
 var TimeRegex = regexp.MustCompile(`time=\s*(\d+):(\d+):(\d+.\d+)`)
 var FrameRegex = regexp.MustCompile(`frame=\s*([0-9]+)`)
 
+// ProgressLineRegex matches the key=value lines written by the -progress
+// option.
+var ProgressLineRegex = regexp.MustCompile(`^\w+=\S*$`)
+
 func GetTimeFromRegex(str string) float64 {
 	regexResult := TimeRegex.FindStringSubmatch(str)
 
@@ -0,0 +1,199 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// DefaultTranscodeProfile returns the built-in profile used for generated
+// transcodes.
+func DefaultTranscodeProfile() models.TranscodeProfile {
+	preset := "superfast"
+	crf := 23
+	audioCodec := "aac"
+	return models.TranscodeProfile{
+		Name:       "h264",
+		VideoCodec: "libx264",
+		Preset:     &preset,
+		Crf:        &crf,
+		AudioCodec: &audioCodec,
+		Container:  "mp4",
+		ExtraArgs: []string{
+			"-pix_fmt", "yuv420p",
+			"-profile:v", "high",
+			"-level", "4.2",
+			"-strict", "-2",
+		},
+	}
+}
+
+// DefaultStreamingTranscodeProfile returns the built-in profile used for
+// live transcoded streams.
+func DefaultStreamingTranscodeProfile() models.TranscodeProfile {
+	crf := 30
+	videoBitrate := "0"
+	return models.TranscodeProfile{
+		Name:         "vp9",
+		VideoCodec:   "libvpx-vp9",
+		Crf:          &crf,
+		VideoBitrate: &videoBitrate,
+		Container:    "webm",
+		ExtraArgs: []string{
+			"-deadline", "realtime",
+			"-cpu-used", "5",
+			"-row-mt", "1",
+		},
+	}
+}
+
+// DefaultHLSTranscodeProfile returns the built-in profile used for HLS
+// streams. HLS segments are always MPEG-TS files, whatever the container of
+// the profile.
+func DefaultHLSTranscodeProfile() models.TranscodeProfile {
+	preset := "veryfast"
+	crf := 23
+	audioCodec := "aac"
+	audioBitrate := "128k"
+	return models.TranscodeProfile{
+		Name:         "hls",
+		VideoCodec:   "libx264",
+		Preset:       &preset,
+		Crf:          &crf,
+		AudioCodec:   &audioCodec,
+		AudioBitrate: &audioBitrate,
+		Container:    "mpegts",
+		ExtraArgs: []string{
+			"-pix_fmt", "yuv420p",
+			"-profile:v", "high",
+			"-level", "4.2",
+			"-ac", "2",
+			"-strict", "-2",
+		},
+	}
+}
+
+// transcodeProfileInputArgs returns the ffmpeg arguments of the profile
+// that come before the input file.
+func transcodeProfileInputArgs(profile models.TranscodeProfile) []string {
+	return append([]string{}, profile.InputArgs...)
+}
+
+// transcodeProfileFilter returns the video filter of the profile. The scale
+// filter is used if the profile does not set one, otherwise {scale} in the
+// profile filter is replaced with the scale.
+func transcodeProfileFilter(profile models.TranscodeProfile, scale string) string {
+	if profile.VideoFilter == nil || *profile.VideoFilter == "" {
+		return "scale=" + scale
+	}
+	return strings.Replace(*profile.VideoFilter, "{scale}", scale, -1)
+}
+
+// transcodeProfileArgs returns the ffmpeg output arguments of the profile,
+// scaling the video with the given scale.
+func transcodeProfileArgs(profile models.TranscodeProfile, scale string) []string {
+	args := []string{"-c:v", profile.VideoCodec}
+	if profile.Preset != nil {
+		args = append(args, "-preset", *profile.Preset)
+	}
+	if profile.Crf != nil {
+		args = append(args, "-crf", strconv.Itoa(*profile.Crf))
+	}
+	if profile.VideoBitrate != nil {
+		args = append(args, "-b:v", *profile.VideoBitrate)
+	}
+	args = append(args, "-vf", transcodeProfileFilter(profile, scale))
+
+	if profile.AudioCodec != nil {
+		args = append(args, "-c:a", *profile.AudioCodec)
+	}
+	if profile.AudioBitrate != nil {
+		args = append(args, "-b:a", *profile.AudioBitrate)
+	}
+
+	args = append(args, profile.ExtraArgs...)
+	return append(args, "-f", profile.Container)
+}
+
+// ContainerMimeType returns the mime type of the ffmpeg output format.
+func ContainerMimeType(container string) string {
+	switch container {
+	case "mp4":
+		return "video/mp4"
+	case "webm":
+		return "video/webm"
+	case "matroska":
+		return "video/x-matroska"
+	case "mpegts":
+		return "video/mp2t"
+	case "ogg":
+		return "video/ogg"
+	}
+	return "application/octet-stream"
+}
+
+// GetEncoders returns the names of the encoders supported by ffmpeg.
+func (e *Encoder) GetEncoders() ([]string, error) {
+	output, err := exec.Command(e.Path, "-hide_banner", "-encoders").Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseEncoders(string(output)), nil
+}
+
+// parseEncoders parses the output of ffmpeg -encoders. The encoders are
+// listed after the legend, one per line following the capability flags.
+func parseEncoders(output string) []string {
+	var ret []string
+	listed := false
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if !listed {
+			listed = strings.HasPrefix(fields[0], "---")
+			continue
+		}
+		if len(fields) > 1 {
+			ret = append(ret, fields[1])
+		}
+	}
+	return ret
+}
+
+// ValidateTranscodeProfile returns an error if the profile is incomplete or
+// uses an encoder that is not in the given encoders. The encoders are not
+// checked if none are given.
+func ValidateTranscodeProfile(profile models.TranscodeProfile, encoders []string) error {
+	if profile.Name == "" {
+		return fmt.Errorf("transcode profile has no name")
+	}
+	if profile.VideoCodec == "" {
+		return fmt.Errorf("transcode profile %s has no video codec", profile.Name)
+	}
+	if profile.Container == "" {
+		return fmt.Errorf("transcode profile %s has no container", profile.Name)
+	}
+
+	if len(encoders) == 0 {
+		return nil
+	}
+
+	codecs := []string{profile.VideoCodec}
+	if profile.AudioCodec != nil {
+		codecs = append(codecs, *profile.AudioCodec)
+	}
+	for _, codec := range codecs {
+		if codec != "copy" && !utils.StrInclude(encoders, codec) {
+			return fmt.Errorf("transcode profile %s uses encoder %s, which is not supported by ffmpeg", profile.Name, codec)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package ffmpeg
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const encodersOutput = `Encoders:
+ V..... = Video
+ A..... = Audio
+ S..... = Subtitle
+ .F.... = Frame-level multithreading
+ ..S... = Slice-level multithreading
+ ...X.. = Codec is experimental
+ ....B. = Supports draw_horiz_band
+ .....D = Supports direct rendering method 1
+ ------
+ V..... libx264              libx264 H.264 / AVC / MPEG-4 AVC / MPEG-4 part 10 (codec h264)
+ V..... h264_vaapi           H.264/AVC (VAAPI) (codec h264)
+ A..... aac                  AAC (Advanced Audio Coding)
+`
+
+func TestParseEncoders(t *testing.T) {
+	encoders := parseEncoders(encodersOutput)
+	if expected := []string{"libx264", "h264_vaapi", "aac"}; !reflect.DeepEqual(encoders, expected) {
+		t.Errorf("Was expecting %v, found %v", expected, encoders)
+	}
+}
+
+func TestValidateTranscodeProfile(t *testing.T) {
+	encoders := parseEncoders(encodersOutput)
+	opus := "libopus"
+
+	scenarios := []struct {
+		profile models.TranscodeProfile
+		valid   bool
+	}{
+		{DefaultTranscodeProfile(), true},
+		{models.TranscodeProfile{Name: "vaapi", VideoCodec: "h264_vaapi", Container: "mp4"}, true},
+		{models.TranscodeProfile{Name: "copy", VideoCodec: "copy", AudioCodec: &opus, Container: "mp4"}, false},
+		{models.TranscodeProfile{Name: "nvenc", VideoCodec: "h264_nvenc", Container: "mp4"}, false},
+		{models.TranscodeProfile{Name: "nocontainer", VideoCodec: "libx264"}, false},
+	}
+
+	for _, s := range scenarios {
+		err := ValidateTranscodeProfile(s.profile, encoders)
+		if (err == nil) != s.valid {
+			t.Errorf("%s: was expecting valid %v, found error %v", s.profile.Name, s.valid, err)
+		}
+	}
+}
+
+func TestTranscodeProfileFilter(t *testing.T) {
+	vaapi := "format=nv12,hwupload,scale_vaapi={scale}"
+
+	scenarios := []struct {
+		profile  models.TranscodeProfile
+		expected string
+	}{
+		{DefaultTranscodeProfile(), "scale=-2:720"},
+		{models.TranscodeProfile{VideoFilter: &vaapi}, "format=nv12,hwupload,scale_vaapi=-2:720"},
+	}
+
+	for _, s := range scenarios {
+		if filter := transcodeProfileFilter(s.profile, "-2:720"); filter != s.expected {
+			t.Errorf("Was expecting %s, found %s", s.expected, filter)
+		}
+	}
+}
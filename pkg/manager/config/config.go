@@ -3,8 +3,12 @@ package config
 import (
 	"golang.org/x/crypto/bcrypt"
 
+	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/viper"
 
@@ -24,9 +28,40 @@ const Database = "database"
 
 const ScrapersPath = "scrapers_path"
 const Exclude = "exclude"
+const VideoExtensions = "video_extensions"
+const GalleryExtensions = "gallery_extensions"
+const ImageExtensions = "image_extensions"
+const CreateGalleriesFromFolders = "create_galleries_from_folders"
+const WatchStashPaths = "watch_stash_paths"
+const CleanThreshold = "clean_threshold"
+
+const ParallelTasks = "parallel_tasks"
+
+const SceneFileNamingHash = "scene_file_naming_hash"
+const CalculateMD5 = "calculate_md5"
 
 const MaxTranscodeSize = "max_transcode_size"
 const MaxStreamingTranscodeSize = "max_streaming_transcode_size"
+const TranscodeProfiles = "transcode_profiles"
+const TranscodeProfile = "transcode_profile"
+const StreamingTranscodeProfile = "streaming_transcode_profile"
+const HLSTranscodeProfile = "hls_transcode_profile"
+
+const PreviewSegments = "preview_segments"
+const PreviewSegmentDuration = "preview_segment_duration"
+const PreviewExcludeStart = "preview_exclude_start"
+const PreviewExcludeEnd = "preview_exclude_end"
+const PreviewPreset = "preview_preset"
+const PreviewAudio = "preview_audio"
+
+// Default values of the preview generation options. Previews generated
+// before the options were configurable were generated with these values.
+const (
+	DefaultPreviewSegments        = 12
+	DefaultPreviewSegmentDuration = 0.75
+	DefaultPreviewPreset          = models.PreviewPresetVeryslow
+	DefaultPreviewAudio           = true
+)
 
 const Host = "host"
 const Port = "port"
@@ -62,8 +97,134 @@ func Write() error {
 	return viper.WriteConfig()
 }
 
+// Keys of the options of each stash path.
+const stashPath = "path"
+const stashExcludeVideo = "exclude_video"
+const stashExcludeImage = "exclude_image"
+const stashSkipGenerate = "skip_generate"
+const stashReadOnly = "read_only"
+const stashScanSchedule = "scan_schedule"
+
+// GetStashes returns the stash paths and their options. Older configs store
+// the stash paths as plain strings, which are read with the default options.
+func GetStashes() []*models.StashConfig {
+	var ret []*models.StashConfig
+	switch value := viper.Get(Stash).(type) {
+	case []interface{}:
+		for _, v := range value {
+			if stash := toStashConfig(v); stash != nil {
+				ret = append(ret, stash)
+			}
+		}
+	case []map[string]interface{}:
+		for _, v := range value {
+			if stash := toStashConfig(v); stash != nil {
+				ret = append(ret, stash)
+			}
+		}
+	default:
+		// a single path may be set as a string, such as from the environment
+		for _, path := range viper.GetStringSlice(Stash) {
+			ret = append(ret, &models.StashConfig{Path: path})
+		}
+	}
+
+	return ret
+}
+
+func toStashConfig(v interface{}) *models.StashConfig {
+	m := make(map[string]interface{})
+	switch v := v.(type) {
+	case string:
+		return &models.StashConfig{Path: v}
+	case map[string]interface{}:
+		m = v
+	case map[interface{}]interface{}:
+		// yaml decodes maps with interface keys
+		for key, value := range v {
+			if k, ok := key.(string); ok {
+				m[k] = value
+			}
+		}
+	default:
+		return nil
+	}
+
+	path, _ := m[stashPath].(string)
+	if path == "" {
+		return nil
+	}
+
+	ret := &models.StashConfig{
+		Path:         path,
+		ExcludeVideo: toBool(m[stashExcludeVideo]),
+		ExcludeImage: toBool(m[stashExcludeImage]),
+		SkipGenerate: toBool(m[stashSkipGenerate]),
+		ReadOnly:     toBool(m[stashReadOnly]),
+	}
+
+	if schedule, _ := m[stashScanSchedule].(string); schedule != "" {
+		ret.ScanSchedule = &schedule
+	}
+
+	return ret
+}
+
+func toBool(v interface{}) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case string:
+		ret, _ := strconv.ParseBool(v)
+		return ret
+	}
+	return false
+}
+
+// SetStashes sets the stash paths and their options.
+func SetStashes(stashes []*models.StashConfig) {
+	var value []map[string]interface{}
+	for _, stash := range stashes {
+		m := map[string]interface{}{
+			stashPath:         stash.Path,
+			stashExcludeVideo: stash.ExcludeVideo,
+			stashExcludeImage: stash.ExcludeImage,
+			stashSkipGenerate: stash.SkipGenerate,
+			stashReadOnly:     stash.ReadOnly,
+		}
+		if stash.ScanSchedule != nil && *stash.ScanSchedule != "" {
+			m[stashScanSchedule] = *stash.ScanSchedule
+		}
+		value = append(value, m)
+	}
+
+	Set(Stash, value)
+}
+
 func GetStashPaths() []string {
-	return viper.GetStringSlice(Stash)
+	var ret []string
+	for _, stash := range GetStashes() {
+		ret = append(ret, stash.Path)
+	}
+	return ret
+}
+
+// GetStashFromPath returns the stash that contains the given path, or nil if
+// the path is not in a stash. If stash paths are nested, the innermost stash
+// is returned.
+func GetStashFromPath(path string) *models.StashConfig {
+	var ret *models.StashConfig
+	for _, stash := range GetStashes() {
+		rel, err := filepath.Rel(stash.Path, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+
+		if ret == nil || len(stash.Path) > len(ret.Path) {
+			ret = stash
+		}
+	}
+	return ret
 }
 
 func GetCachePath() string {
@@ -96,6 +257,64 @@ func GetExcludes() []string {
 	return viper.GetStringSlice(Exclude)
 }
 
+var defaultVideoExtensions = []string{"m4v", "mp4", "mov", "wmv", "avi", "mpg", "mpeg", "rmvb", "rm", "flv", "asf", "mkv", "webm", "ts", "m2ts"}
+var defaultGalleryExtensions = []string{"zip", "cbz"}
+
+// GetVideoExtensions returns the extensions of the files that are scanned as
+// scenes, without the leading dot.
+func GetVideoExtensions() []string {
+	viper.SetDefault(VideoExtensions, defaultVideoExtensions)
+	return trimExtensions(viper.GetStringSlice(VideoExtensions))
+}
+
+// GetGalleryExtensions returns the extensions of the files that are scanned
+// as galleries, without the leading dot.
+func GetGalleryExtensions() []string {
+	viper.SetDefault(GalleryExtensions, defaultGalleryExtensions)
+	return trimExtensions(viper.GetStringSlice(GalleryExtensions))
+}
+
+// GetImageExtensions returns the extensions of the images that are read from
+// galleries, without the leading dot. Only formats that can be decoded are
+// supported, which excludes avif.
+func GetImageExtensions() []string {
+	viper.SetDefault(ImageExtensions, models.DefaultImageExtensions)
+	return trimExtensions(viper.GetStringSlice(ImageExtensions))
+}
+
+func trimExtensions(extensions []string) []string {
+	var ret []string
+	for _, ext := range extensions {
+		ext = strings.TrimPrefix(strings.TrimSpace(ext), ".")
+		if ext != "" {
+			ret = append(ret, ext)
+		}
+	}
+	return ret
+}
+
+// GetCreateGalleriesFromFolders returns true if directories that directly
+// contain images should be scanned as galleries. Defaults to false.
+func GetCreateGalleriesFromFolders() bool {
+	return viper.GetBool(CreateGalleriesFromFolders)
+}
+
+// GetWatchStashPaths returns true if the stash paths should be watched for
+// changes, which are then scanned automatically. Defaults to false.
+func GetWatchStashPaths() bool {
+	return viper.GetBool(WatchStashPaths)
+}
+
+// GetCleanThreshold returns the largest percentage of the library that a
+// clean may remove. Cleans that would remove more are aborted, which guards
+// against removing everything in a stash path that is not mounted. Dry runs
+// are not aborted, but report that a real clean would be. A value of 0
+// disables the check. Defaults to 25.
+func GetCleanThreshold() int {
+	viper.SetDefault(CleanThreshold, 25)
+	return viper.GetInt(CleanThreshold)
+}
+
 func GetScrapersPath() string {
 	return viper.GetString(ScrapersPath)
 }
@@ -108,6 +327,44 @@ func GetPort() int {
 	return viper.GetInt(Port)
 }
 
+// GetParallelTasks returns the number of scan or generate tasks to run at
+// the same time. A value less than 1 uses the number of CPU cores. Defaults
+// to 1.
+func GetParallelTasks() int {
+	viper.SetDefault(ParallelTasks, 1)
+	ret := viper.GetInt(ParallelTasks)
+
+	if ret < 1 {
+		return runtime.NumCPU()
+	}
+
+	return ret
+}
+
+// GetSceneFileNamingHash returns the hash used to name the generated files
+// of scenes. Defaults to MD5.
+func GetSceneFileNamingHash() models.HashAlgorithm {
+	ret := models.HashAlgorithm(viper.GetString(SceneFileNamingHash))
+
+	if !ret.IsValid() {
+		return models.HashAlgorithmMd5
+	}
+
+	return ret
+}
+
+// GetCalculateMD5 returns true if the MD5 checksum of scene files should be
+// calculated when scanning, even if it is not used to name generated files.
+func GetCalculateMD5() bool {
+	return viper.GetBool(CalculateMD5)
+}
+
+// IsCalculateMD5 returns true if scans calculate the MD5 checksum of scene
+// files. The checksum is always calculated while it names generated files.
+func IsCalculateMD5() bool {
+	return GetCalculateMD5() || GetSceneFileNamingHash() == models.HashAlgorithmMd5
+}
+
 func GetMaxTranscodeSize() models.StreamingResolutionEnum {
 	ret := viper.GetString(MaxTranscodeSize)
 
@@ -130,6 +387,202 @@ func GetMaxStreamingTranscodeSize() models.StreamingResolutionEnum {
 	return models.StreamingResolutionEnum(ret)
 }
 
+// Keys of the options of each transcode profile.
+const transcodeProfileName = "name"
+const transcodeProfileVideoCodec = "video_codec"
+const transcodeProfilePreset = "preset"
+const transcodeProfileCrf = "crf"
+const transcodeProfileVideoBitrate = "video_bitrate"
+const transcodeProfileAudioCodec = "audio_codec"
+const transcodeProfileAudioBitrate = "audio_bitrate"
+const transcodeProfileContainer = "container"
+const transcodeProfileExtraArgs = "extra_args"
+const transcodeProfileInputArgs = "input_args"
+const transcodeProfileVideoFilter = "video_filter"
+
+// GetTranscodeProfiles returns the transcode profiles set in the config
+// file. Profiles are not validated.
+func GetTranscodeProfiles() []*models.TranscodeProfile {
+	var ret []*models.TranscodeProfile
+	switch value := viper.Get(TranscodeProfiles).(type) {
+	case []interface{}:
+		for _, v := range value {
+			if profile := toTranscodeProfile(v); profile != nil {
+				ret = append(ret, profile)
+			}
+		}
+	case []map[string]interface{}:
+		for _, v := range value {
+			if profile := toTranscodeProfile(v); profile != nil {
+				ret = append(ret, profile)
+			}
+		}
+	}
+
+	return ret
+}
+
+func toTranscodeProfile(v interface{}) *models.TranscodeProfile {
+	m := make(map[string]interface{})
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m = v
+	case map[interface{}]interface{}:
+		// yaml decodes maps with interface keys
+		for key, value := range v {
+			if k, ok := key.(string); ok {
+				m[k] = value
+			}
+		}
+	default:
+		return nil
+	}
+
+	ret := &models.TranscodeProfile{
+		Name:         toString(m[transcodeProfileName]),
+		VideoCodec:   toString(m[transcodeProfileVideoCodec]),
+		Preset:       toOptionalString(m[transcodeProfilePreset]),
+		VideoBitrate: toOptionalString(m[transcodeProfileVideoBitrate]),
+		AudioCodec:   toOptionalString(m[transcodeProfileAudioCodec]),
+		AudioBitrate: toOptionalString(m[transcodeProfileAudioBitrate]),
+		Container:    toString(m[transcodeProfileContainer]),
+		VideoFilter:  toOptionalString(m[transcodeProfileVideoFilter]),
+	}
+
+	if crf := toOptionalString(m[transcodeProfileCrf]); crf != nil {
+		if value, err := strconv.Atoi(*crf); err == nil {
+			ret.Crf = &value
+		}
+	}
+
+	ret.ExtraArgs = toArgs(m[transcodeProfileExtraArgs])
+	ret.InputArgs = toArgs(m[transcodeProfileInputArgs])
+
+	return ret
+}
+
+// toArgs returns the ffmpeg arguments of a list, or of a single string of
+// arguments separated by spaces.
+func toArgs(v interface{}) []string {
+	switch args := v.(type) {
+	case []interface{}:
+		var ret []string
+		for _, arg := range args {
+			ret = append(ret, toString(arg))
+		}
+		return ret
+	case []string:
+		return args
+	case string:
+		return strings.Fields(args)
+	}
+	return nil
+}
+
+func toString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func toOptionalString(v interface{}) *string {
+	if v == nil {
+		return nil
+	}
+	ret := fmt.Sprint(v)
+	return &ret
+}
+
+// GetTranscodeProfile returns the name of the transcode profile used for
+// generated transcodes, or an empty string for the default profile.
+func GetTranscodeProfile() string {
+	return viper.GetString(TranscodeProfile)
+}
+
+// GetStreamingTranscodeProfile returns the name of the transcode profile
+// used for live transcoded streams, or an empty string for the default
+// profile.
+func GetStreamingTranscodeProfile() string {
+	return viper.GetString(StreamingTranscodeProfile)
+}
+
+// GetHLSTranscodeProfile returns the name of the transcode profile used for
+// HLS streams, or an empty string for the default profile.
+func GetHLSTranscodeProfile() string {
+	return viper.GetString(HLSTranscodeProfile)
+}
+
+// GetPreviewSegments returns the number of segments in a generated preview.
+// Defaults to 12, which is also used if the configured value is less than 1.
+func GetPreviewSegments() int {
+	viper.SetDefault(PreviewSegments, DefaultPreviewSegments)
+	ret := viper.GetInt(PreviewSegments)
+
+	if ret < 1 {
+		return DefaultPreviewSegments
+	}
+
+	return ret
+}
+
+// GetPreviewSegmentDuration returns the length of each preview segment in
+// seconds. Defaults to 0.75, which is also used if the configured value is
+// not greater than 0.
+func GetPreviewSegmentDuration() float64 {
+	viper.SetDefault(PreviewSegmentDuration, DefaultPreviewSegmentDuration)
+	ret := viper.GetFloat64(PreviewSegmentDuration)
+
+	if ret <= 0 {
+		return DefaultPreviewSegmentDuration
+	}
+
+	return ret
+}
+
+// GetPreviewExcludeStart returns the percentage of the video skipped at the
+// start of previews. Defaults to 0, which is also used if the configured
+// value is not a percentage below 100.
+func GetPreviewExcludeStart() float64 {
+	return getPreviewExclude(PreviewExcludeStart)
+}
+
+// GetPreviewExcludeEnd returns the percentage of the video skipped at the
+// end of previews. Defaults to 0, which is also used if the configured
+// value is not a percentage below 100.
+func GetPreviewExcludeEnd() float64 {
+	return getPreviewExclude(PreviewExcludeEnd)
+}
+
+func getPreviewExclude(key string) float64 {
+	ret := viper.GetFloat64(key)
+
+	if ret < 0 || ret >= 100 {
+		return 0
+	}
+
+	return ret
+}
+
+// GetPreviewPreset returns the x264 preset used to encode previews.
+// Defaults to veryslow.
+func GetPreviewPreset() models.PreviewPreset {
+	ret := models.PreviewPreset(viper.GetString(PreviewPreset))
+
+	if !ret.IsValid() {
+		return DefaultPreviewPreset
+	}
+
+	return ret
+}
+
+// GetPreviewAudio returns true if previews include the audio of the video.
+// Defaults to true.
+func GetPreviewAudio() bool {
+	viper.SetDefault(PreviewAudio, DefaultPreviewAudio)
+	return viper.GetBool(PreviewAudio)
+}
+
 func GetUsername() string {
 	return viper.GetString(Username)
 }
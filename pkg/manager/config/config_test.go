@@ -0,0 +1,42 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestPreviewOptionsOutOfRange(t *testing.T) {
+	defer viper.Reset()
+
+	viper.Set(PreviewSegments, 0)
+	viper.Set(PreviewSegmentDuration, -1)
+	viper.Set(PreviewExcludeStart, -10)
+	viper.Set(PreviewExcludeEnd, 100)
+	viper.Set(PreviewPreset, "placebo")
+
+	if got := GetPreviewSegments(); got != DefaultPreviewSegments {
+		t.Errorf("GetPreviewSegments: expected %d, found %d", DefaultPreviewSegments, got)
+	}
+	if got := GetPreviewSegmentDuration(); got != DefaultPreviewSegmentDuration {
+		t.Errorf("GetPreviewSegmentDuration: expected %v, found %v", DefaultPreviewSegmentDuration, got)
+	}
+	if got := GetPreviewExcludeStart(); got != 0 {
+		t.Errorf("GetPreviewExcludeStart: expected 0, found %v", got)
+	}
+	if got := GetPreviewExcludeEnd(); got != 0 {
+		t.Errorf("GetPreviewExcludeEnd: expected 0, found %v", got)
+	}
+	if got := GetPreviewPreset(); got != DefaultPreviewPreset {
+		t.Errorf("GetPreviewPreset: expected %s, found %s", DefaultPreviewPreset, got)
+	}
+
+	viper.Set(PreviewSegments, 20)
+	viper.Set(PreviewExcludeStart, 5.5)
+	if got := GetPreviewSegments(); got != 20 {
+		t.Errorf("GetPreviewSegments: expected 20, found %d", got)
+	}
+	if got := GetPreviewExcludeStart(); got != 5.5 {
+		t.Errorf("GetPreviewExcludeStart: expected 5.5, found %v", got)
+	}
+}
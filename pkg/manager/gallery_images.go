@@ -0,0 +1,126 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// galleryImagesMatch returns true if the stored images of the gallery have
+// the names of the images in the gallery file. Only the names are listed, so
+// the images are not read.
+func galleryImagesMatch(gallery models.Gallery) bool {
+	qb := models.NewGalleryImageQueryBuilder()
+	images, err := qb.FindByGalleryID(gallery.ID, nil)
+	if err != nil {
+		return false
+	}
+
+	reader, err := models.NewGalleryReader(gallery.Path)
+	if err != nil {
+		return false
+	}
+	defer reader.Close()
+
+	names := reader.Names()
+	if len(names) != len(images) {
+		return false
+	}
+	for i, image := range images {
+		if image.FileIndex != i || image.Name != names[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// readGalleryImages reads the images of the gallery, returning their
+// checksums and dimensions. decoded, if not nil, is called with the
+// contents of each image that could be decoded.
+func readGalleryImages(gallery models.Gallery, decoded func(galleryImage models.GalleryImage, data []byte)) ([]models.GalleryImage, error) {
+	reader, err := models.NewGalleryReader(gallery.Path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading gallery: %s", err.Error())
+	}
+	defer reader.Close()
+
+	var images []models.GalleryImage
+	for i, name := range reader.Names() {
+		data, err := reader.Read(i)
+		if err != nil {
+			return nil, fmt.Errorf("error reading gallery image %s: %s", name, err.Error())
+		}
+
+		galleryImage := models.GalleryImage{
+			FileIndex: i,
+			Name:      name,
+			Checksum:  utils.MD5FromBytes(data),
+		}
+
+		// images that cannot be decoded are still listed, and served
+		// without a thumbnail
+		if imageConfig, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+			galleryImage.Width = sql.NullInt64{Int64: int64(imageConfig.Width), Valid: true}
+			galleryImage.Height = sql.NullInt64{Int64: int64(imageConfig.Height), Valid: true}
+
+			if decoded != nil {
+				decoded(galleryImage, data)
+			}
+		} else {
+			logger.Warnf("error decoding %s in %s: %s", name, gallery.Path, err.Error())
+		}
+
+		images = append(images, galleryImage)
+	}
+
+	return images, nil
+}
+
+// saveGalleryImages replaces the stored images of the gallery, and creates
+// the image objects of the images that do not exist yet.
+func saveGalleryImages(galleryID int, images []models.GalleryImage) error {
+	scanMutex.Lock()
+	defer scanMutex.Unlock()
+
+	giqb := models.NewGalleryImageQueryBuilder()
+	qb := models.NewImageQueryBuilder()
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+	if err := giqb.Replace(galleryID, images, tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	currentTime := time.Now()
+	for _, galleryImage := range images {
+		existing, err := qb.FindByChecksum(galleryImage.Checksum, tx)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if existing != nil {
+			continue
+		}
+
+		newImage := models.Image{
+			Checksum:  galleryImage.Checksum,
+			Width:     galleryImage.Width,
+			Height:    galleryImage.Height,
+			CreatedAt: models.SQLiteTimestamp{Timestamp: currentTime},
+			UpdatedAt: models.SQLiteTimestamp{Timestamp: currentTime},
+		}
+		if _, err := qb.Create(newImage, tx); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
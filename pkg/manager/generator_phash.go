@@ -0,0 +1,85 @@
+package manager
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+
+	"github.com/disintegration/imaging"
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+const phashColumns = 5
+const phashRows = 5
+const phashFrameWidth = 160
+
+// PhashGenerator calculates the perceptual hash of a video from a montage
+// of frames taken at equal intervals through the video.
+type PhashGenerator struct {
+	Info *GeneratorInfo
+
+	// TmpPrefix is prefixed to the temporary frame files, so that hashes for
+	// different scenes can be generated at the same time.
+	TmpPrefix string
+}
+
+func NewPhashGenerator(videoFile ffmpeg.VideoFile, tmpPrefix string) (*PhashGenerator, error) {
+	exists, err := utils.FileExists(videoFile.Path)
+	if !exists {
+		return nil, err
+	}
+	generator, err := newGeneratorInfo(videoFile)
+	if err != nil {
+		return nil, err
+	}
+	generator.ChunkCount = phashColumns * phashRows
+
+	return &PhashGenerator{
+		Info:      generator,
+		TmpPrefix: tmpPrefix,
+	}, nil
+}
+
+func (g *PhashGenerator) Generate() (uint64, error) {
+	logger.Infof("[generator] generating phash for %s", g.Info.VideoFile.Path)
+
+	encoder := instance.newJobEncoder()
+	stepSize := g.Info.VideoFile.Duration / float64(g.Info.ChunkCount)
+
+	var images []image.Image
+	for i := 0; i < g.Info.ChunkCount; i++ {
+		time := float64(i) * stepSize
+		framePath := instance.Paths.Generated.GetTmpPath(fmt.Sprintf("%s_phash%.3d.jpg", g.TmpPrefix, i))
+
+		options := ffmpeg.ScreenshotOptions{
+			OutputPath: framePath,
+			Time:       time,
+			Width:      phashFrameWidth,
+		}
+		if err := encoder.Screenshot(g.Info.VideoFile, options); err != nil {
+			_ = os.Remove(framePath)
+			return 0, err
+		}
+
+		img, err := imaging.Open(framePath)
+		_ = os.Remove(framePath)
+		if err != nil {
+			return 0, err
+		}
+		images = append(images, img)
+	}
+
+	width := images[0].Bounds().Size().X
+	height := images[0].Bounds().Size().Y
+	montage := imaging.New(width*phashColumns, height*phashRows, color.NRGBA{})
+	for index, img := range images {
+		x := width * (index % phashColumns)
+		y := height * (index / phashColumns)
+		montage = imaging.Paste(montage, img, image.Pt(x, y))
+	}
+
+	return utils.PerceptualHash(montage), nil
+}
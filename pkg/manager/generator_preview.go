@@ -8,6 +8,7 @@ import (
 	"github.com/stashapp/stash/pkg/utils"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 type PreviewGenerator struct {
@@ -16,9 +17,13 @@ type PreviewGenerator struct {
 	VideoFilename   string
 	ImageFilename   string
 	OutputDirectory string
+	Options         PreviewOptions
+
+	// Overwrite regenerates the preview files even if they exist
+	Overwrite bool
 }
 
-func NewPreviewGenerator(videoFile ffmpeg.VideoFile, videoFilename string, imageFilename string, outputDirectory string) (*PreviewGenerator, error) {
+func NewPreviewGenerator(videoFile ffmpeg.VideoFile, videoFilename string, imageFilename string, outputDirectory string, options PreviewOptions) (*PreviewGenerator, error) {
 	exists, err := utils.FileExists(videoFile.Path)
 	if !exists {
 		return nil, err
@@ -27,7 +32,7 @@ func NewPreviewGenerator(videoFile ffmpeg.VideoFile, videoFilename string, image
 	if err != nil {
 		return nil, err
 	}
-	generator.ChunkCount = 12 // 12 segments to the preview
+	generator.ChunkCount = options.Segments
 	if err := generator.configure(); err != nil {
 		return nil, err
 	}
@@ -37,12 +42,13 @@ func NewPreviewGenerator(videoFile ffmpeg.VideoFile, videoFilename string, image
 		VideoFilename:   videoFilename,
 		ImageFilename:   imageFilename,
 		OutputDirectory: outputDirectory,
+		Options:         options,
 	}, nil
 }
 
 func (g *PreviewGenerator) Generate() error {
 	logger.Infof("[generator] generating scene preview for %s", g.Info.VideoFile.Path)
-	encoder := ffmpeg.NewEncoder(instance.FFMPEGPath)
+	encoder := instance.newJobEncoder()
 
 	if err := g.generateConcatFile(); err != nil {
 		return err
@@ -65,8 +71,7 @@ func (g *PreviewGenerator) generateConcatFile() error {
 
 	w := bufio.NewWriter(f)
 	for i := 0; i < g.Info.ChunkCount; i++ {
-		num := fmt.Sprintf("%.3d", i)
-		filename := "preview" + num + ".mp4"
+		filename := g.getChunkFilename(i)
 		_, _ = w.WriteString(fmt.Sprintf("file '%s'\n", filename))
 	}
 	return w.Flush()
@@ -75,35 +80,44 @@ func (g *PreviewGenerator) generateConcatFile() error {
 func (g *PreviewGenerator) generateVideo(encoder *ffmpeg.Encoder) error {
 	outputPath := filepath.Join(g.OutputDirectory, g.VideoFilename)
 	outputExists, _ := utils.FileExists(outputPath)
-	if outputExists {
+	if outputExists && !g.Overwrite {
 		return nil
 	}
 
-	stepSize := int(g.Info.VideoFile.Duration / float64(g.Info.ChunkCount))
-	for i := 0; i < g.Info.ChunkCount; i++ {
-		time := i * stepSize
-		num := fmt.Sprintf("%.3d", i)
-		filename := "preview" + num + ".mp4"
-		chunkOutputPath := instance.Paths.Generated.GetTmpPath(filename)
+	for i, time := range g.Options.segmentTimes(g.Info.VideoFile.Duration) {
+		chunkOutputPath := instance.Paths.Generated.GetTmpPath(g.getChunkFilename(i))
 
 		options := ffmpeg.ScenePreviewChunkOptions{
 			Time:       time,
+			Duration:   g.Options.SegmentDuration,
 			Width:      640,
+			Preset:     g.Options.Preset.String(),
+			Audio:      g.Options.Audio,
 			OutputPath: chunkOutputPath,
 		}
-		encoder.ScenePreviewVideoChunk(g.Info.VideoFile, options)
+		if err := encoder.ScenePreviewVideoChunk(g.Info.VideoFile, options); err != nil {
+			return err
+		}
 	}
 
-	videoOutputPath := filepath.Join(g.OutputDirectory, g.VideoFilename)
-	encoder.ScenePreviewVideoChunkCombine(g.Info.VideoFile, g.getConcatFilePath(), videoOutputPath)
-	logger.Debug("created video preview: ", videoOutputPath)
+	// combine into the tmp directory so that a partial preview is never
+	// left in the output directory
+	tmpOutputPath := instance.Paths.Generated.GetTmpPath(g.getTmpPrefix() + "_preview.mp4")
+	if err := encoder.ScenePreviewVideoChunkCombine(g.Info.VideoFile, g.getConcatFilePath(), tmpOutputPath); err != nil {
+		_ = os.Remove(tmpOutputPath)
+		return err
+	}
+	if err := os.Rename(tmpOutputPath, outputPath); err != nil {
+		return err
+	}
+	logger.Debug("created video preview: ", outputPath)
 	return nil
 }
 
 func (g *PreviewGenerator) generateImage(encoder *ffmpeg.Encoder) error {
 	outputPath := filepath.Join(g.OutputDirectory, g.ImageFilename)
 	outputExists, _ := utils.FileExists(outputPath)
-	if outputExists {
+	if outputExists && !g.Overwrite {
 		return nil
 	}
 
@@ -120,6 +134,17 @@ func (g *PreviewGenerator) generateImage(encoder *ffmpeg.Encoder) error {
 	return nil
 }
 
+// getTmpPrefix returns the prefix used for the temporary files of this
+// generator, so that previews for different scenes can be generated at
+// the same time.
+func (g *PreviewGenerator) getTmpPrefix() string {
+	return strings.TrimSuffix(g.VideoFilename, filepath.Ext(g.VideoFilename))
+}
+
+func (g *PreviewGenerator) getChunkFilename(index int) string {
+	return fmt.Sprintf("%s_preview%.3d.mp4", g.getTmpPrefix(), index)
+}
+
 func (g *PreviewGenerator) getConcatFilePath() string {
-	return instance.Paths.Generated.GetTmpPath("files.txt")
+	return instance.Paths.Generated.GetTmpPath(g.getTmpPrefix() + "_files.txt")
 }
@@ -48,7 +48,7 @@ func NewSpriteGenerator(videoFile ffmpeg.VideoFile, imageOutputPath string, vttO
 }
 
 func (g *SpriteGenerator) Generate() error {
-	encoder := ffmpeg.NewEncoder(instance.FFMPEGPath)
+	encoder := instance.newJobEncoder()
 
 	if err := g.generateSpriteImage(&encoder); err != nil {
 		return err
@@ -66,22 +66,27 @@ func (g *SpriteGenerator) generateSpriteImage(encoder *ffmpeg.Encoder) error {
 	logger.Infof("[generator] generating sprite image for %s", g.Info.VideoFile.Path)
 
 	// Create `this.chunkCount` thumbnails in the tmp directory
+	// The thumbnails are prefixed with the sprite name, so that sprites for
+	// different scenes can be generated at the same time.
+	tmpPrefix := strings.TrimSuffix(filepath.Base(g.ImageOutputPath), filepath.Ext(g.ImageOutputPath))
 	stepSize := g.Info.VideoFile.Duration / float64(g.Info.ChunkCount)
 	for i := 0; i < g.Info.ChunkCount; i++ {
 		time := float64(i) * stepSize
 		num := fmt.Sprintf("%.3d", i)
-		filename := "thumbnail" + num + ".jpg"
+		filename := tmpPrefix + "_thumbnail" + num + ".jpg"
 
 		options := ffmpeg.ScreenshotOptions{
 			OutputPath: instance.Paths.Generated.GetTmpPath(filename),
 			Time:       time,
 			Width:      160,
 		}
-		encoder.Screenshot(g.Info.VideoFile, options)
+		if err := encoder.Screenshot(g.Info.VideoFile, options); err != nil {
+			return err
+		}
 	}
 
 	// Combine all of the thumbnails into a sprite image
-	globPath := filepath.Join(instance.Paths.Generated.Tmp, "thumbnail*.jpg")
+	globPath := filepath.Join(instance.Paths.Generated.Tmp, tmpPrefix+"_thumbnail*.jpg")
 	imagePaths, _ := doublestar.Glob(globPath)
 	utils.NaturalSort(imagePaths)
 	var images []image.Image
@@ -0,0 +1,367 @@
+package manager
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+const (
+	// hlsSegmentLength is the length of the HLS segments in seconds
+	hlsSegmentLength = 10
+
+	// hlsIdleTimeout is the time after which the cached segments of a
+	// stream that is no longer requested are removed
+	hlsIdleTimeout = 5 * time.Minute
+)
+
+// hlsResolutions are the resolutions that HLS renditions are transcoded to,
+// other than the original resolution.
+var hlsResolutions = []models.StreamingResolutionEnum{
+	models.StreamingResolutionEnumLow,
+	models.StreamingResolutionEnumStandard,
+	models.StreamingResolutionEnumStandardHd,
+	models.StreamingResolutionEnumFullHd,
+	models.StreamingResolutionEnumFourK,
+}
+
+type hlsRendition struct {
+	Resolution models.StreamingResolutionEnum
+	Width      int
+	Height     int
+}
+
+// hlsRenditions returns the renditions of a video of the given dimensions,
+// from the smallest to the largest. Renditions are not larger than the
+// maximum size or the video itself.
+func hlsRenditions(width int, height int, maxSize models.StreamingResolutionEnum) []hlsRendition {
+	videoSize := height
+	if width < videoSize {
+		videoSize = width
+	}
+	limit := ffmpeg.StreamingResolutionSize(maxSize)
+
+	var ret []hlsRendition
+	for _, resolution := range hlsResolutions {
+		size := ffmpeg.StreamingResolutionSize(resolution)
+		if size >= videoSize || (limit != 0 && size > limit) {
+			break
+		}
+
+		rendition := hlsRendition{Resolution: resolution}
+		// the smaller dimension is scaled to the size, and the larger one
+		// keeps the aspect ratio, rounded to an even number
+		if width > height {
+			rendition.Width = int(math.Round(float64(width*size)/float64(height)/2)) * 2
+			rendition.Height = size
+		} else {
+			rendition.Width = size
+			rendition.Height = int(math.Round(float64(height*size)/float64(width)/2)) * 2
+		}
+		ret = append(ret, rendition)
+	}
+
+	if limit == 0 || limit >= videoSize || len(ret) == 0 {
+		ret = append(ret, hlsRendition{
+			Resolution: models.StreamingResolutionEnumOriginal,
+			Width:      width,
+			Height:     height,
+		})
+	}
+
+	return ret
+}
+
+// IsHLSStreamable returns true if the duration and dimensions of the scene
+// are known, which are needed to build its playlists.
+func IsHLSStreamable(scene *models.Scene) bool {
+	return scene.Duration.Valid && scene.Duration.Float64 > 0 &&
+		scene.Width.Valid && scene.Width.Int64 > 0 &&
+		scene.Height.Valid && scene.Height.Int64 > 0
+}
+
+// IsValidHLSResolution returns true if the resolution is one of the HLS
+// renditions of the scene.
+func IsValidHLSResolution(scene *models.Scene, resolution models.StreamingResolutionEnum) bool {
+	if !IsHLSStreamable(scene) {
+		return false
+	}
+
+	for _, rendition := range hlsRenditions(int(scene.Width.Int64), int(scene.Height.Int64), config.GetMaxStreamingTranscodeSize()) {
+		if rendition.Resolution == resolution {
+			return true
+		}
+	}
+	return false
+}
+
+// hlsSegmentCount returns the number of segments of a video of the given
+// duration.
+func hlsSegmentCount(duration float64) int {
+	return int(math.Ceil(duration / hlsSegmentLength))
+}
+
+// hlsSegmentDuration returns the duration of the segment, which is shorter
+// than the segment length for the last segment.
+func hlsSegmentDuration(duration float64, segment int) float64 {
+	return math.Min(hlsSegmentLength, duration-float64(segment*hlsSegmentLength))
+}
+
+// GetHLSMasterPlaylist returns the HLS master playlist of the scene, which
+// lists a media playlist per rendition.
+func GetHLSMasterPlaylist(scene *models.Scene) (string, error) {
+	if !IsHLSStreamable(scene) {
+		return "", fmt.Errorf("scene %d has no duration or dimensions", scene.ID)
+	}
+
+	renditions := hlsRenditions(int(scene.Width.Int64), int(scene.Height.Int64), config.GetMaxStreamingTranscodeSize())
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	for _, rendition := range renditions {
+		// roughly three bits per pixel per second for H.264
+		bandwidth := rendition.Width * rendition.Height * 3
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d\n", bandwidth, rendition.Width, rendition.Height)
+		fmt.Fprintf(&b, "hls/%s.m3u8\n", rendition.Resolution)
+	}
+
+	return b.String(), nil
+}
+
+// GetHLSMediaPlaylist returns the HLS media playlist of the scene at the
+// given resolution.
+func GetHLSMediaPlaylist(scene *models.Scene, resolution models.StreamingResolutionEnum) (string, error) {
+	if !scene.Duration.Valid {
+		return "", fmt.Errorf("scene %d has no duration", scene.ID)
+	}
+	duration := scene.Duration.Float64
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	b.WriteString("#EXT-X-VERSION:3\n")
+	b.WriteString("#EXT-X-PLAYLIST-TYPE:VOD\n")
+	fmt.Fprintf(&b, "#EXT-X-TARGETDURATION:%d\n", hlsSegmentLength)
+	b.WriteString("#EXT-X-MEDIA-SEQUENCE:0\n")
+	for i := 0; i < hlsSegmentCount(duration); i++ {
+		fmt.Fprintf(&b, "#EXTINF:%.3f,\n", hlsSegmentDuration(duration, i))
+		fmt.Fprintf(&b, "%s/%d.ts\n", resolution, i)
+	}
+	b.WriteString("#EXT-X-ENDLIST\n")
+
+	return b.String(), nil
+}
+
+// hlsStream is a scene being streamed at a resolution. Its segments are
+// transcoded when first requested and cached until the stream is idle.
+type hlsStream struct {
+	scene      *models.Scene
+	hash       string
+	resolution models.StreamingResolutionEnum
+	videoFile  *ffmpeg.VideoFile
+
+	mutex    sync.Mutex
+	lastUsed time.Time
+	pending  map[int]*hlsTranscode
+}
+
+// hlsTranscode is a pending segment transcode. done is closed once err is
+// set.
+type hlsTranscode struct {
+	done chan struct{}
+	err  error
+}
+
+var (
+	hlsStreams      = make(map[string]*hlsStream)
+	hlsStreamsMutex sync.Mutex
+	hlsCleanupOnce  sync.Once
+)
+
+// GetHLSSegment returns the path of the segment of the scene at the given
+// resolution, transcoding it if it is not cached. The following segment is
+// transcoded in the background.
+func GetHLSSegment(scene *models.Scene, resolution models.StreamingResolutionEnum, segment int) (string, error) {
+	if !scene.Duration.Valid {
+		return "", fmt.Errorf("scene %d has no duration", scene.ID)
+	}
+	if segment < 0 || segment >= hlsSegmentCount(scene.Duration.Float64) {
+		return "", fmt.Errorf("segment %d out of range", segment)
+	}
+	if !IsValidHLSResolution(scene, resolution) {
+		return "", fmt.Errorf("resolution %s is not streamed for scene %d", resolution, scene.ID)
+	}
+
+	stream, err := getHLSStream(scene, resolution)
+	if err != nil {
+		return "", err
+	}
+
+	if err := stream.transcode(segment); err != nil {
+		return "", err
+	}
+
+	if segment+1 < hlsSegmentCount(scene.Duration.Float64) {
+		go func() {
+			_ = stream.transcode(segment + 1)
+		}()
+	}
+
+	return instance.Paths.Scene.GetHLSSegmentPath(stream.hash, string(resolution), segment), nil
+}
+
+func getHLSStream(scene *models.Scene, resolution models.StreamingResolutionEnum) (*hlsStream, error) {
+	hlsCleanupOnce.Do(func() {
+		go runHLSCleanup()
+	})
+
+	hash := GetSceneHash(scene)
+	key := instance.Paths.Scene.GetHLSDirectory(hash, string(resolution))
+
+	hlsStreamsMutex.Lock()
+	defer hlsStreamsMutex.Unlock()
+
+	stream := hlsStreams[key]
+	if stream == nil || stream.scene.Path != scene.Path {
+		videoFile, err := ffmpeg.NewVideoFile(instance.FFProbePath, scene.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := utils.EnsureDir(key); err != nil {
+			return nil, err
+		}
+
+		stream = &hlsStream{
+			scene:      scene,
+			hash:       hash,
+			resolution: resolution,
+			videoFile:  videoFile,
+			pending:    make(map[int]*hlsTranscode),
+		}
+		hlsStreams[key] = stream
+	}
+
+	stream.mutex.Lock()
+	stream.lastUsed = time.Now()
+	stream.mutex.Unlock()
+
+	return stream, nil
+}
+
+// transcode transcodes the segment if it is not cached, or waits for it if
+// it is already being transcoded.
+func (s *hlsStream) transcode(segment int) error {
+	segmentPath := instance.Paths.Scene.GetHLSSegmentPath(s.hash, string(s.resolution), segment)
+
+	s.mutex.Lock()
+	if pending, ok := s.pending[segment]; ok {
+		s.mutex.Unlock()
+		<-pending.done
+		return pending.err
+	}
+	if exists, _ := utils.FileExists(segmentPath); exists {
+		s.mutex.Unlock()
+		return nil
+	}
+	pending := &hlsTranscode{done: make(chan struct{})}
+	s.pending[segment] = pending
+	s.mutex.Unlock()
+
+	pending.err = s.transcodeSegment(segment, segmentPath)
+	if pending.err != nil {
+		logger.Errorf("[hls] error transcoding segment %d of %s: %s", segment, s.scene.Path, pending.err.Error())
+	}
+
+	s.mutex.Lock()
+	delete(s.pending, segment)
+	s.lastUsed = time.Now()
+	s.mutex.Unlock()
+	close(pending.done)
+
+	return pending.err
+}
+
+func (s *hlsStream) transcodeSegment(segment int, segmentPath string) error {
+	// write to a temporary file so that partial segments are never served
+	tmpPath := segmentPath + ".tmp"
+	options := ffmpeg.HLSSegmentOptions{
+		Start:      float64(segment * hlsSegmentLength),
+		Duration:   hlsSegmentDuration(s.videoFile.Duration, segment),
+		Resolution: s.resolution,
+		Profile:    GetHLSTranscodeProfile(),
+		OutputPath: tmpPath,
+	}
+
+	encoder := ffmpeg.NewEncoder(instance.FFMPEGPath)
+	if err := encoder.HLSSegment(*s.videoFile, options); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, segmentPath)
+}
+
+// idle returns true if the stream has no pending transcodes and has not been
+// used since the idle timeout.
+func (s *hlsStream) idle(now time.Time) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.pending) == 0 && now.Sub(s.lastUsed) > hlsIdleTimeout
+}
+
+// invalidateHLSStreams removes the cached segments of the scene hash at every
+// resolution. It is called when the scene file changes, since the segments
+// are only keyed by the hash and resolution.
+func invalidateHLSStreams(hash string) {
+	hlsStreamsMutex.Lock()
+	defer hlsStreamsMutex.Unlock()
+
+	resolutions := append([]models.StreamingResolutionEnum{models.StreamingResolutionEnumOriginal}, hlsResolutions...)
+	for _, resolution := range resolutions {
+		dir := instance.Paths.Scene.GetHLSDirectory(hash, string(resolution))
+		delete(hlsStreams, dir)
+		if exists, _ := utils.DirExists(dir); exists {
+			logger.Debugf("[hls] removing stale stream %s", dir)
+			_ = utils.RemoveDir(dir)
+		}
+	}
+}
+
+// runHLSCleanup periodically removes the segments of idle streams, along
+// with any segment directories left over from previous runs.
+func runHLSCleanup() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		hlsStreamsMutex.Lock()
+		for dir, stream := range hlsStreams {
+			if stream.idle(now) {
+				logger.Debugf("[hls] removing idle stream %s", dir)
+				_ = utils.RemoveDir(dir)
+				delete(hlsStreams, dir)
+			}
+		}
+
+		hlsPath := instance.Paths.Generated.HLS
+		infos, _ := ioutil.ReadDir(hlsPath)
+		for _, info := range infos {
+			dir := filepath.Join(hlsPath, info.Name())
+			if _, active := hlsStreams[dir]; !active && now.Sub(info.ModTime()) > hlsIdleTimeout {
+				_ = utils.RemoveDir(dir)
+			}
+		}
+		hlsStreamsMutex.Unlock()
+	}
+}
@@ -0,0 +1,80 @@
+package manager
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func TestHLSRenditions(t *testing.T) {
+	scenarios := []struct {
+		width    int
+		height   int
+		maxSize  models.StreamingResolutionEnum
+		expected []hlsRendition
+	}{
+		{1920, 1080, models.StreamingResolutionEnumOriginal, []hlsRendition{
+			{models.StreamingResolutionEnumLow, 426, 240},
+			{models.StreamingResolutionEnumStandard, 854, 480},
+			{models.StreamingResolutionEnumStandardHd, 1280, 720},
+			{models.StreamingResolutionEnumOriginal, 1920, 1080},
+		}},
+		{1920, 1080, models.StreamingResolutionEnumStandard, []hlsRendition{
+			{models.StreamingResolutionEnumLow, 426, 240},
+			{models.StreamingResolutionEnumStandard, 854, 480},
+		}},
+		{1080, 1920, models.StreamingResolutionEnumLow, []hlsRendition{
+			{models.StreamingResolutionEnumLow, 240, 426},
+		}},
+		{320, 200, models.StreamingResolutionEnumLow, []hlsRendition{
+			{models.StreamingResolutionEnumOriginal, 320, 200},
+		}},
+		{320, 200, models.StreamingResolutionEnumFullHd, []hlsRendition{
+			{models.StreamingResolutionEnumOriginal, 320, 200},
+		}},
+	}
+
+	for i, s := range scenarios {
+		renditions := hlsRenditions(s.width, s.height, s.maxSize)
+		if !reflect.DeepEqual(renditions, s.expected) {
+			t.Errorf("[%d] Was expecting %v, found %v", i, s.expected, renditions)
+		}
+	}
+}
+
+func TestHLSSegments(t *testing.T) {
+	const duration = 25.5
+	if count := hlsSegmentCount(duration); count != 3 {
+		t.Errorf("Was expecting 3 segments, found %d", count)
+	}
+	if d := hlsSegmentDuration(duration, 1); d != hlsSegmentLength {
+		t.Errorf("Was expecting %d, found %v", hlsSegmentLength, d)
+	}
+	if d := hlsSegmentDuration(duration, 2); d != 5.5 {
+		t.Errorf("Was expecting 5.5, found %v", d)
+	}
+}
+
+func TestIsHLSStreamable(t *testing.T) {
+	duration := sql.NullFloat64{Float64: 60, Valid: true}
+	width := sql.NullInt64{Int64: 1920, Valid: true}
+	height := sql.NullInt64{Int64: 1080, Valid: true}
+
+	scenarios := []struct {
+		scene    models.Scene
+		expected bool
+	}{
+		{models.Scene{Duration: duration, Width: width, Height: height}, true},
+		{models.Scene{Width: width, Height: height}, false},
+		{models.Scene{Duration: duration, Width: width}, false},
+		{models.Scene{Duration: duration, Width: sql.NullInt64{Valid: true}, Height: height}, false},
+	}
+
+	for i, s := range scenarios {
+		if streamable := IsHLSStreamable(&s.scene); streamable != s.expected {
+			t.Errorf("[%d] Was expecting %v, found %v", i, s.expected, streamable)
+		}
+	}
+}
@@ -0,0 +1,404 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// jobQueue runs the queued jobs one at a time, in order of position. Jobs
+// are stored in the jobs table so that the queue survives a restart.
+type jobQueue struct {
+	mutex   sync.Mutex
+	current *models.Job
+	report  *jobReport
+	wake    chan struct{}
+
+	// ctx is cancelled when the running job is stopped, killing any ffmpeg
+	// processes started by the job
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newJobQueue() *jobQueue {
+	return &jobQueue{
+		wake: make(chan struct{}, 1),
+	}
+}
+
+// StartJobQueue requeues any jobs that were interrupted and starts running
+// queued jobs. It must be called after the database is initialized.
+func (s *singleton) StartJobQueue() {
+	qb := models.NewJobQueryBuilder()
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+	if err := qb.ResetRunning(tx); err != nil {
+		logger.Errorf("Error requeuing interrupted jobs: %s", err.Error())
+		_ = tx.Rollback()
+	} else if err := tx.Commit(); err != nil {
+		logger.Errorf("Error requeuing interrupted jobs: %s", err.Error())
+	}
+
+	go s.runJobQueue()
+}
+
+// GetJobQueue returns the running and queued jobs in the order they will
+// be run.
+func (s *singleton) GetJobQueue() ([]*models.Job, error) {
+	qb := models.NewJobQueryBuilder()
+	return qb.FindByState([]models.JobState{models.JobStateRunning, models.JobStateQueued}, nil)
+}
+
+// GetJobHistory returns the most recently completed jobs, most recent first.
+// All completed jobs are returned if count is less than 1.
+func (s *singleton) GetJobHistory(count int) ([]*models.Job, error) {
+	qb := models.NewJobQueryBuilder()
+	return qb.FindHistory(count, nil)
+}
+
+// GetJob returns the job with the given id, or nil if it does not exist.
+func (s *singleton) GetJob(id int) (*models.Job, error) {
+	qb := models.NewJobQueryBuilder()
+	return qb.Find(id, nil)
+}
+
+// GetCurrentJob returns the job that is currently running, or nil.
+func (s *singleton) GetCurrentJob() *models.Job {
+	s.queue.mutex.Lock()
+	defer s.queue.mutex.Unlock()
+	return s.queue.current
+}
+
+// CancelJob removes a queued job from the queue, or stops the job if it is
+// currently running. Returns false if the job is not queued or running.
+func (s *singleton) CancelJob(id int) (bool, error) {
+	s.queue.mutex.Lock()
+	defer s.queue.mutex.Unlock()
+
+	if s.queue.current != nil && s.queue.current.ID == id {
+		return s.stopCurrentJob(), nil
+	}
+
+	qb := models.NewJobQueryBuilder()
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+	job, err := qb.Find(id, tx)
+	if err != nil || job == nil || job.State != models.JobStateQueued.String() {
+		_ = tx.Rollback()
+		return false, err
+	}
+
+	if err := qb.UpdateState(id, models.JobStateCancelled, tx); err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	logger.Infof("Cancelled queued %s job %d", JobStatus(job.Type).String(), id)
+	return true, nil
+}
+
+// StopJob stops the running job. Any ffmpeg processes started by the job
+// are killed.
+func (s *singleton) StopJob() bool {
+	s.queue.mutex.Lock()
+	defer s.queue.mutex.Unlock()
+	return s.stopCurrentJob()
+}
+
+// stopCurrentJob must be called with the queue mutex held.
+func (s *singleton) stopCurrentJob() bool {
+	if s.queue.cancel != nil {
+		s.queue.cancel()
+	}
+	return s.Status.Stop()
+}
+
+// jobContext returns the context of the running job, which is cancelled
+// when the job is stopped.
+func (s *singleton) jobContext() context.Context {
+	s.queue.mutex.Lock()
+	defer s.queue.mutex.Unlock()
+	if s.queue.ctx == nil {
+		return context.Background()
+	}
+	return s.queue.ctx
+}
+
+// ReorderJob moves a queued job to the given zero-based position among the
+// queued jobs. Positions outside of the queue are clamped to the start or end.
+func (s *singleton) ReorderJob(id int, position int) (bool, error) {
+	s.queue.mutex.Lock()
+	defer s.queue.mutex.Unlock()
+
+	qb := models.NewJobQueryBuilder()
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+	queued, err := qb.FindByState([]models.JobState{models.JobStateQueued}, tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+
+	index := -1
+	for i, job := range queued {
+		if job.ID == id {
+			index = i
+			break
+		}
+	}
+
+	if index == -1 {
+		_ = tx.Rollback()
+		return false, fmt.Errorf("job %d is not queued", id)
+	}
+
+	job := queued[index]
+	queued = append(queued[:index], queued[index+1:]...)
+
+	if position < 0 {
+		position = 0
+	} else if position > len(queued) {
+		position = len(queued)
+	}
+	queued = append(queued[:position], append([]*models.Job{job}, queued[position:]...)...)
+
+	// positions of queued jobs are renumbered after the running job, if any
+	offset := 1
+	if s.queue.current != nil {
+		offset = s.queue.current.Position + 1
+	}
+
+	updatedTime := models.SQLiteTimestamp{Timestamp: time.Now()}
+	for i, job := range queued {
+		updatedJob := models.Job{
+			ID:        job.ID,
+			Position:  offset + i,
+			UpdatedAt: updatedTime,
+		}
+		if _, err := qb.Update(updatedJob, tx); err != nil {
+			_ = tx.Rollback()
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// enqueueJob adds a job of the given type to the end of the queue. The
+// input is stored with the job and passed back when it is run. Returns the
+// id of the new job.
+func (s *singleton) enqueueJob(jobType JobStatus, input interface{}) (string, error) {
+	var inputJSON sql.NullString
+	if input != nil {
+		data, err := json.Marshal(input)
+		if err != nil {
+			return "", err
+		}
+		inputJSON = sql.NullString{String: string(data), Valid: true}
+	}
+
+	s.queue.mutex.Lock()
+	defer s.queue.mutex.Unlock()
+
+	qb := models.NewJobQueryBuilder()
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+	maxPosition, err := qb.GetMaxPosition(tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return "", err
+	}
+
+	currentTime := time.Now()
+	newJob := models.Job{
+		Type:      int(jobType),
+		State:     models.JobStateQueued.String(),
+		Position:  maxPosition + 1,
+		Input:     inputJSON,
+		CreatedAt: models.SQLiteTimestamp{Timestamp: currentTime},
+		UpdatedAt: models.SQLiteTimestamp{Timestamp: currentTime},
+	}
+
+	job, err := qb.Create(newJob, tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+
+	logger.Infof("Queued %s job %d", jobType.String(), job.ID)
+
+	// wake the queue if it is waiting for work
+	select {
+	case s.queue.wake <- struct{}{}:
+	default:
+	}
+
+	return strconv.Itoa(job.ID), nil
+}
+
+func (s *singleton) runJobQueue() {
+	for {
+		job, err := s.startNextJob()
+		if err != nil {
+			logger.Errorf("Error getting next job: %s", err.Error())
+		}
+
+		if job == nil {
+			// wait until a job is queued, checking periodically in case the
+			// database was replaced underneath us by an import
+			select {
+			case <-s.queue.wake:
+			case <-time.After(time.Minute):
+			}
+			continue
+		}
+
+		state := s.runJob(job)
+		s.finishJob(job, state)
+	}
+}
+
+func (s *singleton) startNextJob() (*models.Job, error) {
+	s.queue.mutex.Lock()
+	defer s.queue.mutex.Unlock()
+
+	qb := models.NewJobQueryBuilder()
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+	job, err := qb.FindNextQueued(tx)
+	if err != nil || job == nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	if err := qb.Start(job.ID, tx); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	job.State = models.JobStateRunning.String()
+	s.queue.current = job
+	s.queue.report = &jobReport{}
+	s.queue.ctx, s.queue.cancel = context.WithCancel(context.Background())
+	return job, nil
+}
+
+// finishJob saves the final state and the report of the job, and clears
+// the running job.
+func (s *singleton) finishJob(job *models.Job, state models.JobState) {
+	s.queue.mutex.Lock()
+	defer s.queue.mutex.Unlock()
+
+	items := s.queue.report.getItems()
+	s.queue.current = nil
+	s.queue.report = nil
+	s.queue.cancel()
+	s.queue.ctx = nil
+	s.queue.cancel = nil
+
+	qb := models.NewJobQueryBuilder()
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+
+	// the job no longer exists if the database was replaced by an import
+	existing, err := qb.Find(job.ID, tx)
+	if err == nil && existing != nil {
+		err = qb.Finish(job.ID, state, tx)
+		if err == nil {
+			err = qb.CreateReportItems(job.ID, items, tx)
+		}
+	}
+
+	if err != nil {
+		logger.Errorf("Error updating job %d: %s", job.ID, err.Error())
+		_ = tx.Rollback()
+	} else if err := tx.Commit(); err != nil {
+		logger.Errorf("Error updating job %d: %s", job.ID, err.Error())
+	}
+}
+
+// runJob runs the job to completion and returns the state it finished in.
+func (s *singleton) runJob(job *models.Job) (state models.JobState) {
+	jobType := JobStatus(job.Type)
+	s.Status.SetStatus(jobType)
+	s.Status.indefiniteProgress()
+
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("%s job %d failed: %v", jobType.String(), job.ID, r)
+			s.addJobReportItem(models.JobReportItemTypeError, "", fmt.Sprintf("%v", r))
+			state = models.JobStateFailed
+		} else if s.Status.isStopping() {
+			state = models.JobStateCancelled
+		} else {
+			state = models.JobStateFinished
+		}
+
+		s.returnToIdleState()
+	}()
+
+	logger.Infof("Starting %s job %d", jobType.String(), job.ID)
+
+	switch jobType {
+	case Scan:
+		var input models.ScanMetadataInput
+		s.unmarshalJobInput(job, &input)
+		s.scan(input)
+	case Import:
+		s.doImport()
+	case Export:
+		s.export()
+	case Generate:
+		var input models.GenerateMetadataInput
+		s.unmarshalJobInput(job, &input)
+		s.generate(input)
+	case AutoTag:
+		var input models.AutoTagMetadataInput
+		s.unmarshalJobInput(job, &input)
+		s.autoTag(input)
+	case Clean:
+		var input models.CleanMetadataInput
+		s.unmarshalJobInput(job, &input)
+		s.clean(input)
+	case Watch:
+		var input watchInput
+		s.unmarshalJobInput(job, &input)
+		s.watchScan(input)
+	case AutoLinkGalleries:
+		s.autoLinkGalleries()
+	case MigrateHash:
+		s.migrateHash()
+	default:
+		panic(fmt.Sprintf("unknown job type %d", job.Type))
+	}
+
+	return
+}
+
+func (s *singleton) unmarshalJobInput(job *models.Job, input interface{}) {
+	if !job.Input.Valid {
+		return
+	}
+
+	if err := json.Unmarshal([]byte(job.Input.String), input); err != nil {
+		panic(fmt.Sprintf("invalid input for job %d: %s", job.ID, err.Error()))
+	}
+}
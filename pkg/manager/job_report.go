@@ -0,0 +1,91 @@
+package manager
+
+import (
+	"database/sql"
+	"sync"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// jobReport collects the results of a job while it is running. The items
+// are saved with the job when it finishes.
+type jobReport struct {
+	mutex sync.Mutex
+	items []models.JobReportItem
+}
+
+func (r *jobReport) add(itemType models.JobReportItemType, path string, message string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	item := models.JobReportItem{
+		Type: itemType.String(),
+		Path: path,
+	}
+	if message != "" {
+		item.Message = sql.NullString{String: message, Valid: true}
+	}
+
+	r.items = append(r.items, item)
+}
+
+func (r *jobReport) addErrors(errors []taskError) {
+	for _, e := range errors {
+		r.add(models.JobReportItemTypeError, e.Path, e.Err.Error())
+	}
+}
+
+func (r *jobReport) getItems() []models.JobReportItem {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	ret := make([]models.JobReportItem, len(r.items))
+	copy(ret, r.items)
+	return ret
+}
+
+// addJobReportItem adds an item to the report of the running job. It does
+// nothing if no job is running.
+func (s *singleton) addJobReportItem(itemType models.JobReportItemType, path string, message string) {
+	if report := s.getJobReport(); report != nil {
+		report.add(itemType, path, message)
+	}
+}
+
+func (s *singleton) addJobReportErrors(errors []taskError) {
+	if report := s.getJobReport(); report != nil {
+		report.addErrors(errors)
+	}
+}
+
+func (s *singleton) getJobReport() *jobReport {
+	// tasks run outside of the manager, such as in tests, have no report
+	if s == nil || s.queue == nil {
+		return nil
+	}
+
+	s.queue.mutex.Lock()
+	defer s.queue.mutex.Unlock()
+	return s.queue.report
+}
+
+// GetJobReportItems returns the report items of the job with the given id.
+// The items of the running job are returned as they currently stand.
+func (s *singleton) GetJobReportItems(id int) ([]*models.JobReportItem, error) {
+	s.queue.mutex.Lock()
+	if s.queue.current != nil && s.queue.current.ID == id {
+		items := s.queue.report.getItems()
+		s.queue.mutex.Unlock()
+
+		ret := make([]*models.JobReportItem, len(items))
+		for i := range items {
+			items[i].JobID = id
+			ret[i] = &items[i]
+		}
+		return ret, nil
+	}
+	s.queue.mutex.Unlock()
+
+	qb := models.NewJobQueryBuilder()
+	return qb.GetReportItems(id, nil)
+}
@@ -11,6 +11,10 @@ const (
 	Clean    JobStatus = 5
 	Scrape   JobStatus = 6
 	AutoTag  JobStatus = 7
+	Watch    JobStatus = 8
+
+	AutoLinkGalleries JobStatus = 9
+	MigrateHash       JobStatus = 10
 )
 
 func (s JobStatus) String() string {
@@ -27,8 +31,16 @@ func (s JobStatus) String() string {
 		statusMessage = "Scan"
 	case Generate:
 		statusMessage = "Generate"
+	case Clean:
+		statusMessage = "Clean"
 	case AutoTag:
 		statusMessage = "Auto Tag"
+	case Watch:
+		statusMessage = "Watch"
+	case AutoLinkGalleries:
+		statusMessage = "Auto Link Galleries"
+	case MigrateHash:
+		statusMessage = "Migrate Hash"
 	}
 
 	return statusMessage
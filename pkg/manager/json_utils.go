@@ -45,3 +45,19 @@ func (jp *jsonUtils) getScene(checksum string) (*jsonschema.Scene, error) {
 func (jp *jsonUtils) saveScene(checksum string, scene *jsonschema.Scene) error {
 	return jsonschema.SaveSceneFile(instance.Paths.JSON.SceneJSONPath(checksum), scene)
 }
+
+func (jp *jsonUtils) getGallery(checksum string) (*jsonschema.Gallery, error) {
+	return jsonschema.LoadGalleryFile(instance.Paths.JSON.GalleryJSONPath(checksum))
+}
+
+func (jp *jsonUtils) saveGallery(checksum string, gallery *jsonschema.Gallery) error {
+	return jsonschema.SaveGalleryFile(instance.Paths.JSON.GalleryJSONPath(checksum), gallery)
+}
+
+func (jp *jsonUtils) getImage(checksum string) (*jsonschema.Image, error) {
+	return jsonschema.LoadImageFile(instance.Paths.JSON.ImageJSONPath(checksum))
+}
+
+func (jp *jsonUtils) saveImage(checksum string, image *jsonschema.Image) error {
+	return jsonschema.SaveImageFile(instance.Paths.JSON.ImageJSONPath(checksum), image)
+}
@@ -0,0 +1,43 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stashapp/stash/pkg/models"
+	"os"
+)
+
+type Gallery struct {
+	Title      string          `json:"title,omitempty"`
+	Studio     string          `json:"studio,omitempty"`
+	URL        string          `json:"url,omitempty"`
+	Date       string          `json:"date,omitempty"`
+	Rating     int             `json:"rating,omitempty"`
+	Details    string          `json:"details,omitempty"`
+	Performers []string        `json:"performers,omitempty"`
+	Tags       []string        `json:"tags,omitempty"`
+	CreatedAt  models.JSONTime `json:"created_at,omitempty"`
+	UpdatedAt  models.JSONTime `json:"updated_at,omitempty"`
+}
+
+func LoadGalleryFile(filePath string) (*Gallery, error) {
+	var gallery Gallery
+	file, err := os.Open(filePath)
+	defer file.Close()
+	if err != nil {
+		return nil, err
+	}
+	jsonParser := json.NewDecoder(file)
+	err = jsonParser.Decode(&gallery)
+	if err != nil {
+		return nil, err
+	}
+	return &gallery, nil
+}
+
+func SaveGalleryFile(filePath string, gallery *Gallery) error {
+	if gallery == nil {
+		return fmt.Errorf("gallery must not be nil")
+	}
+	return marshalToFile(filePath, gallery)
+}
@@ -0,0 +1,46 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stashapp/stash/pkg/models"
+	"os"
+)
+
+type ImageFile struct {
+	Size   string `json:"size,omitempty"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+}
+
+type Image struct {
+	Title      string          `json:"title,omitempty"`
+	Rating     int             `json:"rating,omitempty"`
+	Performers []string        `json:"performers,omitempty"`
+	Tags       []string        `json:"tags,omitempty"`
+	File       *ImageFile      `json:"file,omitempty"`
+	CreatedAt  models.JSONTime `json:"created_at,omitempty"`
+	UpdatedAt  models.JSONTime `json:"updated_at,omitempty"`
+}
+
+func LoadImageFile(filePath string) (*Image, error) {
+	var image Image
+	file, err := os.Open(filePath)
+	defer file.Close()
+	if err != nil {
+		return nil, err
+	}
+	jsonParser := json.NewDecoder(file)
+	err = jsonParser.Decode(&image)
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+func SaveImageFile(filePath string, image *Image) error {
+	if image == nil {
+		return fmt.Errorf("image must not be nil")
+	}
+	return marshalToFile(filePath, image)
+}
@@ -14,6 +14,9 @@ type NameMapping struct {
 type PathMapping struct {
 	Path     string `json:"path"`
 	Checksum string `json:"checksum"`
+	// Oshash is only set for scenes, which are mapped by oshash if they
+	// have no checksum.
+	Oshash string `json:"oshash,omitempty"`
 }
 
 type Mappings struct {
@@ -21,6 +24,9 @@ type Mappings struct {
 	Studios    []NameMapping `json:"studios"`
 	Galleries  []PathMapping `json:"galleries"`
 	Scenes     []PathMapping `json:"scenes"`
+	// Images are mapped by checksum. Images that are only in galleries
+	// have no path.
+	Images []PathMapping `json:"images,omitempty"`
 }
 
 func LoadMappingsFile(filePath string) (*Mappings, error) {
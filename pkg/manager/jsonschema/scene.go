@@ -21,6 +21,7 @@ type SceneFile struct {
 	Duration   string `json:"duration"`
 	VideoCodec string `json:"video_codec"`
 	AudioCodec string `json:"audio_codec"`
+	Format     string `json:"format"`
 	Width      int    `json:"width"`
 	Height     int    `json:"height"`
 	Framerate  string `json:"framerate"`
@@ -35,6 +36,7 @@ type Scene struct {
 	Rating     int             `json:"rating,omitempty"`
 	Details    string          `json:"details,omitempty"`
 	Gallery    string          `json:"gallery,omitempty"`
+	Galleries  []string        `json:"galleries,omitempty"`
 	Performers []string        `json:"performers,omitempty"`
 	Tags       []string        `json:"tags,omitempty"`
 	Markers    []SceneMarker   `json:"markers,omitempty"`
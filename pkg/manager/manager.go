@@ -10,6 +10,7 @@ import (
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/manager/paths"
+	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/utils"
 )
 
@@ -20,6 +21,12 @@ type singleton struct {
 
 	FFMPEGPath  string
 	FFProbePath string
+
+	// encoders supported by ffmpeg, used to validate transcode profiles
+	ffmpegEncoders []string
+
+	queue   *jobQueue
+	watcher *stashWatcher
 }
 
 var instance *singleton
@@ -43,14 +50,16 @@ func Initialize() *singleton {
 		initLog()
 		initEnvs()
 		instance = &singleton{
-			Status: TaskStatus{Status: Idle, Progress: -1},
+			Status: newTaskStatus(),
 			Paths:  paths.NewPaths(),
 			JSON:   &jsonUtils{},
+			queue:  newJobQueue(),
 		}
 
 		instance.RefreshConfig()
 
 		initFFMPEG()
+		instance.validateTranscodeProfiles()
 	})
 
 	return instance
@@ -146,15 +155,19 @@ func initLog() {
 
 func (s *singleton) RefreshConfig() {
 	s.Paths = paths.NewPaths()
+	models.SetImageExtensions(config.GetImageExtensions())
 	if config.IsValid() {
 		_ = utils.EnsureDir(s.Paths.Generated.Screenshots)
 		_ = utils.EnsureDir(s.Paths.Generated.Vtt)
 		_ = utils.EnsureDir(s.Paths.Generated.Markers)
 		_ = utils.EnsureDir(s.Paths.Generated.Transcodes)
+		_ = utils.EnsureDir(s.Paths.Generated.Thumbnails)
+		_ = utils.EnsureDir(s.Paths.Generated.HLS)
 
 		_ = utils.EnsureDir(s.Paths.JSON.Performers)
 		_ = utils.EnsureDir(s.Paths.JSON.Scenes)
 		_ = utils.EnsureDir(s.Paths.JSON.Galleries)
+		_ = utils.EnsureDir(s.Paths.JSON.Images)
 		_ = utils.EnsureDir(s.Paths.JSON.Studios)
 	}
 }
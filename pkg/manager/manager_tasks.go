@@ -1,13 +1,15 @@
 package manager
 
 import (
-	"github.com/bmatcuk/doublestar"
+	"github.com/stashapp/stash/pkg/ffmpeg"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/utils"
+	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -19,245 +21,559 @@ type TaskStatus struct {
 	stopping   bool
 	upTo       int
 	total      int
+
+	// CurrentFile is the file being reported by an encoder, with its
+	// progress from 0 to 1 and estimated time remaining. SubProgress is -1
+	// when no file is being encoded.
+	CurrentFile  string
+	SubProgress  float64
+	SubRemaining time.Duration
+
+	// mutex guards the status, which is updated by parallel tasks. It is a
+	// pointer so that snapshots of the status can be compared.
+	mutex *sync.Mutex
+}
+
+func newTaskStatus() TaskStatus {
+	return TaskStatus{
+		Status:      Idle,
+		Progress:    -1,
+		SubProgress: -1,
+		mutex:       &sync.Mutex{},
+	}
+}
+
+// GetStatus returns a copy of the current status.
+func (t *TaskStatus) GetStatus() TaskStatus {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return *t
 }
 
 func (t *TaskStatus) Stop() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
 	t.stopping = true
 	t.updated()
 	return true
 }
 
+func (t *TaskStatus) isStopping() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.stopping
+}
+
 func (t *TaskStatus) SetStatus(s JobStatus) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
 	t.Status = s
 	t.updated()
 }
 
+func (t *TaskStatus) getStatus() JobStatus {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.Status
+}
+
 func (t *TaskStatus) setProgress(upTo int, total int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.setProgressLocked(upTo, total)
+}
+
+func (t *TaskStatus) setProgressLocked(upTo int, total int) {
+	t.upTo = upTo
+	t.total = total
 	if total == 0 {
 		t.Progress = 1
+	} else {
+		t.Progress = float64(upTo) / float64(total)
 	}
-	t.upTo = upTo
-	t.total = total
-	t.Progress = float64(upTo) / float64(total)
 	t.updated()
 }
 
 func (t *TaskStatus) incrementProgress() {
-	t.setProgress(t.upTo+1, t.total)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.setProgressLocked(t.upTo+1, t.total)
 }
 
 func (t *TaskStatus) indefiniteProgress() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
 	t.Progress = -1
 	t.updated()
 }
 
+// setFileProgress sets the progress of the given file. Progress of other
+// files is ignored until the reported file is cleared, so that the reported
+// file does not flip between parallel encodes.
+func (t *TaskStatus) setFileProgress(path string, progress float64, remaining time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.CurrentFile != "" && t.CurrentFile != path {
+		return
+	}
+	t.CurrentFile = path
+	t.SubProgress = progress
+	t.SubRemaining = remaining
+	t.updated()
+}
+
+// clearFileProgress clears the file progress if it is of the given file, or
+// of any file if path is empty.
+func (t *TaskStatus) clearFileProgress(path string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if path != "" && t.CurrentFile != path {
+		return
+	}
+	t.CurrentFile = ""
+	t.SubProgress = -1
+	t.SubRemaining = 0
+	t.updated()
+}
+
+// reset returns the status to idle.
+func (t *TaskStatus) reset() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.Status = Idle
+	t.Progress = -1
+	t.CurrentFile = ""
+	t.SubProgress = -1
+	t.SubRemaining = 0
+	t.stopping = false
+	t.updated()
+}
+
 func (t *TaskStatus) updated() {
 	t.LastUpdate = time.Now()
 }
 
-func (s *singleton) Scan(useFileMetadata bool) {
-	if s.Status.Status != Idle {
-		return
+// newJobEncoder returns an encoder whose processes are killed when the
+// running job is stopped.
+func (s *singleton) newJobEncoder() ffmpeg.Encoder {
+	encoder := ffmpeg.NewEncoder(s.FFMPEGPath)
+	encoder.Context = s.jobContext()
+	return encoder
+}
+
+// newJobProgressEncoder returns a job encoder that also reports its progress
+// on the file to the task status.
+func (s *singleton) newJobProgressEncoder(path string) ffmpeg.Encoder {
+	encoder := s.newJobEncoder()
+	encoder.Progress = func(progress float64, remaining time.Duration) {
+		s.Status.setFileProgress(path, progress, remaining)
 	}
-	s.Status.SetStatus(Scan)
-	s.Status.indefiniteProgress()
+	return encoder
+}
 
-	go func() {
-		defer s.returnToIdleState()
+// Scan queues a scan of the stash paths. Returns the id of the queued job.
+func (s *singleton) Scan(input models.ScanMetadataInput) (string, error) {
+	return s.enqueueJob(Scan, input)
+}
+
+// Import queues an import from the metadata directory. Returns the id of
+// the queued job.
+func (s *singleton) Import() (string, error) {
+	return s.enqueueJob(Import, nil)
+}
 
-		var results []string
-		for _, path := range config.GetStashPaths() {
-			globPath := filepath.Join(path, "**/*.{zip,m4v,mp4,mov,wmv,avi,mpg,mpeg,rmvb,rm,flv,asf,mkv,webm}") // TODO: Make this configurable
-			globResults, _ := doublestar.Glob(globPath)
-			results = append(results, globResults...)
+// Export queues an export to the metadata directory. Returns the id of the
+// queued job.
+func (s *singleton) Export() (string, error) {
+	return s.enqueueJob(Export, nil)
+}
+
+// Generate queues generation of the selected content for all scenes.
+// Returns the id of the queued job, or an error if previews are requested
+// with invalid options.
+func (s *singleton) Generate(input models.GenerateMetadataInput) (string, error) {
+	if input.Previews {
+		if err := GetPreviewOptions(input.PreviewOptions).Validate(); err != nil {
+			return "", err
 		}
+	}
+	return s.enqueueJob(Generate, input)
+}
+
+// AutoTag queues auto-tagging of scenes with the provided performers,
+// studios and tags. Returns the id of the queued job.
+func (s *singleton) AutoTag(input models.AutoTagMetadataInput) (string, error) {
+	return s.enqueueJob(AutoTag, input)
+}
+
+// AutoLinkGalleries queues linking of galleries to the scenes that share
+// their path. Returns the id of the queued job.
+func (s *singleton) AutoLinkGalleries() (string, error) {
+	return s.enqueueJob(AutoLinkGalleries, nil)
+}
+
+// MigrateHash queues renaming of the generated scene files to the hash
+// chosen by the scene file naming hash setting. Returns the id of the
+// queued job.
+func (s *singleton) MigrateHash() (string, error) {
+	return s.enqueueJob(MigrateHash, nil)
+}
+
+// Clean queues removal of scenes, galleries and images whose files are
+// missing or excluded, and marks galleries whose files cannot be read as corrupt.
+// Returns the id of the queued job.
+func (s *singleton) Clean(input models.CleanMetadataInput) (string, error) {
+	return s.enqueueJob(Clean, input)
+}
 
-		if s.Status.stopping {
+func (s *singleton) scan(input models.ScanMetadataInput) {
+	paths := config.GetStashPaths()
+	if len(input.Paths) > 0 {
+		paths = nil
+		for _, path := range input.Paths {
+			if !isInStash(path) {
+				logger.Warnf("%s is not in a stash path, skipping", path)
+				continue
+			}
+			paths = append(paths, path)
+		}
+	}
+
+	results := getScanFiles(paths)
+
+	generatePreviews := input.ScanGeneratePreviews != nil && *input.ScanGeneratePreviews
+	generateSprites := input.ScanGenerateSprites != nil && *input.ScanGenerateSprites
+	if generatePreviews || generateSprites {
+		instance.Paths.Generated.EnsureTmpDir()
+		defer instance.Paths.Generated.RemoveTmpDir()
+	}
+
+	if s.Status.isStopping() {
+		logger.Info("Stopping due to user request")
+		return
+	}
+
+	results, _ = excludeFiles(results, config.GetExcludes())
+	total := len(results)
+	logger.Infof("Starting scan of %d files. %d New files found", total, s.neededScan(results))
+
+	s.Status.setProgress(0, total)
+	pool := newWorkerPool(s.jobContext(), config.GetParallelTasks(), s.Status.incrementProgress)
+	for _, path := range results {
+		if s.Status.isStopping() {
 			logger.Info("Stopping due to user request")
-			return
+			break
 		}
 
-		results, _ = excludeFiles(results, config.GetExcludes())
-		total := len(results)
-		logger.Infof("Starting scan of %d files. %d New files found", total, s.neededScan(results))
+		task := ScanTask{
+			FilePath:        path,
+			UseFileMetadata: input.UseFileMetadata,
+			GeneratePreview: generatePreviews,
+			GenerateSprite:  generateSprites,
+		}
+		pool.Run(path, task.Start)
+	}
 
-		var wg sync.WaitGroup
-		s.Status.Progress = 0
-		for i, path := range results {
-			s.Status.setProgress(i, total)
-			if s.Status.stopping {
-				logger.Info("Stopping due to user request")
-				return
+	errors := pool.Wait()
+	s.addJobReportErrors(errors)
+	logger.Infof("Finished scan. %d files failed", len(errors))
+}
+
+// getScanFiles returns the files with scanned extensions in the given
+// directories and their subdirectories, including image files. Paths of
+// files are included if they have a scanned extension. If folder galleries
+// are enabled, directories that directly contain images are included, as
+// are the directories of image paths. Files excluded by the options of
+// their stash path are not returned.
+func getScanFiles(paths []string) []string {
+	folderGalleries := config.GetCreateGalleriesFromFolders()
+
+	var results []string
+	folders := make(map[string]bool)
+	addFolder := func(path string) {
+		if !folders[path] && !excludedByStash(path) {
+			folders[path] = true
+			results = append(results, path)
+		}
+	}
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			logger.Warnf("Error reading %s: %s", path, err.Error())
+			continue
+		}
+
+		if !info.IsDir() {
+			if (isVideo(path) || isGallery(path) || models.IsImage(path)) && !excludedByStash(path) {
+				results = append(results, path)
 			}
-			wg.Add(1)
-			task := ScanTask{FilePath: path, UseFileMetadata: useFileMetadata}
-			go task.Start(&wg)
-			wg.Wait()
+			if folderGalleries && models.IsImage(path) {
+				addFolder(filepath.Dir(path))
+			}
+			continue
 		}
 
-		logger.Info("Finished scan")
-	}()
-}
+		if folderGalleries {
+			for _, folder := range getImageFolders(path) {
+				addFolder(folder)
+			}
+		}
 
-func (s *singleton) Import() {
-	if s.Status.Status != Idle {
-		return
+		// walk rather than glob, since the extensions are matched case
+		// insensitively
+		_ = filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				logger.Warnf("Error reading %s: %s", path, err.Error())
+				return nil
+			}
+
+			if !info.IsDir() && (isVideo(path) || isGallery(path) || models.IsImage(path)) && !excludedByStash(path) {
+				results = append(results, path)
+			}
+			return nil
+		})
 	}
-	s.Status.SetStatus(Import)
-	s.Status.indefiniteProgress()
+	return results
+}
 
-	go func() {
-		defer s.returnToIdleState()
+// getImageFolders returns the directory and its subdirectories that
+// directly contain images.
+func getImageFolders(path string) []string {
+	var ret []string
+	_ = filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			logger.Warnf("Error reading %s: %s", path, err.Error())
+			return nil
+		}
 
-		var wg sync.WaitGroup
-		wg.Add(1)
-		task := ImportTask{}
-		go task.Start(&wg)
-		wg.Wait()
-	}()
+		if !info.IsDir() && models.IsImage(path) {
+			dir := filepath.Dir(path)
+			if len(ret) == 0 || ret[len(ret)-1] != dir {
+				ret = append(ret, dir)
+			}
+		}
+		return nil
+	})
+	return ret
 }
 
-func (s *singleton) Export() {
-	if s.Status.Status != Idle {
-		return
+// excludedByStash returns true if the options of the stash path containing
+// the file exclude it from being scanned.
+func excludedByStash(path string) bool {
+	stash := config.GetStashFromPath(path)
+	if stash == nil {
+		return false
 	}
-	s.Status.SetStatus(Export)
-	s.Status.indefiniteProgress()
 
-	go func() {
-		defer s.returnToIdleState()
+	if stash.ExcludeVideo && isVideo(path) {
+		return true
+	}
+	return stash.ExcludeImage && (isGalleryPath(path) || models.IsImage(path))
+}
 
-		var wg sync.WaitGroup
-		wg.Add(1)
-		task := ExportTask{}
-		go task.Start(&wg)
-		wg.Wait()
-	}()
+// skipGenerate returns true if the file is in a stash path that generated
+// files should not be created for.
+func skipGenerate(path string) bool {
+	stash := config.GetStashFromPath(path)
+	return stash != nil && stash.SkipGenerate
 }
 
-func (s *singleton) Generate(sprites bool, previews bool, markers bool, transcodes bool) {
-	if s.Status.Status != Idle {
-		return
+// isVideo returns true if the file has one of the video extensions.
+func isVideo(path string) bool {
+	return matchExtension(path, config.GetVideoExtensions())
+}
+
+// isGallery returns true if the file has one of the gallery extensions.
+func isGallery(path string) bool {
+	return matchExtension(path, config.GetGalleryExtensions())
+}
+
+// isGalleryPath returns true if the path is a gallery file or a directory,
+// which is scanned as a folder gallery.
+func isGalleryPath(path string) bool {
+	if isGallery(path) {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// matchExtension returns true if the file has one of the extensions. The
+// extension is not case sensitive.
+func matchExtension(path string, extensions []string) bool {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	for _, e := range extensions {
+		if strings.EqualFold(ext, e) {
+			return true
+		}
 	}
-	s.Status.SetStatus(Generate)
-	s.Status.indefiniteProgress()
+	return false
+}
+
+// isInStash returns true if the path is a stash path or is within one.
+func isInStash(path string) bool {
+	return config.GetStashFromPath(path) != nil
+}
+
+func (s *singleton) doImport() {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	task := ImportTask{}
+	go task.Start(&wg)
+	wg.Wait()
+}
+
+func (s *singleton) export() {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	task := ExportTask{}
+	go task.Start(&wg)
+	wg.Wait()
+}
+
+func (s *singleton) generate(input models.GenerateMetadataInput) {
+	sprites := input.Sprites
+	previews := input.Previews
+	markers := input.Markers
+	transcodes := input.Transcodes
+	phashes := input.Phashes != nil && *input.Phashes
+	galleryThumbnails := input.GalleryThumbnails != nil && *input.GalleryThumbnails
+	previewOptions := GetPreviewOptions(input.PreviewOptions)
 
 	qb := models.NewSceneQueryBuilder()
 	//this.job.total = await ObjectionUtils.getCount(Scene);
 	instance.Paths.Generated.EnsureTmpDir()
 
-	go func() {
-		defer s.returnToIdleState()
+	allScenes, err := qb.All()
+	if err != nil {
+		logger.Errorf("failed to get scenes for generate")
+		return
+	}
+
+	var scenes []*models.Scene
+	for _, scene := range allScenes {
+		if scene != nil && skipGenerate(scene.Path) {
+			continue
+		}
+		scenes = append(scenes, scene)
+	}
 
-		scenes, err := qb.All()
+	var galleries []*models.Gallery
+	if galleryThumbnails {
+		galleries, err = s.getGenerateGalleries()
 		if err != nil {
-			logger.Errorf("failed to get scenes for generate")
+			logger.Errorf("failed to get galleries for generate: %s", err.Error())
 			return
 		}
+	}
+
+	delta := utils.Btoi(sprites) + utils.Btoi(previews) + utils.Btoi(markers) + utils.Btoi(transcodes) + utils.Btoi(phashes)
+	total := len(scenes)*delta + len(galleries)
 
-		delta := utils.Btoi(sprites) + utils.Btoi(previews) + utils.Btoi(markers) + utils.Btoi(transcodes)
-		var wg sync.WaitGroup
-		s.Status.Progress = 0
-		total := len(scenes)
+	if s.Status.isStopping() {
+		logger.Info("Stopping due to user request")
+		return
+	}
+	totalsNeeded := s.neededGenerate(scenes, sprites, previews, markers, transcodes, phashes, previewOptions)
+	totalsNeeded.galleryThumbnails = s.neededGalleryThumbnails(galleries)
+	logger.Infof("Generating %d sprites %d previews %d markers %d transcodes %d phashes %d gallery thumbnails", totalsNeeded.sprites, totalsNeeded.previews, totalsNeeded.markers, totalsNeeded.transcodes, totalsNeeded.phashes, totalsNeeded.galleryThumbnails)
 
-		if s.Status.stopping {
+	s.Status.setProgress(0, total)
+	pool := newWorkerPool(s.jobContext(), config.GetParallelTasks(), s.Status.incrementProgress)
+	for _, scene := range scenes {
+		if s.Status.isStopping() {
 			logger.Info("Stopping due to user request")
-			return
+			break
 		}
-		totalsNeeded := s.neededGenerate(scenes, sprites, previews, markers, transcodes)
-		logger.Infof("Generating %d sprites %d previews %d markers %d transcodes", totalsNeeded.sprites, totalsNeeded.previews, totalsNeeded.markers, totalsNeeded.transcodes)
-
-		for i, scene := range scenes {
-			s.Status.setProgress(i, total)
-			if s.Status.stopping {
-				logger.Info("Stopping due to user request")
-				return
-			}
-
-			if scene == nil {
-				logger.Errorf("nil scene, skipping generate")
-				continue
-			}
 
-			wg.Add(delta)
+		if scene == nil {
+			logger.Errorf("nil scene, skipping generate")
+			continue
+		}
 
-			// Clear the tmp directory for each scene
-			if sprites || previews || markers {
-				instance.Paths.Generated.EmptyTmpDir()
-			}
+		if sprites {
+			task := GenerateSpriteTask{Scene: *scene}
+			pool.Run(scene.Path, task.Start)
+		}
 
-			if sprites {
-				task := GenerateSpriteTask{Scene: *scene}
-				go task.Start(&wg)
-			}
+		if previews {
+			task := GeneratePreviewTask{Scene: *scene, Options: previewOptions}
+			pool.Run(scene.Path, task.Start)
+		}
 
-			if previews {
-				task := GeneratePreviewTask{Scene: *scene}
-				go task.Start(&wg)
-			}
+		if markers {
+			task := GenerateMarkersTask{Scene: *scene}
+			pool.Run(scene.Path, task.Start)
+		}
 
-			if markers {
-				task := GenerateMarkersTask{Scene: *scene}
-				go task.Start(&wg)
-			}
+		if transcodes {
+			task := GenerateTranscodeTask{Scene: *scene}
+			pool.Run(scene.Path, task.Start)
+		}
 
-			if transcodes {
-				task := GenerateTranscodeTask{Scene: *scene}
-				go task.Start(&wg)
-			}
+		if phashes {
+			task := GeneratePhashTask{Scene: *scene}
+			pool.Run(scene.Path, task.Start)
+		}
+	}
 
-			wg.Wait()
+	for _, gallery := range galleries {
+		if s.Status.isStopping() {
+			logger.Info("Stopping due to user request")
+			break
 		}
-	}()
-}
 
-func (s *singleton) AutoTag(performerIds []string, studioIds []string, tagIds []string) {
-	if s.Status.Status != Idle {
-		return
+		task := GenerateGalleryThumbnailsTask{Gallery: *gallery}
+		pool.Run(gallery.Path, task.Start)
 	}
-	s.Status.SetStatus(AutoTag)
-	s.Status.indefiniteProgress()
 
-	go func() {
-		defer s.returnToIdleState()
+	errors := pool.Wait()
+	s.addJobReportErrors(errors)
+	logger.Infof("Finished generating. %d tasks failed", len(errors))
+}
 
-		// calculate work load
-		performerCount := len(performerIds)
-		studioCount := len(studioIds)
-		tagCount := len(tagIds)
+func (s *singleton) autoTag(input models.AutoTagMetadataInput) {
+	performerIds := input.Performers
+	studioIds := input.Studios
+	tagIds := input.Tags
 
-		performerQuery := models.NewPerformerQueryBuilder()
-		studioQuery := models.NewTagQueryBuilder()
-		tagQuery := models.NewTagQueryBuilder()
+	// calculate work load
+	performerCount := len(performerIds)
+	studioCount := len(studioIds)
+	tagCount := len(tagIds)
 
-		const wildcard = "*"
-		var err error
-		if performerCount == 1 && performerIds[0] == wildcard {
-			performerCount, err = performerQuery.Count()
-			if err != nil {
-				logger.Errorf("Error getting performer count: %s", err.Error())
-			}
+	performerQuery := models.NewPerformerQueryBuilder()
+	studioQuery := models.NewTagQueryBuilder()
+	tagQuery := models.NewTagQueryBuilder()
+
+	const wildcard = "*"
+	var err error
+	if performerCount == 1 && performerIds[0] == wildcard {
+		performerCount, err = performerQuery.Count()
+		if err != nil {
+			logger.Errorf("Error getting performer count: %s", err.Error())
 		}
-		if studioCount == 1 && studioIds[0] == wildcard {
-			studioCount, err = studioQuery.Count()
-			if err != nil {
-				logger.Errorf("Error getting studio count: %s", err.Error())
-			}
+	}
+	if studioCount == 1 && studioIds[0] == wildcard {
+		studioCount, err = studioQuery.Count()
+		if err != nil {
+			logger.Errorf("Error getting studio count: %s", err.Error())
 		}
-		if tagCount == 1 && tagIds[0] == wildcard {
-			tagCount, err = tagQuery.Count()
-			if err != nil {
-				logger.Errorf("Error getting tag count: %s", err.Error())
-			}
+	}
+	if tagCount == 1 && tagIds[0] == wildcard {
+		tagCount, err = tagQuery.Count()
+		if err != nil {
+			logger.Errorf("Error getting tag count: %s", err.Error())
 		}
+	}
 
-		total := performerCount + studioCount + tagCount
-		s.Status.setProgress(0, total)
+	total := performerCount + studioCount + tagCount
+	s.Status.setProgress(0, total)
 
-		s.autoTagPerformers(performerIds)
-		s.autoTagStudios(studioIds)
-		s.autoTagTags(tagIds)
-	}()
+	s.autoTagPerformers(performerIds)
+	s.autoTagStudios(studioIds)
+	s.autoTagTags(tagIds)
 }
 
 func (s *singleton) autoTagPerformers(performerIds []string) {
@@ -377,66 +693,198 @@ func (s *singleton) autoTagTags(tagIds []string) {
 	}
 }
 
-func (s *singleton) Clean() {
-	if s.Status.Status != Idle {
+func (s *singleton) autoLinkGalleries() {
+	qb := models.NewGalleryQueryBuilder()
+	galleries, err := qb.All()
+	if err != nil {
+		logger.Errorf("Error querying galleries: %s", err.Error())
 		return
 	}
-	s.Status.SetStatus(Clean)
-	s.Status.indefiniteProgress()
 
+	logger.Infof("Starting linking of %d galleries", len(galleries))
+
+	var errors []taskError
+	for i, gallery := range galleries {
+		s.Status.setProgress(i, len(galleries))
+		if s.Status.isStopping() {
+			logger.Info("Stopping due to user request")
+			break
+		}
+
+		task := AutoLinkGalleryTask{Gallery: *gallery}
+		if err := task.Start(); err != nil {
+			errors = append(errors, taskError{Path: gallery.Path, Err: err})
+		}
+	}
+
+	s.addJobReportErrors(errors)
+	logger.Infof("Finished linking galleries. %d galleries failed", len(errors))
+}
+
+func (s *singleton) migrateHash() {
 	qb := models.NewSceneQueryBuilder()
-	go func() {
-		defer s.returnToIdleState()
+	scenes, err := qb.All()
+	if err != nil {
+		logger.Errorf("Error querying scenes: %s", err.Error())
+		return
+	}
 
-		logger.Infof("Starting cleaning of tracked files")
-		scenes, err := qb.All()
-		if err != nil {
-			logger.Errorf("failed to fetch list of scenes for cleaning")
+	logger.Infof("Starting renaming of generated files of %d scenes", len(scenes))
+
+	var errors []taskError
+	for i, scene := range scenes {
+		s.Status.setProgress(i, len(scenes))
+		if s.Status.isStopping() {
+			logger.Info("Stopping due to user request")
+			break
+		}
+
+		task := MigrateHashTask{Scene: *scene}
+		if err := task.Start(); err != nil {
+			errors = append(errors, taskError{Path: scene.Path, Err: err})
+		}
+	}
+
+	s.addJobReportErrors(errors)
+	logger.Infof("Finished renaming generated files. %d scenes failed", len(errors))
+}
+
+func (s *singleton) clean(input models.CleanMetadataInput) {
+	qb := models.NewSceneQueryBuilder()
+	gqb := models.NewGalleryQueryBuilder()
+	iqb := models.NewImageQueryBuilder()
+	dryRun := input.DryRun != nil && *input.DryRun
+
+	logger.Infof("Starting cleaning of tracked files")
+	scenes, err := qb.All()
+	if err != nil {
+		logger.Errorf("failed to fetch list of scenes for cleaning")
+		return
+	}
+
+	galleries, err := gqb.All()
+	if err != nil {
+		logger.Errorf("failed to fetch list of galleries for cleaning")
+		return
+	}
+
+	images, err := iqb.All()
+	if err != nil {
+		logger.Errorf("failed to fetch list of images for cleaning")
+		return
+	}
+
+	if s.Status.isStopping() {
+		logger.Info("Stopping due to user request")
+		return
+	}
+
+	// find everything to remove before removing anything, so that the clean
+	// can be aborted if it would remove too much
+	var toClean []cleanItem
+	total := len(scenes) + len(galleries) + len(images)
+	for i, scene := range scenes {
+		s.Status.setProgress(i, total)
+		if s.Status.isStopping() {
+			logger.Info("Stopping due to user request")
 			return
 		}
 
-		if s.Status.stopping {
+		if scene == nil {
+			logger.Errorf("nil scene, skipping Clean")
+			continue
+		}
+
+		task := &CleanTask{Scene: *scene, DryRun: dryRun}
+		if reason := task.getCleanReason(); reason != "" {
+			toClean = append(toClean, cleanItem{task: task, path: scene.Path, reason: reason})
+		}
+	}
+
+	// kept galleries are checked for corruption once the clean is known not
+	// to be aborted
+	var keptGalleries []*CleanGalleryTask
+	removedGalleries := make(map[int]bool)
+	for i, gallery := range galleries {
+		s.Status.setProgress(len(scenes)+i, total)
+		if s.Status.isStopping() {
 			logger.Info("Stopping due to user request")
 			return
 		}
 
-		var wg sync.WaitGroup
-		s.Status.Progress = 0
-		total := len(scenes)
-		for i, scene := range scenes {
-			s.Status.setProgress(i, total)
-			if s.Status.stopping {
-				logger.Info("Stopping due to user request")
-				return
-			}
+		task := &CleanGalleryTask{Gallery: *gallery, DryRun: dryRun}
+		if reason := task.getCleanReason(); reason != "" {
+			toClean = append(toClean, cleanItem{task: task, path: gallery.Path, reason: reason})
+			removedGalleries[gallery.ID] = true
+		} else {
+			keptGalleries = append(keptGalleries, task)
+		}
+	}
 
-			if scene == nil {
-				logger.Errorf("nil scene, skipping Clean")
-				continue
-			}
+	for i, image := range images {
+		s.Status.setProgress(len(scenes)+len(galleries)+i, total)
+		if s.Status.isStopping() {
+			logger.Info("Stopping due to user request")
+			return
+		}
 
-			wg.Add(1)
+		task := &CleanImageTask{Image: *image, DryRun: dryRun, RemovedGalleries: removedGalleries}
+		if reason := task.getCleanReason(); reason != "" {
+			toClean = append(toClean, cleanItem{task: task, path: task.getName(), reason: reason})
+		}
+	}
 
-			task := CleanTask{Scene: *scene}
-			go task.Start(&wg)
-			wg.Wait()
+	if s.abortClean(checkCleanThreshold(len(toClean), total, config.GetCleanThreshold()), dryRun) {
+		return
+	}
+
+	cleanTotal := len(toClean) + len(keptGalleries)
+	s.Status.setProgress(0, cleanTotal)
+	var errors []taskError
+	defer func() {
+		s.addJobReportErrors(errors)
+	}()
+
+	for i, item := range toClean {
+		if s.Status.isStopping() {
+			logger.Info("Stopping due to user request")
+			return
+		}
+
+		if err := item.task.clean(item.reason); err != nil {
+			logger.Errorf("Error cleaning %s: %s", item.path, err.Error())
+			errors = append(errors, taskError{Path: item.path, Err: err})
+		}
+		s.Status.setProgress(i+1, cleanTotal)
+	}
+
+	for i, task := range keptGalleries {
+		if s.Status.isStopping() {
+			logger.Info("Stopping due to user request")
+			return
 		}
 
+		task.checkCorrupt()
+		s.Status.setProgress(len(toClean)+i+1, cleanTotal)
+	}
+
+	if err := cleanOrphanedThumbnails(dryRun); err != nil {
+		logger.Errorf("Error cleaning gallery thumbnails: %s", err.Error())
+		errors = append(errors, taskError{Path: s.Paths.Generated.Thumbnails, Err: err})
+	}
+
+	if dryRun {
+		logger.Infof("Finished dry run of cleaning. %d scenes, galleries and images would be removed", len(toClean))
+	} else {
 		logger.Info("Finished Cleaning")
-	}()
+	}
 }
 
 func (s *singleton) returnToIdleState() {
-	if r := recover(); r != nil {
-		logger.Info("recovered from ", r)
-	}
-
-	if s.Status.Status == Generate {
+	if s.Status.getStatus() == Generate {
 		instance.Paths.Generated.RemoveTmpDir()
 	}
-	s.Status.SetStatus(Idle)
-	s.Status.indefiniteProgress()
-	s.Status.stopping = false
+	s.Status.reset()
 }
 
 func (s *singleton) neededScan(paths []string) int64 {
@@ -456,23 +904,26 @@ type totalsGenerate struct {
 	previews   int64
 	markers    int64
 	transcodes int64
+	phashes    int64
+
+	galleryThumbnails int64
 }
 
-func (s *singleton) neededGenerate(scenes []*models.Scene, sprites, previews, markers, transcodes bool) *totalsGenerate {
+func (s *singleton) neededGenerate(scenes []*models.Scene, sprites, previews, markers, transcodes, phashes bool, previewOptions PreviewOptions) *totalsGenerate {
 
 	var totals totalsGenerate
 	for _, scene := range scenes {
 		if scene != nil {
 			if sprites {
 				task := GenerateSpriteTask{Scene: *scene}
-				if !task.doesSpriteExist(task.Scene.Checksum) {
+				if !task.doesSpriteExist(GetSceneHash(&task.Scene)) {
 					totals.sprites++
 				}
 			}
 
 			if previews {
-				task := GeneratePreviewTask{Scene: *scene}
-				if !task.doesPreviewExist(task.Scene.Checksum) {
+				task := GeneratePreviewTask{Scene: *scene, Options: previewOptions}
+				if !task.doesPreviewExist(GetSceneHash(&task.Scene)) {
 					totals.previews++
 				}
 			}
@@ -488,7 +939,42 @@ func (s *singleton) neededGenerate(scenes []*models.Scene, sprites, previews, ma
 					totals.transcodes++
 				}
 			}
+			if phashes {
+				task := GeneratePhashTask{Scene: *scene}
+				if !task.doesPhashExist() {
+					totals.phashes++
+				}
+			}
 		}
 	}
 	return &totals
 }
+
+// getGenerateGalleries returns the galleries that are not in stash paths
+// that skip generated files.
+func (s *singleton) getGenerateGalleries() ([]*models.Gallery, error) {
+	qb := models.NewGalleryQueryBuilder()
+	allGalleries, err := qb.All()
+	if err != nil {
+		return nil, err
+	}
+
+	var galleries []*models.Gallery
+	for _, gallery := range allGalleries {
+		if !skipGenerate(gallery.Path) {
+			galleries = append(galleries, gallery)
+		}
+	}
+	return galleries, nil
+}
+
+func (s *singleton) neededGalleryThumbnails(galleries []*models.Gallery) int64 {
+	var ret int64
+	for _, gallery := range galleries {
+		task := GenerateGalleryThumbnailsTask{Gallery: *gallery}
+		if task.isThumbnailsNeeded() {
+			ret++
+		}
+	}
+	return ret
+}
@@ -0,0 +1,38 @@
+package manager
+
+import (
+	"bytes"
+	"image"
+	"testing"
+)
+
+func TestMatchExtension(t *testing.T) {
+	extensions := []string{"mp4", "MKV"}
+
+	scenarios := map[string]bool{
+		"/stash/video.mp4": true,
+		"/stash/video.MP4": true,
+		"/stash/video.mkv": true,
+		"/stash/video.avi": false,
+		"/stash/mp4":       false,
+	}
+
+	for path, expected := range scenarios {
+		if result := matchExtension(path, extensions); result != expected {
+			t.Errorf("[%s] Was expecting %v, found %v", path, expected, result)
+		}
+	}
+}
+
+func TestDecodeWebp(t *testing.T) {
+	// a lossless 1x1 webp image
+	data := []byte("RIFF\x1a\x00\x00\x00WEBPVP8L\x0d\x00\x00\x00\x2f\x00\x00\x00\x10\x07\x10\x11\x11\x88\x88\xfe\x07\x00")
+
+	imageConfig, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Error decoding webp image: %s", err.Error())
+	}
+	if format != "webp" || imageConfig.Width != 1 || imageConfig.Height != 1 {
+		t.Errorf("Was expecting a 1x1 webp image, found a %dx%d %s image", imageConfig.Width, imageConfig.Height, format)
+	}
+}
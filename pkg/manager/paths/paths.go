@@ -19,7 +19,7 @@ func NewPaths() *Paths {
 	p.Generated = newGeneratedPaths()
 	p.JSON = newJSONPaths()
 
-	p.Gallery = newGalleryPaths()
+	p.Gallery = newGalleryPaths(p)
 	p.Scene = newScenePaths(p)
 	p.SceneMarkers = newSceneMarkerPaths(p)
 	return &p
@@ -5,10 +5,14 @@ import (
 	"path/filepath"
 )
 
-type galleryPaths struct{}
+type galleryPaths struct {
+	generated generatedPaths
+}
 
-func newGalleryPaths() *galleryPaths {
-	return &galleryPaths{}
+func newGalleryPaths(p Paths) *galleryPaths {
+	gp := galleryPaths{}
+	gp.generated = *p.Generated
+	return &gp
 }
 
 func (gp *galleryPaths) GetExtractedPath(checksum string) string {
@@ -18,3 +22,9 @@ func (gp *galleryPaths) GetExtractedPath(checksum string) string {
 func (gp *galleryPaths) GetExtractedFilePath(checksum string, fileName string) string {
 	return filepath.Join(config.GetCachePath(), checksum, fileName)
 }
+
+// GetThumbnailPath returns the path of the thumbnail of the gallery image
+// with the given checksum.
+func (gp *galleryPaths) GetThumbnailPath(imageChecksum string) string {
+	return filepath.Join(gp.generated.Thumbnails, imageChecksum+".jpg")
+}
@@ -11,6 +11,8 @@ type generatedPaths struct {
 	Vtt         string
 	Markers     string
 	Transcodes  string
+	Thumbnails  string
+	HLS         string
 	Tmp         string
 }
 
@@ -20,6 +22,8 @@ func newGeneratedPaths() *generatedPaths {
 	gp.Vtt = filepath.Join(config.GetGeneratedPath(), "vtt")
 	gp.Markers = filepath.Join(config.GetGeneratedPath(), "markers")
 	gp.Transcodes = filepath.Join(config.GetGeneratedPath(), "transcodes")
+	gp.Thumbnails = filepath.Join(config.GetGeneratedPath(), "thumbnails")
+	gp.HLS = filepath.Join(config.GetGeneratedPath(), "hls")
 	gp.Tmp = filepath.Join(config.GetGeneratedPath(), "tmp")
 	return &gp
 }
@@ -12,6 +12,7 @@ type jsonPaths struct {
 	Performers string
 	Scenes     string
 	Galleries  string
+	Images     string
 	Studios    string
 }
 
@@ -22,6 +23,7 @@ func newJSONPaths() *jsonPaths {
 	jp.Performers = filepath.Join(config.GetMetadataPath(), "performers")
 	jp.Scenes = filepath.Join(config.GetMetadataPath(), "scenes")
 	jp.Galleries = filepath.Join(config.GetMetadataPath(), "galleries")
+	jp.Images = filepath.Join(config.GetMetadataPath(), "images")
 	jp.Studios = filepath.Join(config.GetMetadataPath(), "studios")
 	return &jp
 }
@@ -34,6 +36,14 @@ func (jp *jsonPaths) SceneJSONPath(checksum string) string {
 	return filepath.Join(jp.Scenes, checksum+".json")
 }
 
+func (jp *jsonPaths) GalleryJSONPath(checksum string) string {
+	return filepath.Join(jp.Galleries, checksum+".json")
+}
+
+func (jp *jsonPaths) ImageJSONPath(checksum string) string {
+	return filepath.Join(jp.Images, checksum+".json")
+}
+
 func (jp *jsonPaths) StudioJSONPath(checksum string) string {
 	return filepath.Join(jp.Studios, checksum+".json")
 }
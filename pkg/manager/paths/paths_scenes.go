@@ -1,8 +1,10 @@
 package paths
 
 import (
-	"github.com/stashapp/stash/pkg/utils"
 	"path/filepath"
+	"strconv"
+
+	"github.com/stashapp/stash/pkg/utils"
 )
 
 type scenePaths struct {
@@ -36,6 +38,16 @@ func (sp *scenePaths) GetStreamPath(scenePath string, checksum string) string {
 	return scenePath
 }
 
+// GetHLSDirectory returns the directory of the cached HLS segments of the
+// scene at the given resolution.
+func (sp *scenePaths) GetHLSDirectory(checksum string, resolution string) string {
+	return filepath.Join(sp.generated.HLS, checksum+"_"+resolution)
+}
+
+func (sp *scenePaths) GetHLSSegmentPath(checksum string, resolution string, segment int) string {
+	return filepath.Join(sp.GetHLSDirectory(checksum, resolution), strconv.Itoa(segment)+".ts")
+}
+
 func (sp *scenePaths) GetStreamPreviewPath(checksum string) string {
 	return filepath.Join(sp.generated.Screenshots, checksum+".mp4")
 }
@@ -44,6 +56,12 @@ func (sp *scenePaths) GetStreamPreviewImagePath(checksum string) string {
 	return filepath.Join(sp.generated.Screenshots, checksum+".webp")
 }
 
+// GetStreamPreviewOptionsPath returns the path of the options that the
+// preview of the scene was generated with.
+func (sp *scenePaths) GetStreamPreviewOptionsPath(checksum string) string {
+	return filepath.Join(sp.generated.Screenshots, checksum+"_preview.json")
+}
+
 func (sp *scenePaths) GetSpriteImageFilePath(checksum string) string {
 	return filepath.Join(sp.generated.Vtt, checksum+"_sprite.jpg")
 }
@@ -0,0 +1,132 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// PreviewOptions are the options that a scene preview is generated with.
+// They are saved alongside the preview, so that the preview is regenerated
+// when the options change.
+type PreviewOptions struct {
+	Segments        int                  `json:"segments"`
+	SegmentDuration float64              `json:"segment_duration"`
+	ExcludeStart    float64              `json:"exclude_start"`
+	ExcludeEnd      float64              `json:"exclude_end"`
+	Preset          models.PreviewPreset `json:"preset"`
+	Audio           bool                 `json:"audio"`
+}
+
+// defaultPreviewOptions are the options of previews generated before the
+// options were saved with them, which are the default config values.
+var defaultPreviewOptions = PreviewOptions{
+	Segments:        config.DefaultPreviewSegments,
+	SegmentDuration: config.DefaultPreviewSegmentDuration,
+	Preset:          config.DefaultPreviewPreset,
+	Audio:           config.DefaultPreviewAudio,
+}
+
+// GetPreviewOptions returns the configured preview options, overridden by
+// the options that are set in input. input may be nil.
+func GetPreviewOptions(input *models.GeneratePreviewOptionsInput) PreviewOptions {
+	ret := PreviewOptions{
+		Segments:        config.GetPreviewSegments(),
+		SegmentDuration: config.GetPreviewSegmentDuration(),
+		ExcludeStart:    config.GetPreviewExcludeStart(),
+		ExcludeEnd:      config.GetPreviewExcludeEnd(),
+		Preset:          config.GetPreviewPreset(),
+		Audio:           config.GetPreviewAudio(),
+	}
+
+	if input == nil {
+		return ret
+	}
+
+	if input.PreviewSegments != nil {
+		ret.Segments = *input.PreviewSegments
+	}
+	if input.PreviewSegmentDuration != nil {
+		ret.SegmentDuration = *input.PreviewSegmentDuration
+	}
+	if input.PreviewExcludeStart != nil {
+		ret.ExcludeStart = *input.PreviewExcludeStart
+	}
+	if input.PreviewExcludeEnd != nil {
+		ret.ExcludeEnd = *input.PreviewExcludeEnd
+	}
+	if input.PreviewPreset != nil {
+		ret.Preset = *input.PreviewPreset
+	}
+	if input.PreviewAudio != nil {
+		ret.Audio = *input.PreviewAudio
+	}
+
+	return ret
+}
+
+// Validate returns an error if previews cannot be generated with the
+// options.
+func (o PreviewOptions) Validate() error {
+	if o.Segments < 1 {
+		return fmt.Errorf("preview segments must be at least 1")
+	}
+	if o.SegmentDuration <= 0 {
+		return fmt.Errorf("preview segment duration must be greater than 0")
+	}
+	if o.ExcludeStart < 0 || o.ExcludeEnd < 0 {
+		return fmt.Errorf("preview exclude percentages must not be negative")
+	}
+	if o.ExcludeStart+o.ExcludeEnd >= 100 {
+		return fmt.Errorf("preview exclude percentages must total less than 100")
+	}
+	if !o.Preset.IsValid() {
+		return fmt.Errorf("invalid preview preset %s", o.Preset)
+	}
+	return nil
+}
+
+// segmentTimes returns the start times of the preview segments of a video
+// of the given duration. The segments are evenly spaced between the excluded
+// start and end of the video.
+func (o PreviewOptions) segmentTimes(duration float64) []float64 {
+	start := duration * o.ExcludeStart / 100
+	end := duration * (100 - o.ExcludeEnd) / 100
+	stepSize := (end - start) / float64(o.Segments)
+
+	ret := make([]float64, o.Segments)
+	for i := range ret {
+		ret[i] = start + float64(i)*stepSize
+	}
+	return ret
+}
+
+// readPreviewOptions returns the options saved at path. Previews without
+// saved options were generated with the default options.
+func readPreviewOptions(path string) (PreviewOptions, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return defaultPreviewOptions, nil
+	}
+	if err != nil {
+		return PreviewOptions{}, err
+	}
+
+	var ret PreviewOptions
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return PreviewOptions{}, err
+	}
+	return ret, nil
+}
+
+func writePreviewOptions(path string, options PreviewOptions) error {
+	data, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
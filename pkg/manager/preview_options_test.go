@@ -0,0 +1,55 @@
+package manager
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func TestPreviewSegmentTimes(t *testing.T) {
+	scenarios := []struct {
+		segments     int
+		excludeStart float64
+		excludeEnd   float64
+		expected     []float64
+	}{
+		{4, 0, 0, []float64{0, 25, 50, 75}},
+		{4, 20, 0, []float64{20, 40, 60, 80}},
+		{4, 0, 20, []float64{0, 20, 40, 60}},
+		{2, 10, 30, []float64{10, 40}},
+		{1, 0, 0, []float64{0}},
+	}
+
+	for i, s := range scenarios {
+		options := PreviewOptions{
+			Segments:     s.segments,
+			ExcludeStart: s.excludeStart,
+			ExcludeEnd:   s.excludeEnd,
+		}
+		times := options.segmentTimes(100)
+		if !reflect.DeepEqual(times, s.expected) {
+			t.Errorf("[%d] Was expecting %v, found %v", i, s.expected, times)
+		}
+	}
+}
+
+func TestPreviewOptionsValidate(t *testing.T) {
+	if err := defaultPreviewOptions.Validate(); err != nil {
+		t.Errorf("Was expecting default options to be valid, found %s", err.Error())
+	}
+
+	invalid := []PreviewOptions{
+		{Segments: 0, SegmentDuration: 1, Preset: models.PreviewPresetSlow},
+		{Segments: 12, SegmentDuration: 0, Preset: models.PreviewPresetSlow},
+		{Segments: 12, SegmentDuration: 1, ExcludeStart: -1, Preset: models.PreviewPresetSlow},
+		{Segments: 12, SegmentDuration: 1, ExcludeStart: 60, ExcludeEnd: 40, Preset: models.PreviewPresetSlow},
+		{Segments: 12, SegmentDuration: 1, Preset: models.PreviewPreset("placebo")},
+	}
+
+	for i, options := range invalid {
+		if err := options.Validate(); err == nil {
+			t.Errorf("[%d] Was expecting an error for %v", i, options)
+		}
+	}
+}
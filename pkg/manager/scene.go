@@ -1,13 +1,17 @@
 package manager
 
 import (
+	"database/sql"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	
 	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/utils"
 )
@@ -44,18 +48,165 @@ func DestroyScene(sceneID int, tx *sqlx.Tx) (error) {
 	return nil
 }
 
-func DeleteGeneratedSceneFiles(scene *models.Scene) {
-	markersFolder := filepath.Join(GetInstance().Paths.Generated.Markers, scene.Checksum)
+// MergeScenes moves the performers, tags, markers, galleries and rating of
+// the source scenes onto the destination scene, then destroys the source
+// scenes. The rating of the destination scene is kept if set.
+func MergeScenes(sourceIDs []int, destinationID int, tx *sqlx.Tx) error {
+	qb := models.NewSceneQueryBuilder()
+	jqb := models.NewJoinsQueryBuilder()
+	mqb := models.NewSceneMarkerQueryBuilder()
+
+	destination, err := qb.Find(destinationID)
+	if err != nil {
+		return err
+	}
+	if destination == nil {
+		return fmt.Errorf("scene with id %d not found", destinationID)
+	}
+
+	rating := destination.Rating
+
+	for _, sourceID := range sourceIDs {
+		if sourceID == destinationID {
+			return fmt.Errorf("cannot merge scene %d into itself", sourceID)
+		}
+
+		source, err := qb.Find(sourceID)
+		if err != nil {
+			return err
+		}
+		if source == nil {
+			return fmt.Errorf("scene with id %d not found", sourceID)
+		}
+
+		performers, err := jqb.GetScenePerformers(sourceID, tx)
+		if err != nil {
+			return err
+		}
+		for _, performer := range performers {
+			if _, err := jqb.AddPerformerScene(destinationID, performer.PerformerID, tx); err != nil {
+				return err
+			}
+		}
+
+		tags, err := jqb.GetSceneTags(sourceID, tx)
+		if err != nil {
+			return err
+		}
+		for _, tag := range tags {
+			if _, err := jqb.AddSceneTag(destinationID, tag.TagID, tx); err != nil {
+				return err
+			}
+		}
+
+		markers, err := mqb.FindBySceneID(sourceID, tx)
+		if err != nil {
+			return err
+		}
+		for _, marker := range markers {
+			marker.SceneID = sql.NullInt64{Int64: int64(destinationID), Valid: true}
+			marker.UpdatedAt = models.SQLiteTimestamp{Timestamp: time.Now()}
+			if _, err := mqb.Update(*marker, tx); err != nil {
+				return err
+			}
+		}
+
+		galleries, err := jqb.GetSceneGalleries(sourceID, tx)
+		if err != nil {
+			return err
+		}
+		for _, gallery := range galleries {
+			if _, err := jqb.AddSceneGallery(destinationID, gallery.GalleryID, tx); err != nil {
+				return err
+			}
+		}
+
+		if !rating.Valid && source.Rating.Valid {
+			rating = source.Rating
+		}
+
+		if err := DestroyScene(sourceID, tx); err != nil {
+			return err
+		}
+	}
+
+	if rating != destination.Rating {
+		updatedTime := models.SQLiteTimestamp{Timestamp: time.Now()}
+		scenePartial := models.ScenePartial{
+			ID:        destinationID,
+			Rating:    &rating,
+			UpdatedAt: &updatedTime,
+		}
+		if _, err := qb.Update(scenePartial, tx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isSceneHashUsed returns true if a scene has the given checksum or oshash.
+// Errors are treated as the hash being used.
+func isSceneHashUsed(sceneHash string) bool {
+	qb := models.NewSceneQueryBuilder()
+	scene, err := qb.FindByChecksum(sceneHash)
+	if err != nil || scene != nil {
+		return true
+	}
+
+	fqb := models.NewSceneFingerprintQueryBuilder()
+	fingerprints, err := fqb.FindByFingerprint(models.FingerprintTypeOshash, sceneHash, nil)
+	return err != nil || len(fingerprints) > 0
+}
+
+// GetSceneHash returns the hash used to name the generated files of the
+// scene, as chosen by the scene file naming hash setting. The other hash is
+// used if the scene does not have the chosen hash.
+func GetSceneHash(scene *models.Scene) string {
+	oshash := ""
+	if config.GetSceneFileNamingHash() == models.HashAlgorithmOshash || !scene.Checksum.Valid {
+		qb := models.NewSceneFingerprintQueryBuilder()
+		fingerprint, _ := qb.Find(scene.ID, models.FingerprintTypeOshash, nil)
+		if fingerprint != nil {
+			oshash = fingerprint.Fingerprint
+		}
+	}
+
+	return selectSceneHash(scene.Checksum.String, oshash)
+}
+
+// selectSceneHash returns the hash to name generated files with, given the
+// MD5 checksum and oshash of a scene file. The checksum is empty if it was
+// not calculated.
+func selectSceneHash(checksum string, oshash string) string {
+	if oshash != "" && (checksum == "" || config.GetSceneFileNamingHash() == models.HashAlgorithmOshash) {
+		return oshash
+	}
+
+	return checksum
+}
+
+// DeleteGeneratedSceneFiles deletes the generated files named with the given
+// scene hash. The hash must be found with GetSceneHash before the scene is
+// destroyed. Duplicate scenes share their generated files, so the files are
+// kept while another scene has the same hash.
+func DeleteGeneratedSceneFiles(sceneHash string) {
+	if isSceneHashUsed(sceneHash) {
+		logger.Debugf("Keeping generated files of %s, which are used by another scene", sceneHash)
+		return
+	}
+
+	markersFolder := filepath.Join(GetInstance().Paths.Generated.Markers, sceneHash)
 
 	exists, _ := utils.FileExists(markersFolder)
 	if exists {
 		err := os.RemoveAll(markersFolder)
 		if err != nil {
-			logger.Warnf("Could not delete file %s: %s", scene.Path, err.Error())
+			logger.Warnf("Could not delete file %s: %s", markersFolder, err.Error())
 		}
 	}
 
-	thumbPath := GetInstance().Paths.Scene.GetThumbnailScreenshotPath(scene.Checksum)
+	thumbPath := GetInstance().Paths.Scene.GetThumbnailScreenshotPath(sceneHash)
 	exists, _ = utils.FileExists(thumbPath)
 	if exists {
 		err := os.Remove(thumbPath)
@@ -64,7 +215,7 @@ func DeleteGeneratedSceneFiles(scene *models.Scene) {
 		}
 	}
 
-	normalPath := GetInstance().Paths.Scene.GetScreenshotPath(scene.Checksum)
+	normalPath := GetInstance().Paths.Scene.GetScreenshotPath(sceneHash)
 	exists, _ = utils.FileExists(normalPath)
 	if exists {
 		err := os.Remove(normalPath)
@@ -73,7 +224,7 @@ func DeleteGeneratedSceneFiles(scene *models.Scene) {
 		}
 	}
 
-	streamPreviewPath := GetInstance().Paths.Scene.GetStreamPreviewPath(scene.Checksum)
+	streamPreviewPath := GetInstance().Paths.Scene.GetStreamPreviewPath(sceneHash)
 	exists, _ = utils.FileExists(streamPreviewPath)
 	if exists {
 		err := os.Remove(streamPreviewPath)
@@ -82,7 +233,7 @@ func DeleteGeneratedSceneFiles(scene *models.Scene) {
 		}
 	}
 
-	streamPreviewImagePath := GetInstance().Paths.Scene.GetStreamPreviewImagePath(scene.Checksum)
+	streamPreviewImagePath := GetInstance().Paths.Scene.GetStreamPreviewImagePath(sceneHash)
 	exists, _ = utils.FileExists(streamPreviewImagePath)
 	if exists {
 		err := os.Remove(streamPreviewImagePath)
@@ -91,7 +242,16 @@ func DeleteGeneratedSceneFiles(scene *models.Scene) {
 		}
 	}
 
-	transcodePath := GetInstance().Paths.Scene.GetTranscodePath(scene.Checksum)
+	streamPreviewOptionsPath := GetInstance().Paths.Scene.GetStreamPreviewOptionsPath(sceneHash)
+	exists, _ = utils.FileExists(streamPreviewOptionsPath)
+	if exists {
+		err := os.Remove(streamPreviewOptionsPath)
+		if err != nil {
+			logger.Warnf("Could not delete file %s: %s", streamPreviewOptionsPath, err.Error())
+		}
+	}
+
+	transcodePath := GetInstance().Paths.Scene.GetTranscodePath(sceneHash)
 	exists, _ = utils.FileExists(transcodePath)
 	if exists {
 		// kill any running streams
@@ -103,7 +263,7 @@ func DeleteGeneratedSceneFiles(scene *models.Scene) {
 		}
 	}
 
-	spritePath := GetInstance().Paths.Scene.GetSpriteImageFilePath(scene.Checksum)
+	spritePath := GetInstance().Paths.Scene.GetSpriteImageFilePath(sceneHash)
 	exists, _ = utils.FileExists(spritePath)
 	if exists {
 		err := os.Remove(spritePath)
@@ -112,7 +272,7 @@ func DeleteGeneratedSceneFiles(scene *models.Scene) {
 		}
 	}
 
-	vttPath := GetInstance().Paths.Scene.GetSpriteVttFilePath(scene.Checksum)
+	vttPath := GetInstance().Paths.Scene.GetSpriteVttFilePath(sceneHash)
 	exists, _ = utils.FileExists(vttPath)
 	if exists {
 		err := os.Remove(vttPath)
@@ -122,7 +282,58 @@ func DeleteGeneratedSceneFiles(scene *models.Scene) {
 	}
 }
 
+// renameGeneratedSceneFiles renames the generated files named with oldHash
+// to be named with newHash, after the hash that names the files of a scene
+// has changed. Existing files named with newHash are not replaced. Returns
+// true if any file was renamed.
+func renameGeneratedSceneFiles(oldHash string, newHash string) (bool, error) {
+	paths := GetInstance().Paths
+	getPaths := []func(string) string{
+		func(sceneHash string) string {
+			return filepath.Join(paths.Generated.Markers, sceneHash)
+		},
+		paths.Scene.GetThumbnailScreenshotPath,
+		paths.Scene.GetScreenshotPath,
+		paths.Scene.GetStreamPreviewPath,
+		paths.Scene.GetStreamPreviewImagePath,
+		paths.Scene.GetStreamPreviewOptionsPath,
+		paths.Scene.GetTranscodePath,
+		paths.Scene.GetSpriteImageFilePath,
+		paths.Scene.GetSpriteVttFilePath,
+	}
+
+	// streams of the old transcode and HLS segments are named with the old hash
+	KillRunningStreams(paths.Scene.GetTranscodePath(oldHash))
+	invalidateHLSStreams(oldHash)
+
+	renamed := false
+	for _, getPath := range getPaths {
+		oldPath := getPath(oldHash)
+		if exists, _ := utils.FileExists(oldPath); !exists {
+			continue
+		}
+
+		newPath := getPath(newHash)
+		if exists, _ := utils.FileExists(newPath); exists {
+			logger.Warnf("Not renaming %s: %s already exists", oldPath, newPath)
+			continue
+		}
+
+		if err := os.Rename(oldPath, newPath); err != nil {
+			return renamed, err
+		}
+		renamed = true
+	}
+
+	return renamed, nil
+}
+
 func DeleteSceneFile(scene *models.Scene) {
+	if IsReadOnlyPath(scene.Path) {
+		logger.Warnf("Not deleting file %s: stash path is read-only", scene.Path)
+		return
+	}
+
 	// kill any running encoders
 	KillRunningStreams(scene.Path)
 
@@ -130,4 +341,11 @@ func DeleteSceneFile(scene *models.Scene) {
 	if err != nil {
 		logger.Warnf("Could not delete file %s: %s", scene.Path, err.Error())
 	}
-}
\ No newline at end of file
+}
+
+// IsReadOnlyPath returns true if the file is in a read-only stash path.
+// Files in read-only stash paths must never be deleted.
+func IsReadOnlyPath(path string) bool {
+	stash := config.GetStashFromPath(path)
+	return stash != nil && stash.ReadOnly
+}
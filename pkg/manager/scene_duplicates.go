@@ -0,0 +1,172 @@
+package manager
+
+import (
+	"sort"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// FindDuplicateScenes returns groups of scenes that are duplicates of each
+// other. Scenes with the same file checksum or the same oshash are always
+// grouped. If distance is not negative, scenes whose
+// perceptual hashes differ by no more than distance bits are grouped as well.
+func FindDuplicateScenes(distance int) ([][]*models.Scene, error) {
+	fqb := models.NewSceneFingerprintQueryBuilder()
+	qb := models.NewSceneQueryBuilder()
+
+	scenes, err := qb.All()
+	if err != nil {
+		return nil, err
+	}
+
+	oshashes, err := fqb.AllByType(models.FingerprintTypeOshash, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var phashes []*models.SceneFingerprint
+	if distance >= 0 {
+		phashes, err = fqb.AllByType(models.FingerprintTypePhash, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	byID := make(map[int]*models.Scene)
+	for _, scene := range scenes {
+		byID[scene.ID] = scene
+	}
+
+	var ret [][]*models.Scene
+	for _, group := range groupDuplicateFingerprints(getFileHashes(scenes, oshashes), phashes, distance) {
+		var duplicates []*models.Scene
+		for _, sceneID := range group {
+			if scene := byID[sceneID]; scene != nil {
+				duplicates = append(duplicates, scene)
+			}
+		}
+
+		if len(duplicates) > 1 {
+			ret = append(ret, duplicates)
+		}
+	}
+
+	return ret, nil
+}
+
+// getFileHashes returns the hashes that identical scene files share: the
+// checksum and the oshash of each scene, whichever are set. Both are
+// returned so that a scene with only an oshash is still matched against a
+// scene that has a checksum as well.
+func getFileHashes(scenes []*models.Scene, oshashes []*models.SceneFingerprint) []*models.SceneFingerprint {
+	var ret []*models.SceneFingerprint
+	for _, scene := range scenes {
+		if scene.Checksum.Valid && scene.Checksum.String != "" {
+			ret = append(ret, &models.SceneFingerprint{
+				SceneID:     scene.ID,
+				Type:        models.FingerprintTypeMD5,
+				Fingerprint: scene.Checksum.String,
+			})
+		}
+	}
+	for _, fingerprint := range oshashes {
+		if fingerprint.Fingerprint != "" {
+			ret = append(ret, &models.SceneFingerprint{
+				SceneID:     fingerprint.SceneID,
+				Type:        models.FingerprintTypeOshash,
+				Fingerprint: fingerprint.Fingerprint,
+			})
+		}
+	}
+	return ret
+}
+
+// groupDuplicateFingerprints returns the ids of the scenes with identical
+// file hashes of the same type or perceptual hashes within distance bits of each other,
+// grouped together. Groups are sorted by their lowest scene id, and only
+// groups with more than one scene are returned.
+func groupDuplicateFingerprints(fileHashes []*models.SceneFingerprint, phashes []*models.SceneFingerprint, distance int) [][]int {
+	// union-find of scene ids
+	parents := make(map[int]int)
+	var find func(id int) int
+	find = func(id int) int {
+		parent, found := parents[id]
+		if !found {
+			parents[id] = id
+			return id
+		}
+		if parent == id {
+			return id
+		}
+		root := find(parent)
+		parents[id] = root
+		return root
+	}
+	union := func(a, b int) {
+		rootA := find(a)
+		rootB := find(b)
+		if rootA < rootB {
+			parents[rootB] = rootA
+		} else if rootB < rootA {
+			parents[rootA] = rootB
+		}
+	}
+
+	// hashes are keyed by type so that a checksum never matches an oshash
+	type fileHash struct {
+		hashType string
+		hash     string
+	}
+	bySceneHash := make(map[fileHash]int)
+	for _, fingerprint := range fileHashes {
+		key := fileHash{hashType: fingerprint.Type, hash: fingerprint.Fingerprint}
+		if sceneID, found := bySceneHash[key]; found {
+			union(sceneID, fingerprint.SceneID)
+		} else {
+			bySceneHash[key] = fingerprint.SceneID
+		}
+	}
+
+	type parsedPhash struct {
+		sceneID int
+		hash    uint64
+	}
+	var parsed []parsedPhash
+	for _, fingerprint := range phashes {
+		hash, err := utils.ParsePerceptualHash(fingerprint.Fingerprint)
+		if err != nil {
+			logger.Warnf("Invalid phash for scene %d: %s", fingerprint.SceneID, err.Error())
+			continue
+		}
+		parsed = append(parsed, parsedPhash{sceneID: fingerprint.SceneID, hash: hash})
+	}
+
+	for i := range parsed {
+		for j := i + 1; j < len(parsed); j++ {
+			if utils.HammingDistance(parsed[i].hash, parsed[j].hash) <= distance {
+				union(parsed[i].sceneID, parsed[j].sceneID)
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	for id := range parents {
+		root := find(id)
+		groups[root] = append(groups[root], id)
+	}
+
+	var ret [][]int
+	for _, group := range groups {
+		if len(group) > 1 {
+			sort.Ints(group)
+			ret = append(ret, group)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i][0] < ret[j][0]
+	})
+
+	return ret
+}
@@ -0,0 +1,57 @@
+// +build integration
+
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+func TestFindDuplicateScenes(t *testing.T) {
+	sqb := models.NewSceneQueryBuilder()
+	checksum := sql.NullString{String: utils.MD5FromString("duplicate"), Valid: true}
+
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+	var sceneIDs []int
+	for _, path := range []string{"duplicate/a.mp4", "duplicate/b.mp4"} {
+		scene, err := sqb.Create(models.Scene{Checksum: checksum, Path: path}, tx)
+		if err != nil {
+			tx.Rollback()
+			t.Fatalf("Error creating scene: %s", err.Error())
+		}
+		sceneIDs = append(sceneIDs, scene.ID)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Error committing: %s", err.Error())
+	}
+
+	defer func() {
+		tx := database.DB.MustBeginTx(context.TODO(), nil)
+		for _, id := range sceneIDs {
+			if err := sqb.Destroy(strconv.Itoa(id), tx); err != nil {
+				t.Errorf("Error destroying scene: %s", err.Error())
+			}
+		}
+		tx.Commit()
+	}()
+
+	groups, err := FindDuplicateScenes(-1)
+	if err != nil {
+		t.Fatalf("Error finding duplicates: %s", err.Error())
+	}
+
+	if len(groups) != 1 || len(groups[0]) != 2 {
+		t.Fatalf("Was expecting one group of two scenes, found %v", groups)
+	}
+	for i, scene := range groups[0] {
+		if scene.ID != sceneIDs[i] {
+			t.Errorf("Was expecting scene %d, found %d", sceneIDs[i], scene.ID)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package manager
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func TestGroupDuplicateFingerprints(t *testing.T) {
+	oshashes := []*models.SceneFingerprint{
+		{SceneID: 1, Fingerprint: "aaaa"},
+		{SceneID: 2, Fingerprint: "bbbb"},
+		{SceneID: 3, Fingerprint: "aaaa"},
+		{SceneID: 4, Fingerprint: "cccc"},
+		{SceneID: 5, Fingerprint: "dddd"},
+	}
+	phashes := []*models.SceneFingerprint{
+		{SceneID: 2, Fingerprint: "00000000000000ff"},
+		{SceneID: 4, Fingerprint: "00000000000000fe"},
+		{SceneID: 5, Fingerprint: "ff00000000000000"},
+	}
+
+	exact := groupDuplicateFingerprints(oshashes, nil, -1)
+	if expected := [][]int{{1, 3}}; !reflect.DeepEqual(exact, expected) {
+		t.Errorf("Was expecting %v, found %v", expected, exact)
+	}
+
+	similar := groupDuplicateFingerprints(oshashes, phashes, 1)
+	if expected := [][]int{{1, 3}, {2, 4}}; !reflect.DeepEqual(similar, expected) {
+		t.Errorf("Was expecting %v, found %v", expected, similar)
+	}
+
+	none := groupDuplicateFingerprints(oshashes, phashes, 0)
+	if expected := [][]int{{1, 3}}; !reflect.DeepEqual(none, expected) {
+		t.Errorf("Was expecting %v, found %v", expected, none)
+	}
+}
+
+func TestGetFileHashes(t *testing.T) {
+	scenes := []*models.Scene{
+		{ID: 1, Checksum: sql.NullString{String: "md5a", Valid: true}},
+		{ID: 2, Checksum: sql.NullString{String: "md5a", Valid: true}},
+		{ID: 3},
+		{ID: 4},
+	}
+	oshashes := []*models.SceneFingerprint{
+		{SceneID: 1, Fingerprint: "aaaa"},
+		{SceneID: 3, Fingerprint: "cccc"},
+	}
+
+	hashes := getFileHashes(scenes, oshashes)
+	expected := []*models.SceneFingerprint{
+		{SceneID: 1, Type: models.FingerprintTypeMD5, Fingerprint: "md5a"},
+		{SceneID: 2, Type: models.FingerprintTypeMD5, Fingerprint: "md5a"},
+		{SceneID: 1, Type: models.FingerprintTypeOshash, Fingerprint: "aaaa"},
+		{SceneID: 3, Type: models.FingerprintTypeOshash, Fingerprint: "cccc"},
+	}
+	if !reflect.DeepEqual(hashes, expected) {
+		t.Errorf("Was expecting %v, found %v", expected, hashes)
+	}
+}
+
+func TestGroupDuplicateFileHashesMixed(t *testing.T) {
+	// scene 1 has a checksum and an oshash, scene 2 was scanned without
+	// calculating the checksum, and scene 3 has a checksum equal to scene
+	// 4's oshash, which must not match
+	scenes := []*models.Scene{
+		{ID: 1, Checksum: sql.NullString{String: "md5a", Valid: true}},
+		{ID: 2},
+		{ID: 3, Checksum: sql.NullString{String: "bbbb", Valid: true}},
+		{ID: 4},
+	}
+	oshashes := []*models.SceneFingerprint{
+		{SceneID: 1, Fingerprint: "aaaa"},
+		{SceneID: 2, Fingerprint: "aaaa"},
+		{SceneID: 3, Fingerprint: "cccc"},
+		{SceneID: 4, Fingerprint: "bbbb"},
+	}
+
+	groups := groupDuplicateFingerprints(getFileHashes(scenes, oshashes), nil, -1)
+	if expected := [][]int{{1, 2}}; !reflect.DeepEqual(groups, expected) {
+		t.Errorf("Was expecting %v, found %v", expected, groups)
+	}
+}
@@ -0,0 +1,43 @@
+package manager
+
+import "sync"
+
+// sceneHashLocks serialises the generation of the files named with a scene
+// hash. Duplicate scenes share their hash, so their tasks would otherwise
+// write the same temporary and output files at the same time.
+var sceneHashLocks = struct {
+	sync.Mutex
+	locks map[string]*sceneHashLock
+}{locks: make(map[string]*sceneHashLock)}
+
+type sceneHashLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lockSceneHash blocks until no other task is generating files named with
+// the scene hash, and returns the function that releases the lock. Tasks
+// should check whether their output exists after taking the lock, since a
+// duplicate scene may have just generated it.
+func lockSceneHash(sceneHash string) func() {
+	sceneHashLocks.Lock()
+	lock := sceneHashLocks.locks[sceneHash]
+	if lock == nil {
+		lock = &sceneHashLock{}
+		sceneHashLocks.locks[sceneHash] = lock
+	}
+	lock.refs++
+	sceneHashLocks.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+
+		sceneHashLocks.Lock()
+		defer sceneHashLocks.Unlock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(sceneHashLocks.locks, sceneHash)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package manager
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockSceneHash(t *testing.T) {
+	const tasks = 10
+
+	var mutex sync.Mutex
+	running := map[string]int{}
+	maxRunning := map[string]int{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < tasks; i++ {
+		sceneHash := "a"
+		if i%2 == 0 {
+			sceneHash = "b"
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := lockSceneHash(sceneHash)
+			defer unlock()
+
+			mutex.Lock()
+			running[sceneHash]++
+			if running[sceneHash] > maxRunning[sceneHash] {
+				maxRunning[sceneHash] = running[sceneHash]
+			}
+			mutex.Unlock()
+
+			time.Sleep(time.Millisecond)
+
+			mutex.Lock()
+			running[sceneHash]--
+			mutex.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	for sceneHash, max := range maxRunning {
+		if max != 1 {
+			t.Errorf("Was expecting one task at a time for %s, found %d", sceneHash, max)
+		}
+	}
+
+	if len(sceneHashLocks.locks) != 0 {
+		t.Errorf("Was expecting released locks to be removed, found %d", len(sceneHashLocks.locks))
+	}
+}
@@ -34,9 +34,9 @@ func writeThumbnail(path string, thumbnail image.Image) error {
 	return jpeg.Encode(f, thumbnail, nil)
 }
 
-func SetSceneScreenshot(checksum string, imageData []byte) error {
-	thumbPath := instance.Paths.Scene.GetThumbnailScreenshotPath(checksum)
-	normalPath := instance.Paths.Scene.GetScreenshotPath(checksum)
+func SetSceneScreenshot(sceneHash string, imageData []byte) error {
+	thumbPath := instance.Paths.Scene.GetThumbnailScreenshotPath(sceneHash)
+	normalPath := instance.Paths.Scene.GetScreenshotPath(sceneHash)
 
 	img, _, err := image.Decode(bytes.NewReader(imageData))
 	if err != nil {
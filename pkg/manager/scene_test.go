@@ -0,0 +1,33 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func TestSelectSceneHash(t *testing.T) {
+	defer viper.Reset()
+
+	tests := []struct {
+		namingHash models.HashAlgorithm
+		checksum   string
+		oshash     string
+		expected   string
+	}{
+		{models.HashAlgorithmMd5, "md5", "oshash", "md5"},
+		{models.HashAlgorithmOshash, "md5", "oshash", "oshash"},
+		// scenes without the chosen hash are named with the other hash
+		{models.HashAlgorithmMd5, "", "oshash", "oshash"},
+		{models.HashAlgorithmOshash, "md5", "", "md5"},
+	}
+
+	for _, test := range tests {
+		viper.Set(config.SceneFileNamingHash, test.namingHash.String())
+		if got := selectSceneHash(test.checksum, test.oshash); got != test.expected {
+			t.Errorf("selectSceneHash(%q, %q) with %s: expected %s, found %s", test.checksum, test.oshash, test.namingHash, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// scheduleTaskJobTypes are the types of job queued by each schedule task.
+var scheduleTaskJobTypes = map[models.ScheduleTask]JobStatus{
+	models.ScheduleTaskScan:     Scan,
+	models.ScheduleTaskGenerate: Generate,
+	models.ScheduleTaskClean:    Clean,
+	models.ScheduleTaskAutoTag:  AutoTag,
+}
+
+// ScheduleTaskJobType returns the type of job queued by the schedule task.
+func ScheduleTaskJobType(task models.ScheduleTask) JobStatus {
+	return scheduleTaskJobTypes[task]
+}
+
+// JobTypeScheduleTask returns the schedule task that queues the job type.
+func JobTypeScheduleTask(jobType JobStatus) (models.ScheduleTask, error) {
+	for task, t := range scheduleTaskJobTypes {
+		if t == jobType {
+			return task, nil
+		}
+	}
+	return "", fmt.Errorf("%s jobs cannot be scheduled", jobType.String())
+}
+
+// NextScheduleRun returns the first time after t that matches the cron
+// expression.
+func NextScheduleRun(schedule string, t time.Time) (time.Time, error) {
+	cron, err := utils.ParseCron(schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	next := cron.Next(t)
+	if next.IsZero() {
+		return next, fmt.Errorf("cron expression %q never matches", schedule)
+	}
+	return next, nil
+}
+
+// StartScheduler starts queueing the jobs of the task schedules and of the
+// stash path scan schedules when they are due. It must be called after the
+// database is initialized.
+func (s *singleton) StartScheduler() {
+	go s.runScheduler()
+}
+
+func (s *singleton) runScheduler() {
+	// next runs of the stash path scan schedules, by path and schedule. Zero
+	// times are invalid schedules.
+	stashRuns := make(map[string]time.Time)
+
+	for {
+		// check at the start of each minute
+		now := time.Now()
+		time.Sleep(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+
+		now = time.Now()
+		s.runSchedules(now)
+		s.runStashSchedules(now, stashRuns)
+	}
+}
+
+// runSchedules queues the jobs of the schedules that are due, and sets the
+// time of their next run. Schedules that were due while stash was stopped
+// are run once.
+func (s *singleton) runSchedules(now time.Time) {
+	qb := models.NewScheduleQueryBuilder()
+	schedules, err := qb.FindEnabled(nil)
+	if err != nil {
+		logger.Errorf("Error getting schedules: %s", err.Error())
+		return
+	}
+
+	for _, schedule := range schedules {
+		if schedule.NextRunAt.Valid && schedule.NextRunAt.Timestamp.After(now) {
+			continue
+		}
+
+		next, err := NextScheduleRun(schedule.Schedule, now)
+		if err != nil {
+			logger.Errorf("Error in schedule %q: %s", schedule.Name, err.Error())
+			continue
+		}
+
+		// schedules without a next run have not been due yet
+		lastRunAt := schedule.LastRunAt
+		if schedule.NextRunAt.Valid {
+			var input interface{}
+			if schedule.Input.Valid {
+				input = json.RawMessage(schedule.Input.String)
+			}
+
+			logger.Infof("Running schedule %q", schedule.Name)
+			s.queueScheduledJob(JobStatus(schedule.Type), input)
+			lastRunAt = models.NullSQLiteTimestamp{Timestamp: now, Valid: true}
+		}
+
+		// only the run times are updated, so that edits made to the schedule
+		// while it was being run are kept
+		nextRunAt := models.NullSQLiteTimestamp{Timestamp: next, Valid: true}
+		tx := database.DB.MustBeginTx(context.TODO(), nil)
+		if err := qb.SetRuns(schedule.ID, lastRunAt, nextRunAt, tx); err != nil {
+			logger.Errorf("Error updating schedule %q: %s", schedule.Name, err.Error())
+			_ = tx.Rollback()
+		} else if err := tx.Commit(); err != nil {
+			logger.Errorf("Error updating schedule %q: %s", schedule.Name, err.Error())
+		}
+	}
+}
+
+// runStashSchedules queues scans of the stash paths whose scan schedules
+// are due. Unlike task schedules, the next runs are not stored, so runs
+// missed while stash was stopped are skipped.
+func (s *singleton) runStashSchedules(now time.Time, nextRuns map[string]time.Time) {
+	for _, stash := range config.GetStashes() {
+		if stash.ScanSchedule == nil || *stash.ScanSchedule == "" {
+			continue
+		}
+
+		key := stash.Path + "\n" + *stash.ScanSchedule
+		next, found := nextRuns[key]
+		if !found {
+			// include the current minute, which may match the schedule
+			var err error
+			next, err = NextScheduleRun(*stash.ScanSchedule, now.Add(-time.Minute))
+			if err != nil {
+				logger.Errorf("Error in scan schedule of %s: %s", stash.Path, err.Error())
+			}
+			nextRuns[key] = next
+		}
+
+		if next.IsZero() || next.After(now) {
+			continue
+		}
+
+		logger.Infof("Running scan schedule of %s", stash.Path)
+		s.queueScheduledJob(Scan, models.ScanMetadataInput{Paths: []string{stash.Path}})
+		nextRuns[key], _ = NextScheduleRun(*stash.ScanSchedule, now)
+	}
+}
+
+// queueScheduledJob adds the job to the end of the queue, so that it runs
+// after any jobs that were queued manually. The job is not queued if an
+// identical job is already waiting in the queue.
+func (s *singleton) queueScheduledJob(jobType JobStatus, input interface{}) {
+	var inputJSON string
+	if input != nil {
+		data, err := json.Marshal(input)
+		if err != nil {
+			logger.Errorf("Error queueing scheduled %s job: %s", jobType.String(), err.Error())
+			return
+		}
+		inputJSON = string(data)
+	}
+
+	qb := models.NewJobQueryBuilder()
+	queued, err := qb.FindByState([]models.JobState{models.JobStateQueued}, nil)
+	if err != nil {
+		logger.Errorf("Error queueing scheduled %s job: %s", jobType.String(), err.Error())
+		return
+	}
+
+	for _, job := range queued {
+		if JobStatus(job.Type) == jobType && job.Input.String == inputJSON {
+			logger.Infof("Not queueing scheduled %s job: job %d is already queued", jobType.String(), job.ID)
+			return
+		}
+	}
+
+	if _, err := s.enqueueJob(jobType, input); err != nil {
+		logger.Errorf("Error queueing scheduled %s job: %s", jobType.String(), err.Error())
+	}
+}
@@ -0,0 +1,81 @@
+package manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+type AutoLinkGalleryTask struct {
+	Gallery models.Gallery
+}
+
+// Start links the gallery to the scenes with the same path without the
+// extension, such as a.mp4 and a.zip. Folder galleries are also linked to
+// the scenes directly within the folder.
+func (t *AutoLinkGalleryTask) Start() error {
+	info, err := os.Stat(t.Gallery.Path)
+	if err != nil {
+		// missing galleries are removed by clean
+		return nil
+	}
+
+	qb := models.NewSceneQueryBuilder()
+	var scenes []*models.Scene
+	for _, regex := range t.getSceneRegexes(info.IsDir()) {
+		found, err := qb.QueryAllByPathRegex(regex)
+		if err != nil {
+			return err
+		}
+		scenes = append(scenes, found...)
+	}
+
+	if len(scenes) == 0 {
+		return nil
+	}
+
+	jqb := models.NewJoinsQueryBuilder()
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+	var linked []*models.Scene
+	for _, scene := range scenes {
+		added, err := jqb.AddSceneGallery(scene.ID, t.Gallery.ID, tx)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if added {
+			linked = append(linked, scene)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, scene := range linked {
+		instance.addJobReportItem(models.JobReportItemTypeModified, t.Gallery.Path, "Linked to scene "+scene.Path)
+	}
+	return nil
+}
+
+// getSceneRegexes returns the regular expressions matching the paths of the
+// scenes that the gallery is linked to.
+func (t *AutoLinkGalleryTask) getSceneRegexes(folder bool) []string {
+	separator := regexp.QuoteMeta(string(filepath.Separator))
+
+	basename := t.Gallery.Path
+	if !folder {
+		basename = strings.TrimSuffix(basename, filepath.Ext(basename))
+	}
+	ret := []string{"^" + regexp.QuoteMeta(basename) + `\.[^.` + separator + `]+$`}
+
+	if folder {
+		ret = append(ret, "^"+regexp.QuoteMeta(t.Gallery.Path)+separator+"[^"+separator+"]+$")
+	}
+	return ret
+}
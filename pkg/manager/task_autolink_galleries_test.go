@@ -0,0 +1,45 @@
+package manager
+
+import (
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func TestAutoLinkGallerySceneRegexes(t *testing.T) {
+	tests := []struct {
+		gallery string
+		folder  bool
+		scene   string
+		match   bool
+	}{
+		{"/v/a.zip", false, "/v/a.mp4", true},
+		{"/v/a.zip", false, "/v/a.b.mp4", false},
+		{"/v/a.zip", false, "/v/ab.mp4", false},
+		{"/v/a.zip", false, "/w/a.mp4", false},
+		{"/v/a.b.zip", false, "/v/a.b.mp4", true},
+		{"/v/a", true, "/v/a.mp4", true},
+		{"/v/a", true, "/v/a/b.mp4", true},
+		{"/v/a", true, "/v/a/b/c.mp4", false},
+		{"/v/a", true, "/v/ab.mp4", false},
+		{"/v/a+", true, "/v/a+/b.mp4", true},
+	}
+
+	for _, test := range tests {
+		task := AutoLinkGalleryTask{Gallery: models.Gallery{Path: filepath.FromSlash(test.gallery)}}
+		scenePath := filepath.FromSlash(test.scene)
+
+		match := false
+		for _, regex := range task.getSceneRegexes(test.folder) {
+			if regexp.MustCompile(regex).MatchString(scenePath) {
+				match = true
+			}
+		}
+
+		if match != test.match {
+			t.Errorf("gallery %s, scene %s: expected match %v", test.gallery, test.scene, test.match)
+		}
+	}
+}
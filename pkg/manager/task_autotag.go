@@ -49,24 +49,30 @@ func (t *AutoTagPerformerTask) autoTagPerformer() {
 	ctx := context.TODO()
 	tx := database.DB.MustBeginTx(ctx, nil)
 
+	var modified []*models.Scene
 	for _, scene := range scenes {
 		added, err := jqb.AddPerformerScene(scene.ID, t.performer.ID, tx)
 
 		if err != nil {
 			logger.Infof("Error adding performer '%s' to scene '%s': %s", t.performer.Name.String, scene.GetTitle(), err.Error())
+			instance.addJobReportItem(models.JobReportItemTypeError, scene.Path, err.Error())
 			tx.Rollback()
 			return
 		}
 
 		if added {
 			logger.Infof("Added performer '%s' to scene '%s'", t.performer.Name.String, scene.GetTitle())
+			modified = append(modified, scene)
 		}
 	}
 
 	if err := tx.Commit(); err != nil {
 		logger.Infof("Error adding performer to scene: %s", err.Error())
+		instance.addJobReportItem(models.JobReportItemTypeError, "", err.Error())
 		return
 	}
+
+	reportAutoTagged(modified, "Added performer "+t.performer.Name.String)
 }
 
 type AutoTagStudioTask struct {
@@ -94,6 +100,7 @@ func (t *AutoTagStudioTask) autoTagStudio() {
 	ctx := context.TODO()
 	tx := database.DB.MustBeginTx(ctx, nil)
 
+	var modified []*models.Scene
 	for _, scene := range scenes {
 		if scene.StudioID.Int64 == int64(t.studio.ID) {
 			// don't modify
@@ -113,15 +120,20 @@ func (t *AutoTagStudioTask) autoTagStudio() {
 
 		if err != nil {
 			logger.Infof("Error adding studio to scene: %s", err.Error())
+			instance.addJobReportItem(models.JobReportItemTypeError, scene.Path, err.Error())
 			tx.Rollback()
 			return
 		}
+		modified = append(modified, scene)
 	}
 
 	if err := tx.Commit(); err != nil {
 		logger.Infof("Error adding studio to scene: %s", err.Error())
+		instance.addJobReportItem(models.JobReportItemTypeError, "", err.Error())
 		return
 	}
+
+	reportAutoTagged(modified, "Set studio "+t.studio.Name.String)
 }
 
 type AutoTagTagTask struct {
@@ -150,22 +162,36 @@ func (t *AutoTagTagTask) autoTagTag() {
 	ctx := context.TODO()
 	tx := database.DB.MustBeginTx(ctx, nil)
 
+	var modified []*models.Scene
 	for _, scene := range scenes {
 		added, err := jqb.AddSceneTag(scene.ID, t.tag.ID, tx)
 
 		if err != nil {
 			logger.Infof("Error adding tag '%s' to scene '%s': %s", t.tag.Name, scene.GetTitle(), err.Error())
+			instance.addJobReportItem(models.JobReportItemTypeError, scene.Path, err.Error())
 			tx.Rollback()
 			return
 		}
 
 		if added {
 			logger.Infof("Added tag '%s' to scene '%s'", t.tag.Name, scene.GetTitle())
+			modified = append(modified, scene)
 		}
 	}
 
 	if err := tx.Commit(); err != nil {
 		logger.Infof("Error adding tag to scene: %s", err.Error())
+		instance.addJobReportItem(models.JobReportItemTypeError, "", err.Error())
 		return
 	}
+
+	reportAutoTagged(modified, "Added tag "+t.tag.Name)
+}
+
+// reportAutoTagged adds the scenes that an auto tag task modified to the job
+// report.
+func reportAutoTagged(scenes []*models.Scene, message string) {
+	for _, scene := range scenes {
+		instance.addJobReportItem(models.JobReportItemTypeModified, scene.Path, message)
+	}
 }
@@ -179,7 +179,7 @@ func createScenes(tx *sqlx.Tx) error {
 
 func createScene(sqb models.SceneQueryBuilder, tx *sqlx.Tx, name string, expectedResult bool) error {
 	scene := models.Scene{
-		Checksum: utils.MD5FromString(name),
+		Checksum: sql.NullString{String: utils.MD5FromString(name), Valid: true},
 		Path:     name,
 	}
 
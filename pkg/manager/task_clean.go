@@ -2,55 +2,89 @@ package manager
 
 import (
 	"context"
+	"fmt"
 	"github.com/stashapp/stash/pkg/database"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
 	"os"
-	"path/filepath"
-	"strings"
-	"sync"
 )
 
 type CleanTask struct {
 	Scene models.Scene
+	// DryRun reports the scene as removed without removing it.
+	DryRun bool
 }
 
-func (t *CleanTask) Start(wg *sync.WaitGroup) {
-	defer wg.Done()
+// Start removes the scene if it should be cleaned.
+func (t *CleanTask) Start() error {
+	if reason := t.getCleanReason(); reason != "" {
+		return t.clean(reason)
+	}
+	return nil
+}
+
+// getCleanReason returns the reason that the scene should be removed, or
+// an empty string if it should be kept. Scenes in stash paths that exclude
+// videos are skipped, since the scan does not update them.
+func (t *CleanTask) getCleanReason() string {
+	stash := config.GetStashFromPath(t.Scene.Path)
+	if stash != nil && stash.ExcludeVideo {
+		logger.Debugf("Skipping %s in stash path %s which excludes videos", t.Scene.Path, stash.Path)
+		return ""
+	}
+
+	if stash == nil {
+		logger.Debugf("File %s is out from stash path", t.Scene.Path)
+		return "File not found"
+	}
+	if !t.fileExists(t.Scene.Path) {
+		return "File not found"
+	}
+
+	logger.Debugf("File Found: %s", t.Scene.Path)
+	if matchFile(t.Scene.Path, config.GetExcludes()) {
+		return "File matched exclude pattern"
+	}
+	if !isVideo(t.Scene.Path) {
+		return "File extension is not a video extension"
+	}
+
+	return ""
+}
 
-	if t.fileExists(t.Scene.Path) && t.pathInStash() {
-		logger.Debugf("File Found: %s", t.Scene.Path)
-		if matchFile(t.Scene.Path, config.GetExcludes()) {
-			logger.Infof("File matched regex. Cleaning: \"%s\"", t.Scene.Path)
-			t.deleteScene(t.Scene.ID)
-		}
-	} else {
-		logger.Infof("File not found. Cleaning: \"%s\"", t.Scene.Path)
-		t.deleteScene(t.Scene.ID)
+// clean removes the scene, or reports that it would be removed if this is
+// a dry run.
+func (t *CleanTask) clean(reason string) error {
+	if t.DryRun {
+		logger.Infof("%s. Would clean: \"%s\"", reason, t.Scene.Path)
+		instance.addJobReportItem(models.JobReportItemTypeRemoved, t.Scene.Path, "Dry run: "+reason)
+		return nil
 	}
+
+	logger.Infof("%s. Cleaning: \"%s\"", reason, t.Scene.Path)
+	return t.deleteScene(t.Scene.ID, reason)
 }
 
-func (t *CleanTask) deleteScene(sceneID int) {
+func (t *CleanTask) deleteScene(sceneID int, reason string) error {
 	ctx := context.TODO()
-	qb := models.NewSceneQueryBuilder()
 	tx := database.DB.MustBeginTx(ctx, nil)
 
-	scene, err := qb.Find(sceneID)
-	err = DestroyScene(sceneID, tx)
+	sceneHash := GetSceneHash(&t.Scene)
+	err := DestroyScene(sceneID, tx)
 
 	if err != nil {
-		logger.Infof("Error deleting scene from database: %s", err.Error())
 		tx.Rollback()
-		return
+		return fmt.Errorf("error deleting scene from database: %s", err.Error())
 	}
 
 	if err := tx.Commit(); err != nil {
-		logger.Infof("Error deleting scene from database: %s", err.Error())
-		return
+		return fmt.Errorf("error deleting scene from database: %s", err.Error())
 	}
 
-	DeleteGeneratedSceneFiles(scene)
+	instance.addJobReportItem(models.JobReportItemTypeRemoved, t.Scene.Path, reason)
+	DeleteGeneratedSceneFiles(sceneHash)
+	return nil
 }
 
 func (t *CleanTask) fileExists(filename string) bool {
@@ -61,19 +95,72 @@ func (t *CleanTask) fileExists(filename string) bool {
 	return !info.IsDir()
 }
 
-func (t *CleanTask) pathInStash() bool {
-	for _, path := range config.GetStashPaths() {
+// cleaner is a scene, gallery or image clean task.
+type cleaner interface {
+	clean(reason string) error
+}
+
+// cleanItem is a scene, gallery or image that a clean will remove, and why.
+type cleanItem struct {
+	task   cleaner
+	path   string
+	reason string
+}
+
+// checkCleanThreshold returns an error if removing count of the total items
+// is more than the threshold percentage. A threshold of 0 or less disables
+// the check.
+func checkCleanThreshold(count int, total int, threshold int) error {
+	if threshold <= 0 || total == 0 {
+		return nil
+	}
+
+	percent := float64(count) * 100 / float64(total)
+	if percent > float64(threshold) {
+		return fmt.Errorf("%d of %d scenes, galleries and images (%.1f%%) would be removed, more than the threshold of %d%%", count, total, percent, threshold)
+	}
+	return nil
+}
+
+// abortClean returns true if a clean that exceeds the threshold, as
+// returned by checkCleanThreshold, should be aborted. Dry runs are not
+// aborted, so that they still report everything that would be removed, but
+// report that a real clean would be aborted instead.
+func (s *singleton) abortClean(thresholdErr error, dryRun bool) bool {
+	if thresholdErr == nil {
+		return false
+	}
+
+	if dryRun {
+		logger.Warnf("A real clean would be aborted: %s", thresholdErr.Error())
+		s.addJobReportItem(models.JobReportItemTypeError, "", "Dry run: a real clean would be aborted: "+thresholdErr.Error())
+		return false
+	}
 
-		rel, error := filepath.Rel(path, filepath.Dir(t.Scene.Path))
+	logger.Errorf("Aborting clean: %s", thresholdErr.Error())
+	s.addJobReportItem(models.JobReportItemTypeError, "", "Clean aborted: "+thresholdErr.Error())
+	return true
+}
 
-		if error == nil {
-			if !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
-				logger.Debugf("File %s belongs to stash path %s", t.Scene.Path, path)
-				return true
-			}
-		}
+// checkLibraryCleanThreshold checks the configured threshold against the
+// number of scenes, galleries and images in the library.
+func checkLibraryCleanThreshold(count int) error {
+	qb := models.NewSceneQueryBuilder()
+	sceneCount, err := qb.Count()
+	if err != nil {
+		return err
+	}
+	gqb := models.NewGalleryQueryBuilder()
+	galleryCount, err := gqb.Count()
+	if err != nil {
+		return err
+	}
 
+	iqb := models.NewImageQueryBuilder()
+	imageCount, err := iqb.Count()
+	if err != nil {
+		return err
 	}
-	logger.Debugf("File %s is out from stash path", t.Scene.Path)
-	return false
+
+	return checkCleanThreshold(count, sceneCount+galleryCount+imageCount, config.GetCleanThreshold())
 }
@@ -0,0 +1,171 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+type CleanGalleryTask struct {
+	Gallery models.Gallery
+	// DryRun reports the gallery as removed or corrupt without changing it.
+	DryRun bool
+}
+
+// Start removes the gallery if it should be cleaned.
+func (t *CleanGalleryTask) Start() error {
+	if reason := t.getCleanReason(); reason != "" {
+		return t.clean(reason)
+	}
+	return nil
+}
+
+// getCleanReason returns the reason that the gallery should be removed, or
+// an empty string if it should be kept. Galleries in stash paths that exclude
+// images are skipped, since the scan does not update them.
+func (t *CleanGalleryTask) getCleanReason() string {
+	stash := config.GetStashFromPath(t.Gallery.Path)
+	if stash != nil && stash.ExcludeImage {
+		logger.Debugf("Skipping %s in stash path %s which excludes images", t.Gallery.Path, stash.Path)
+		return ""
+	}
+
+	exists, _ := utils.FileExists(t.Gallery.Path)
+	if !exists || stash == nil {
+		return "File not found"
+	}
+
+	logger.Debugf("File Found: %s", t.Gallery.Path)
+	if matchFile(t.Gallery.Path, config.GetExcludes()) {
+		return "File matched exclude pattern"
+	}
+
+	if isDir, _ := utils.DirExists(t.Gallery.Path); isDir {
+		if !config.GetCreateGalleriesFromFolders() {
+			return "Folder galleries are disabled"
+		}
+		if files := t.Gallery.GetFiles(""); len(files) == 0 {
+			return "Folder contains no images"
+		}
+		return ""
+	}
+
+	if !isGallery(t.Gallery.Path) {
+		return "File extension is not a gallery extension"
+	}
+
+	return ""
+}
+
+// checkCorrupt marks the gallery as corrupt if its file cannot be read, and
+// clears the mark if it can. It is only called once the clean is known not
+// to be aborted, and only reports corrupt galleries on a dry run.
+func (t *CleanGalleryTask) checkCorrupt() {
+	err := t.Gallery.CheckFile()
+	corrupt := err != nil
+	wasCorrupt := t.Gallery.Corrupt.Valid && t.Gallery.Corrupt.Bool
+
+	if corrupt {
+		message := "Gallery file could not be read: " + err.Error()
+		if t.DryRun {
+			message = "Dry run: " + message
+		}
+		instance.addJobReportItem(models.JobReportItemTypeError, t.Gallery.Path, message)
+	}
+
+	if corrupt == wasCorrupt || t.DryRun {
+		return
+	}
+
+	qb := models.NewGalleryQueryBuilder()
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+	if err := qb.SetCorrupt(t.Gallery.ID, corrupt, tx); err != nil {
+		logger.Errorf("Error updating gallery %s: %s", t.Gallery.Path, err.Error())
+		_ = tx.Rollback()
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		logger.Errorf("Error updating gallery %s: %s", t.Gallery.Path, err.Error())
+	}
+}
+
+// clean removes the gallery, or reports that it would be removed if this is
+// a dry run.
+func (t *CleanGalleryTask) clean(reason string) error {
+	if t.DryRun {
+		logger.Infof("%s. Would clean: \"%s\"", reason, t.Gallery.Path)
+		instance.addJobReportItem(models.JobReportItemTypeRemoved, t.Gallery.Path, "Dry run: "+reason)
+		return nil
+	}
+
+	logger.Infof("%s. Cleaning: \"%s\"", reason, t.Gallery.Path)
+
+	qb := models.NewGalleryQueryBuilder()
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+	if err := qb.Destroy(strconv.Itoa(t.Gallery.ID), tx); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("error deleting gallery from database: %s", err.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error deleting gallery from database: %s", err.Error())
+	}
+
+	instance.addJobReportItem(models.JobReportItemTypeRemoved, t.Gallery.Path, reason)
+	return nil
+}
+
+// cleanOrphanedThumbnails removes the gallery image thumbnails that are not
+// used by any gallery image or image. It only logs them on a dry run.
+func cleanOrphanedThumbnails(dryRun bool) error {
+	qb := models.NewGalleryImageQueryBuilder()
+	checksums, err := qb.ThumbnailChecksums()
+	if err != nil {
+		return err
+	}
+
+	used := make(map[string]bool)
+	for _, checksum := range checksums {
+		used[checksum] = true
+	}
+
+	thumbnailsPath := instance.Paths.Generated.Thumbnails
+	infos, err := ioutil.ReadDir(thumbnailsPath)
+	if err != nil {
+		return err
+	}
+
+	removed := 0
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || filepath.Ext(name) != ".jpg" || used[strings.TrimSuffix(name, ".jpg")] {
+			continue
+		}
+
+		path := filepath.Join(thumbnailsPath, name)
+		if dryRun {
+			logger.Debugf("Would remove orphaned thumbnail %s", path)
+		} else if err := os.Remove(path); err != nil {
+			logger.Warnf("Could not delete file %s: %s", path, err.Error())
+			continue
+		}
+		removed++
+	}
+
+	if dryRun {
+		logger.Infof("%d orphaned gallery thumbnails would be removed", removed)
+	} else {
+		logger.Infof("Removed %d orphaned gallery thumbnails", removed)
+	}
+	return nil
+}
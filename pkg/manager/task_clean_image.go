@@ -0,0 +1,135 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+type CleanImageTask struct {
+	Image models.Image
+	// DryRun reports the image as removed without removing it.
+	DryRun bool
+	// RemovedGalleries are the ids of the galleries that the clean removes.
+	// Images that are only in removed galleries are removed with them.
+	RemovedGalleries map[int]bool
+
+	inGallery bool
+}
+
+// getCleanReason returns the reason that the image should be removed, or
+// an empty string if it should be kept. Images in galleries only lose their
+// file path when their file is removed.
+func (t *CleanImageTask) getCleanReason() string {
+	t.inGallery = t.isInGallery()
+
+	if !t.Image.Path.Valid {
+		if !t.inGallery {
+			return "Image is not in any gallery"
+		}
+		return ""
+	}
+
+	path := t.Image.Path.String
+	stash := config.GetStashFromPath(path)
+	if stash != nil && stash.ExcludeImage {
+		logger.Debugf("Skipping %s in stash path %s which excludes images", path, stash.Path)
+		return ""
+	}
+
+	exists, _ := utils.FileExists(path)
+	if !exists || stash == nil {
+		return "File not found"
+	}
+
+	logger.Debugf("File Found: %s", path)
+	if matchFile(path, config.GetExcludes()) {
+		return "File matched exclude pattern"
+	}
+	if !models.IsImage(path) {
+		return "File extension is not an image extension"
+	}
+
+	return ""
+}
+
+// isInGallery returns true if the image is in a gallery that the clean
+// does not remove.
+func (t *CleanImageTask) isInGallery() bool {
+	qb := models.NewGalleryImageQueryBuilder()
+	galleryImages, err := qb.FindByChecksum(t.Image.Checksum, nil)
+	if err != nil {
+		logger.Errorf("Error finding galleries of image %d: %s", t.Image.ID, err.Error())
+		// keep the image if its galleries are unknown
+		return true
+	}
+
+	for _, galleryImage := range galleryImages {
+		if !t.RemovedGalleries[galleryImage.GalleryID] {
+			return true
+		}
+	}
+	return false
+}
+
+// clean removes the image, or reports that it would be removed if this is a
+// dry run. The file path of images in galleries is cleared instead.
+func (t *CleanImageTask) clean(reason string) error {
+	name := t.getName()
+	if t.DryRun {
+		logger.Infof("%s. Would clean: \"%s\"", reason, name)
+		instance.addJobReportItem(models.JobReportItemTypeRemoved, name, "Dry run: "+reason)
+		return nil
+	}
+
+	logger.Infof("%s. Cleaning: \"%s\"", reason, name)
+
+	qb := models.NewImageQueryBuilder()
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+	var err error
+	if t.inGallery {
+		_, err = qb.Update(models.ImagePartial{
+			ID:        t.Image.ID,
+			Path:      &sql.NullString{},
+			UpdatedAt: &models.SQLiteTimestamp{Timestamp: time.Now()},
+		}, tx)
+	} else {
+		err = qb.Destroy(strconv.Itoa(t.Image.ID), tx)
+	}
+
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("error deleting image from database: %s", err.Error())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error deleting image from database: %s", err.Error())
+	}
+
+	instance.addJobReportItem(models.JobReportItemTypeRemoved, name, reason)
+
+	if !t.inGallery {
+		thumbnailPath := instance.Paths.Gallery.GetThumbnailPath(t.Image.Checksum)
+		if err := os.Remove(thumbnailPath); err != nil && !os.IsNotExist(err) {
+			logger.Warnf("Could not delete file %s: %s", thumbnailPath, err.Error())
+		}
+	}
+	return nil
+}
+
+// getName returns the path of the image, or its checksum if it has no file.
+func (t *CleanImageTask) getName() string {
+	if t.Image.Path.Valid {
+		return t.Image.Path.String
+	}
+	return t.Image.Checksum
+}
@@ -0,0 +1,26 @@
+package manager
+
+import "testing"
+
+func TestCheckCleanThreshold(t *testing.T) {
+	tests := []struct {
+		count     int
+		total     int
+		threshold int
+		abort     bool
+	}{
+		{10, 100, 0, false},
+		{100, 100, 0, false},
+		{10, 100, 10, false},
+		{11, 100, 10, true},
+		{100, 100, 50, true},
+		{0, 0, 50, false},
+	}
+
+	for _, test := range tests {
+		err := checkCleanThreshold(test.count, test.total, test.threshold)
+		if (err != nil) != test.abort {
+			t.Errorf("checkCleanThreshold(%d, %d, %d): expected abort %v, found error %v", test.count, test.total, test.threshold, test.abort, err)
+		}
+	}
+}
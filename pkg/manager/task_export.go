@@ -20,7 +20,7 @@ type ExportTask struct {
 
 func (t *ExportTask) Start(wg *sync.WaitGroup) {
 	defer wg.Done()
-	// @manager.total = Scene.count + Gallery.count + Performer.count + Studio.count
+	// @manager.total = Scene.count + Gallery.count + Image.count + Performer.count + Studio.count
 
 	t.Mappings = &jsonschema.Mappings{}
 	t.Scraped = []jsonschema.ScrapedItem{}
@@ -29,11 +29,13 @@ func (t *ExportTask) Start(wg *sync.WaitGroup) {
 
 	t.ExportScenes(ctx)
 	t.ExportGalleries(ctx)
+	t.ExportImages(ctx)
 	t.ExportPerformers(ctx)
 	t.ExportStudios(ctx)
 
 	if err := instance.JSON.saveMappings(t.Mappings); err != nil {
 		logger.Errorf("[mappings] failed to save json: %s", err.Error())
+		instance.addJobReportItem(models.JobReportItemTypeError, instance.Paths.JSON.MappingsFile, err.Error())
 	}
 
 	t.ExportScrapedItems(ctx)
@@ -48,9 +50,11 @@ func (t *ExportTask) ExportScenes(ctx context.Context) {
 	performerQB := models.NewPerformerQueryBuilder()
 	tagQB := models.NewTagQueryBuilder()
 	sceneMarkerQB := models.NewSceneMarkerQueryBuilder()
+	fingerprintQB := models.NewSceneFingerprintQueryBuilder()
 	scenes, err := qb.All()
 	if err != nil {
 		logger.Errorf("[scenes] failed to fetch all scenes: %s", err.Error())
+		instance.addJobReportItem(models.JobReportItemTypeError, "", "Failed to fetch all scenes: "+err.Error())
 	}
 
 	logger.Info("[scenes] exporting")
@@ -59,7 +63,20 @@ func (t *ExportTask) ExportScenes(ctx context.Context) {
 		index := i + 1
 		logger.Progressf("[scenes] %d of %d", index, len(scenes))
 
-		t.Mappings.Scenes = append(t.Mappings.Scenes, jsonschema.PathMapping{Path: scene.Path, Checksum: scene.Checksum})
+		oshash := ""
+		if fingerprint, _ := fingerprintQB.Find(scene.ID, models.FingerprintTypeOshash, tx); fingerprint != nil {
+			oshash = fingerprint.Fingerprint
+		}
+
+		mapping := jsonschema.PathMapping{Path: scene.Path, Checksum: scene.Checksum.String, Oshash: oshash}
+		sceneHash := getSceneMappingHash(mapping)
+		if sceneHash == "" {
+			logger.Errorf("[scenes] <%s> scene has no checksum or oshash", scene.Path)
+			instance.addJobReportItem(models.JobReportItemTypeError, scene.Path, "Scene has no checksum or oshash")
+			continue
+		}
+
+		t.Mappings.Scenes = append(t.Mappings.Scenes, mapping)
 		newSceneJSON := jsonschema.Scene{
 			CreatedAt: models.JSONTime{Time: scene.CreatedAt.Timestamp},
 			UpdatedAt: models.JSONTime{Time: scene.UpdatedAt.Timestamp},
@@ -73,11 +90,7 @@ func (t *ExportTask) ExportScenes(ctx context.Context) {
 			}
 		}
 
-		var galleryChecksum string
-		gallery, _ := galleryQB.FindBySceneID(scene.ID, tx)
-		if gallery != nil {
-			galleryChecksum = gallery.Checksum
-		}
+		galleries, _ := galleryQB.FindBySceneID(scene.ID, tx)
 
 		performers, _ := performerQB.FindBySceneID(scene.ID, tx)
 		tags, _ := tagQB.FindBySceneID(scene.ID, tx)
@@ -101,8 +114,8 @@ func (t *ExportTask) ExportScenes(ctx context.Context) {
 		if scene.Details.Valid {
 			newSceneJSON.Details = scene.Details.String
 		}
-		if galleryChecksum != "" {
-			newSceneJSON.Gallery = galleryChecksum
+		for _, gallery := range galleries {
+			newSceneJSON.Galleries = append(newSceneJSON.Galleries, gallery.Checksum)
 		}
 
 		newSceneJSON.Performers = t.getPerformerNames(performers)
@@ -111,12 +124,12 @@ func (t *ExportTask) ExportScenes(ctx context.Context) {
 		for _, sceneMarker := range sceneMarkers {
 			primaryTag, err := tagQB.Find(sceneMarker.PrimaryTagID, tx)
 			if err != nil {
-				logger.Errorf("[scenes] <%s> invalid primary tag for scene marker: %s", scene.Checksum, err.Error())
+				logger.Errorf("[scenes] <%s> invalid primary tag for scene marker: %s", sceneHash, err.Error())
 				continue
 			}
 			sceneMarkerTags, err := tagQB.FindBySceneMarkerID(sceneMarker.ID, tx)
 			if err != nil {
-				logger.Errorf("[scenes] <%s> invalid tags for scene marker: %s", scene.Checksum, err.Error())
+				logger.Errorf("[scenes] <%s> invalid tags for scene marker: %s", sceneHash, err.Error())
 				continue
 			}
 			if sceneMarker.Title == "" || sceneMarker.Seconds == 0 || primaryTag.Name == "" {
@@ -148,6 +161,9 @@ func (t *ExportTask) ExportScenes(ctx context.Context) {
 		if scene.AudioCodec.Valid {
 			newSceneJSON.File.AudioCodec = scene.AudioCodec.String
 		}
+		if scene.Format.Valid {
+			newSceneJSON.File.Format = scene.Format.String
+		}
 		if scene.Width.Valid {
 			newSceneJSON.File.Width = int(scene.Width.Int64)
 		}
@@ -165,15 +181,19 @@ func (t *ExportTask) ExportScenes(ctx context.Context) {
 			newSceneJSON.Cover = utils.GetBase64StringFromData(scene.Cover)
 		}
 
-		sceneJSON, err := instance.JSON.getScene(scene.Checksum)
+		sceneJSON, err := instance.JSON.getScene(sceneHash)
 		if err != nil {
 			logger.Debugf("[scenes] error reading scene json: %s", err.Error())
 		} else if jsonschema.CompareJSON(*sceneJSON, newSceneJSON) {
 			continue
 		}
 
-		if err := instance.JSON.saveScene(scene.Checksum, &newSceneJSON); err != nil {
-			logger.Errorf("[scenes] <%s> failed to save json: %s", scene.Checksum, err.Error())
+		jsonPath := instance.Paths.JSON.SceneJSONPath(sceneHash)
+		if err := instance.JSON.saveScene(sceneHash, &newSceneJSON); err != nil {
+			logger.Errorf("[scenes] <%s> failed to save json: %s", sceneHash, err.Error())
+			instance.addJobReportItem(models.JobReportItemTypeError, jsonPath, err.Error())
+		} else {
+			instance.addJobReportItem(models.JobReportItemTypeGenerated, jsonPath, "")
 		}
 	}
 
@@ -181,10 +201,16 @@ func (t *ExportTask) ExportScenes(ctx context.Context) {
 }
 
 func (t *ExportTask) ExportGalleries(ctx context.Context) {
+	tx := database.DB.MustBeginTx(ctx, nil)
+	defer tx.Commit()
 	qb := models.NewGalleryQueryBuilder()
+	studioQB := models.NewStudioQueryBuilder()
+	performerQB := models.NewPerformerQueryBuilder()
+	tagQB := models.NewTagQueryBuilder()
 	galleries, err := qb.All()
 	if err != nil {
 		logger.Errorf("[galleries] failed to fetch all galleries: %s", err.Error())
+		instance.addJobReportItem(models.JobReportItemTypeError, "", "Failed to fetch all galleries: "+err.Error())
 	}
 
 	logger.Info("[galleries] exporting")
@@ -193,16 +219,126 @@ func (t *ExportTask) ExportGalleries(ctx context.Context) {
 		index := i + 1
 		logger.Progressf("[galleries] %d of %d", index, len(galleries))
 		t.Mappings.Galleries = append(t.Mappings.Galleries, jsonschema.PathMapping{Path: gallery.Path, Checksum: gallery.Checksum})
+		newGalleryJSON := jsonschema.Gallery{
+			CreatedAt: models.JSONTime{Time: gallery.CreatedAt.Timestamp},
+			UpdatedAt: models.JSONTime{Time: gallery.UpdatedAt.Timestamp},
+		}
+
+		if gallery.StudioID.Valid {
+			studio, _ := studioQB.Find(int(gallery.StudioID.Int64), tx)
+			if studio != nil {
+				newGalleryJSON.Studio = studio.Name.String
+			}
+		}
+
+		performers, _ := performerQB.FindByGalleryID(gallery.ID, tx)
+		tags, _ := tagQB.FindByGalleryID(gallery.ID, tx)
+
+		if gallery.Title.Valid {
+			newGalleryJSON.Title = gallery.Title.String
+		}
+		if gallery.URL.Valid {
+			newGalleryJSON.URL = gallery.URL.String
+		}
+		if gallery.Date.Valid {
+			newGalleryJSON.Date = utils.GetYMDFromDatabaseDate(gallery.Date.String)
+		}
+		if gallery.Rating.Valid {
+			newGalleryJSON.Rating = int(gallery.Rating.Int64)
+		}
+		if gallery.Details.Valid {
+			newGalleryJSON.Details = gallery.Details.String
+		}
+
+		newGalleryJSON.Performers = t.getPerformerNames(performers)
+		newGalleryJSON.Tags = t.getTagNames(tags)
+
+		galleryJSON, err := instance.JSON.getGallery(gallery.Checksum)
+		if err != nil {
+			logger.Debugf("[galleries] error reading gallery json: %s", err.Error())
+		} else if jsonschema.CompareJSON(*galleryJSON, newGalleryJSON) {
+			continue
+		}
+
+		jsonPath := instance.Paths.JSON.GalleryJSONPath(gallery.Checksum)
+		if err := instance.JSON.saveGallery(gallery.Checksum, &newGalleryJSON); err != nil {
+			logger.Errorf("[galleries] <%s> failed to save json: %s", gallery.Checksum, err.Error())
+			instance.addJobReportItem(models.JobReportItemTypeError, jsonPath, err.Error())
+		} else {
+			instance.addJobReportItem(models.JobReportItemTypeGenerated, jsonPath, "")
+		}
 	}
 
 	logger.Infof("[galleries] export complete")
 }
 
+func (t *ExportTask) ExportImages(ctx context.Context) {
+	tx := database.DB.MustBeginTx(ctx, nil)
+	defer tx.Commit()
+	qb := models.NewImageQueryBuilder()
+	performerQB := models.NewPerformerQueryBuilder()
+	tagQB := models.NewTagQueryBuilder()
+	images, err := qb.All()
+	if err != nil {
+		logger.Errorf("[images] failed to fetch all images: %s", err.Error())
+		instance.addJobReportItem(models.JobReportItemTypeError, "", "Failed to fetch all images: "+err.Error())
+	}
+
+	logger.Info("[images] exporting")
+
+	for i, image := range images {
+		index := i + 1
+		logger.Progressf("[images] %d of %d", index, len(images))
+		t.Mappings.Images = append(t.Mappings.Images, jsonschema.PathMapping{Path: image.Path.String, Checksum: image.Checksum})
+		newImageJSON := jsonschema.Image{
+			CreatedAt: models.JSONTime{Time: image.CreatedAt.Timestamp},
+			UpdatedAt: models.JSONTime{Time: image.UpdatedAt.Timestamp},
+		}
+
+		performers, _ := performerQB.FindByImageID(image.ID, tx)
+		tags, _ := tagQB.FindByImageID(image.ID, tx)
+
+		if image.Title.Valid {
+			newImageJSON.Title = image.Title.String
+		}
+		if image.Rating.Valid {
+			newImageJSON.Rating = int(image.Rating.Int64)
+		}
+
+		newImageJSON.Performers = t.getPerformerNames(performers)
+		newImageJSON.Tags = t.getTagNames(tags)
+
+		newImageJSON.File = &jsonschema.ImageFile{
+			Size:   image.Size.String,
+			Width:  int(image.Width.Int64),
+			Height: int(image.Height.Int64),
+		}
+
+		imageJSON, err := instance.JSON.getImage(image.Checksum)
+		if err != nil {
+			logger.Debugf("[images] error reading image json: %s", err.Error())
+		} else if jsonschema.CompareJSON(*imageJSON, newImageJSON) {
+			continue
+		}
+
+		jsonPath := instance.Paths.JSON.ImageJSONPath(image.Checksum)
+		if err := instance.JSON.saveImage(image.Checksum, &newImageJSON); err != nil {
+			logger.Errorf("[images] <%s> failed to save json: %s", image.Checksum, err.Error())
+			instance.addJobReportItem(models.JobReportItemTypeError, jsonPath, err.Error())
+		} else {
+			instance.addJobReportItem(models.JobReportItemTypeGenerated, jsonPath, "")
+		}
+	}
+
+	logger.Infof("[images] export complete")
+}
+
 func (t *ExportTask) ExportPerformers(ctx context.Context) {
 	qb := models.NewPerformerQueryBuilder()
 	performers, err := qb.All()
 	if err != nil {
 		logger.Errorf("[performers] failed to fetch all performers: %s", err.Error())
+		instance.addJobReportItem(models.JobReportItemTypeError, "", "Failed to fetch all performers: "+err.Error())
 	}
 
 	logger.Info("[performers] exporting")
@@ -276,8 +412,12 @@ func (t *ExportTask) ExportPerformers(ctx context.Context) {
 			continue
 		}
 
+		jsonPath := instance.Paths.JSON.PerformerJSONPath(performer.Checksum)
 		if err := instance.JSON.savePerformer(performer.Checksum, &newPerformerJSON); err != nil {
 			logger.Errorf("[performers] <%s> failed to save json: %s", performer.Checksum, err.Error())
+			instance.addJobReportItem(models.JobReportItemTypeError, jsonPath, err.Error())
+		} else {
+			instance.addJobReportItem(models.JobReportItemTypeGenerated, jsonPath, "")
 		}
 	}
 
@@ -289,6 +429,7 @@ func (t *ExportTask) ExportStudios(ctx context.Context) {
 	studios, err := qb.All()
 	if err != nil {
 		logger.Errorf("[studios] failed to fetch all studios: %s", err.Error())
+		instance.addJobReportItem(models.JobReportItemTypeError, "", "Failed to fetch all studios: "+err.Error())
 	}
 
 	logger.Info("[studios] exporting")
@@ -320,8 +461,12 @@ func (t *ExportTask) ExportStudios(ctx context.Context) {
 			continue
 		}
 
+		jsonPath := instance.Paths.JSON.StudioJSONPath(studio.Checksum)
 		if err := instance.JSON.saveStudio(studio.Checksum, &newStudioJSON); err != nil {
 			logger.Errorf("[studios] <%s> failed to save json: %s", studio.Checksum, err.Error())
+			instance.addJobReportItem(models.JobReportItemTypeError, jsonPath, err.Error())
+		} else {
+			instance.addJobReportItem(models.JobReportItemTypeGenerated, jsonPath, "")
 		}
 	}
 
@@ -405,6 +550,9 @@ func (t *ExportTask) ExportScrapedItems(ctx context.Context) {
 	if !jsonschema.CompareJSON(scrapedJSON, t.Scraped) {
 		if err := instance.JSON.saveScaped(t.Scraped); err != nil {
 			logger.Errorf("[scraped sites] failed to save json: %s", err.Error())
+			instance.addJobReportItem(models.JobReportItemTypeError, instance.Paths.JSON.ScrapedFile, err.Error())
+		} else {
+			instance.addJobReportItem(models.JobReportItemTypeGenerated, instance.Paths.JSON.ScrapedFile, "")
 		}
 	}
 
@@ -0,0 +1,70 @@
+package manager
+
+import (
+	"io/ioutil"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+type GenerateGalleryThumbnailsTask struct {
+	Gallery models.Gallery
+}
+
+func (t *GenerateGalleryThumbnailsTask) Start() error {
+	if !t.isThumbnailsNeeded() {
+		return nil
+	}
+
+	images, err := readGalleryImages(t.Gallery, func(galleryImage models.GalleryImage, data []byte) {
+		if err := t.generateThumbnail(galleryImage.Checksum, data); err != nil {
+			logger.Warnf("error generating thumbnail of %s in %s: %s", galleryImage.Name, t.Gallery.Path, err.Error())
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := saveGalleryImages(t.Gallery.ID, images); err != nil {
+		return err
+	}
+
+	instance.addJobReportItem(models.JobReportItemTypeGenerated, t.Gallery.Path, "Gallery thumbnails")
+	return nil
+}
+
+// generateThumbnail writes the thumbnail of the image, unless the thumbnail
+// of an identical image already exists.
+func (t *GenerateGalleryThumbnailsTask) generateThumbnail(checksum string, data []byte) error {
+	thumbnailPath := instance.Paths.Gallery.GetThumbnailPath(checksum)
+	if exists, _ := utils.FileExists(thumbnailPath); exists {
+		return nil
+	}
+
+	thumbnail, err := models.GetGalleryThumbnail(data)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(thumbnailPath, thumbnail, 0644)
+}
+
+// isThumbnailsNeeded returns true if the gallery images have not been
+// stored, or the thumbnail of a stored image is missing.
+func (t *GenerateGalleryThumbnailsTask) isThumbnailsNeeded() bool {
+	qb := models.NewGalleryImageQueryBuilder()
+	images, err := qb.FindByGalleryID(t.Gallery.ID, nil)
+	if err != nil || len(images) == 0 {
+		return true
+	}
+
+	for _, image := range images {
+		if !image.Width.Valid {
+			continue
+		}
+		if exists, _ := utils.FileExists(instance.Paths.Gallery.GetThumbnailPath(image.Checksum)); !exists {
+			return true
+		}
+	}
+	return false
+}
@@ -1,10 +1,10 @@
 package manager
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
-	"sync"
 
 	"github.com/stashapp/stash/pkg/ffmpeg"
 	"github.com/stashapp/stash/pkg/logger"
@@ -16,36 +16,41 @@ type GenerateMarkersTask struct {
 	Scene models.Scene
 }
 
-func (t *GenerateMarkersTask) Start(wg *sync.WaitGroup) {
-	defer wg.Done()
-
+func (t *GenerateMarkersTask) Start() error {
 	qb := models.NewSceneMarkerQueryBuilder()
 	sceneMarkers, _ := qb.FindBySceneID(t.Scene.ID, nil)
 	if len(sceneMarkers) == 0 {
-		return
+		return nil
 	}
 
 	videoFile, err := ffmpeg.NewVideoFile(instance.FFProbePath, t.Scene.Path)
 	if err != nil {
-		logger.Errorf("error reading video file: %s", err.Error())
-		return
+		return fmt.Errorf("error reading video file: %s", err.Error())
 	}
 
 	// Make the folder for the scenes markers
-	markersFolder := filepath.Join(instance.Paths.Generated.Markers, t.Scene.Checksum)
+	sceneHash := GetSceneHash(&t.Scene)
+	defer lockSceneHash(sceneHash)()
+	markersFolder := filepath.Join(instance.Paths.Generated.Markers, sceneHash)
 	_ = utils.EnsureDir(markersFolder)
 
-	encoder := ffmpeg.NewEncoder(instance.FFMPEGPath)
+	encoder := instance.newJobEncoder()
+	generated := 0
+	failed := 0
 	for i, sceneMarker := range sceneMarkers {
+		if instance.Status.isStopping() {
+			break
+		}
+
 		index := i + 1
-		logger.Progressf("[generator] <%s> scene marker %d of %d", t.Scene.Checksum, index, len(sceneMarkers))
+		logger.Progressf("[generator] <%s> scene marker %d of %d", sceneHash, index, len(sceneMarkers))
 
 		seconds := int(sceneMarker.Seconds)
 		baseFilename := strconv.Itoa(seconds)
 		videoFilename := baseFilename + ".mp4"
 		imageFilename := baseFilename + ".webp"
-		videoPath := instance.Paths.SceneMarkers.GetStreamPath(t.Scene.Checksum, seconds)
-		imagePath := instance.Paths.SceneMarkers.GetStreamPreviewImagePath(t.Scene.Checksum, seconds)
+		videoPath := instance.Paths.SceneMarkers.GetStreamPath(sceneHash, seconds)
+		imagePath := instance.Paths.SceneMarkers.GetStreamPreviewImagePath(sceneHash, seconds)
 		videoExists, _ := utils.FileExists(videoPath)
 		imageExists, _ := utils.FileExists(imagePath)
 
@@ -55,25 +60,41 @@ func (t *GenerateMarkersTask) Start(wg *sync.WaitGroup) {
 			Width:     640,
 		}
 		if !videoExists {
-			options.OutputPath = instance.Paths.Generated.GetTmpPath(videoFilename) // tmp output in case the process ends abruptly
+			options.OutputPath = instance.Paths.Generated.GetTmpPath(sceneHash + "_" + videoFilename) // tmp output in case the process ends abruptly
 			if err := encoder.SceneMarkerVideo(*videoFile, options); err != nil {
+				_ = os.Remove(options.OutputPath)
 				logger.Errorf("[generator] failed to generate marker video: %s", err)
+				failed++
 			} else {
 				_ = os.Rename(options.OutputPath, videoPath)
 				logger.Debug("created marker video: ", videoPath)
+				generated++
 			}
 		}
 
 		if !imageExists {
-			options.OutputPath = instance.Paths.Generated.GetTmpPath(imageFilename) // tmp output in case the process ends abruptly
+			options.OutputPath = instance.Paths.Generated.GetTmpPath(sceneHash + "_" + imageFilename) // tmp output in case the process ends abruptly
 			if err := encoder.SceneMarkerImage(*videoFile, options); err != nil {
+				_ = os.Remove(options.OutputPath)
 				logger.Errorf("[generator] failed to generate marker image: %s", err)
+				failed++
 			} else {
 				_ = os.Rename(options.OutputPath, imagePath)
 				logger.Debug("created marker image: ", videoPath)
+				generated++
 			}
 		}
 	}
+
+	if generated > 0 {
+		instance.addJobReportItem(models.JobReportItemTypeGenerated, t.Scene.Path, fmt.Sprintf("%d marker files", generated))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to generate %d marker files", failed)
+	}
+
+	return nil
 }
 
 func (t *GenerateMarkersTask) isMarkerNeeded() int {
@@ -85,10 +106,11 @@ func (t *GenerateMarkersTask) isMarkerNeeded() int {
 		return 0
 	}
 
+	sceneHash := GetSceneHash(&t.Scene)
 	for _, sceneMarker := range sceneMarkers {
 		seconds := int(sceneMarker.Seconds)
-		videoPath := instance.Paths.SceneMarkers.GetStreamPath(t.Scene.Checksum, seconds)
-		imagePath := instance.Paths.SceneMarkers.GetStreamPreviewImagePath(t.Scene.Checksum, seconds)
+		videoPath := instance.Paths.SceneMarkers.GetStreamPath(sceneHash, seconds)
+		imagePath := instance.Paths.SceneMarkers.GetStreamPreviewImagePath(sceneHash, seconds)
 		videoExists, _ := utils.FileExists(videoPath)
 		imageExists, _ := utils.FileExists(imagePath)
 
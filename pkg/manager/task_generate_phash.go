@@ -0,0 +1,59 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+type GeneratePhashTask struct {
+	Scene models.Scene
+}
+
+func (t *GeneratePhashTask) Start() error {
+	if t.doesPhashExist() {
+		return nil
+	}
+
+	videoFile, err := ffmpeg.NewVideoFile(instance.FFProbePath, t.Scene.Path)
+	if err != nil {
+		return fmt.Errorf("error reading video file: %s", err.Error())
+	}
+
+	// the frames are named with the scene hash
+	sceneHash := GetSceneHash(&t.Scene)
+	defer lockSceneHash(sceneHash)()
+	generator, err := NewPhashGenerator(*videoFile, sceneHash)
+	if err != nil {
+		return fmt.Errorf("error creating phash generator: %s", err.Error())
+	}
+
+	hash, err := generator.Generate()
+	if err != nil {
+		return fmt.Errorf("error generating phash: %s", err.Error())
+	}
+
+	qb := models.NewSceneFingerprintQueryBuilder()
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+	if err := qb.Set(t.Scene.ID, models.FingerprintTypePhash, utils.PerceptualHashString(hash), tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	instance.addJobReportItem(models.JobReportItemTypeGenerated, t.Scene.Path, "Phash")
+	return nil
+}
+
+func (t *GeneratePhashTask) doesPhashExist() bool {
+	qb := models.NewSceneFingerprintQueryBuilder()
+	fingerprint, _ := qb.Find(t.Scene.ID, models.FingerprintTypePhash, nil)
+	return fingerprint != nil
+}
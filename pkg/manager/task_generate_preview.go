@@ -1,54 +1,72 @@
 package manager
 
 import (
+	"fmt"
+
 	"github.com/stashapp/stash/pkg/ffmpeg"
-	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/utils"
-	"sync"
 )
 
 type GeneratePreviewTask struct {
-	Scene models.Scene
+	Scene   models.Scene
+	Options PreviewOptions
 }
 
-func (t *GeneratePreviewTask) Start(wg *sync.WaitGroup) {
-	defer wg.Done()
+func (t *GeneratePreviewTask) Start() error {
+	sceneHash := GetSceneHash(&t.Scene)
+	defer lockSceneHash(sceneHash)()
+	videoFilename := t.videoFilename(sceneHash)
+	imageFilename := t.imageFilename(sceneHash)
+	if t.doesPreviewExist(sceneHash) {
+		return nil
+	}
 
-	videoFilename := t.videoFilename()
-	imageFilename := t.imageFilename()
-	if t.doesPreviewExist(t.Scene.Checksum) {
-		return
+	if err := t.Options.Validate(); err != nil {
+		return err
 	}
 
 	videoFile, err := ffmpeg.NewVideoFile(instance.FFProbePath, t.Scene.Path)
 	if err != nil {
-		logger.Errorf("error reading video file: %s", err.Error())
-		return
+		return fmt.Errorf("error reading video file: %s", err.Error())
 	}
 
-	generator, err := NewPreviewGenerator(*videoFile, videoFilename, imageFilename, instance.Paths.Generated.Screenshots)
+	generator, err := NewPreviewGenerator(*videoFile, videoFilename, imageFilename, instance.Paths.Generated.Screenshots, t.Options)
 	if err != nil {
-		logger.Errorf("error creating preview generator: %s", err.Error())
-		return
+		return fmt.Errorf("error creating preview generator: %s", err.Error())
 	}
 
+	// existing preview files are replaced if the options have changed
+	generator.Overwrite = !t.hasCurrentOptions(sceneHash)
 	if err := generator.Generate(); err != nil {
-		logger.Errorf("error generating preview: %s", err.Error())
-		return
+		return fmt.Errorf("error generating preview: %s", err.Error())
+	}
+
+	if err := writePreviewOptions(instance.Paths.Scene.GetStreamPreviewOptionsPath(sceneHash), t.Options); err != nil {
+		return fmt.Errorf("error saving preview options: %s", err.Error())
 	}
+
+	instance.addJobReportItem(models.JobReportItemTypeGenerated, t.Scene.Path, "Preview")
+	return nil
+}
+
+func (t *GeneratePreviewTask) doesPreviewExist(sceneHash string) bool {
+	videoExists, _ := utils.FileExists(instance.Paths.Scene.GetStreamPreviewPath(sceneHash))
+	imageExists, _ := utils.FileExists(instance.Paths.Scene.GetStreamPreviewImagePath(sceneHash))
+	return videoExists && imageExists && t.hasCurrentOptions(sceneHash)
 }
 
-func (t *GeneratePreviewTask) doesPreviewExist(sceneChecksum string) bool {
-	videoExists, _ := utils.FileExists(instance.Paths.Scene.GetStreamPreviewPath(sceneChecksum))
-	imageExists, _ := utils.FileExists(instance.Paths.Scene.GetStreamPreviewImagePath(sceneChecksum))
-	return videoExists && imageExists
+// hasCurrentOptions returns true if the existing preview was generated with
+// the options of the task.
+func (t *GeneratePreviewTask) hasCurrentOptions(sceneHash string) bool {
+	options, err := readPreviewOptions(instance.Paths.Scene.GetStreamPreviewOptionsPath(sceneHash))
+	return err == nil && options == t.Options
 }
 
-func (t *GeneratePreviewTask) videoFilename() string {
-	return t.Scene.Checksum + ".mp4"
+func (t *GeneratePreviewTask) videoFilename(sceneHash string) string {
+	return sceneHash + ".mp4"
 }
 
-func (t *GeneratePreviewTask) imageFilename() string {
-	return t.Scene.Checksum + ".webp"
+func (t *GeneratePreviewTask) imageFilename(sceneHash string) string {
+	return sceneHash + ".webp"
 }
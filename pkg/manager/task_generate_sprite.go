@@ -1,46 +1,46 @@
 package manager
 
 import (
+	"fmt"
+
 	"github.com/stashapp/stash/pkg/ffmpeg"
-	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/utils"
-	"sync"
 )
 
 type GenerateSpriteTask struct {
 	Scene models.Scene
 }
 
-func (t *GenerateSpriteTask) Start(wg *sync.WaitGroup) {
-	defer wg.Done()
-
-	if t.doesSpriteExist(t.Scene.Checksum) {
-		return
+func (t *GenerateSpriteTask) Start() error {
+	sceneHash := GetSceneHash(&t.Scene)
+	defer lockSceneHash(sceneHash)()
+	if t.doesSpriteExist(sceneHash) {
+		return nil
 	}
 
 	videoFile, err := ffmpeg.NewVideoFile(instance.FFProbePath, t.Scene.Path)
 	if err != nil {
-		logger.Errorf("error reading video file: %s", err.Error())
-		return
+		return fmt.Errorf("error reading video file: %s", err.Error())
 	}
 
-	imagePath := instance.Paths.Scene.GetSpriteImageFilePath(t.Scene.Checksum)
-	vttPath := instance.Paths.Scene.GetSpriteVttFilePath(t.Scene.Checksum)
+	imagePath := instance.Paths.Scene.GetSpriteImageFilePath(sceneHash)
+	vttPath := instance.Paths.Scene.GetSpriteVttFilePath(sceneHash)
 	generator, err := NewSpriteGenerator(*videoFile, imagePath, vttPath, 9, 9)
 	if err != nil {
-		logger.Errorf("error creating sprite generator: %s", err.Error())
-		return
+		return fmt.Errorf("error creating sprite generator: %s", err.Error())
 	}
 
 	if err := generator.Generate(); err != nil {
-		logger.Errorf("error generating sprite: %s", err.Error())
-		return
+		return fmt.Errorf("error generating sprite: %s", err.Error())
 	}
+
+	instance.addJobReportItem(models.JobReportItemTypeGenerated, t.Scene.Path, "Sprite")
+	return nil
 }
 
-func (t *GenerateSpriteTask) doesSpriteExist(sceneChecksum string) bool {
-	imageExists, _ := utils.FileExists(instance.Paths.Scene.GetSpriteImageFilePath(sceneChecksum))
-	vttExists, _ := utils.FileExists(instance.Paths.Scene.GetSpriteVttFilePath(sceneChecksum))
+func (t *GenerateSpriteTask) doesSpriteExist(sceneHash string) bool {
+	imageExists, _ := utils.FileExists(instance.Paths.Scene.GetSpriteImageFilePath(sceneHash))
+	vttExists, _ := utils.FileExists(instance.Paths.Scene.GetSpriteVttFilePath(sceneHash))
 	return imageExists && vttExists
 }
@@ -10,7 +10,6 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/stashapp/stash/pkg/database"
 	"github.com/stashapp/stash/pkg/logger"
-	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/manager/jsonschema"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/utils"
@@ -27,6 +26,7 @@ func (t *ImportTask) Start(wg *sync.WaitGroup) {
 	t.Mappings, _ = instance.JSON.getMappings()
 	if t.Mappings == nil {
 		logger.Error("missing mappings json")
+		instance.addJobReportItem(models.JobReportItemTypeError, instance.Paths.JSON.MappingsFile, "missing mappings json")
 		return
 	}
 	scraped, _ := instance.JSON.getScraped()
@@ -35,10 +35,11 @@ func (t *ImportTask) Start(wg *sync.WaitGroup) {
 	}
 	t.Scraped = scraped
 
-	err := database.Reset(config.GetDatabasePath())
+	err := database.Reset()
 
 	if err != nil {
 		logger.Errorf("Error resetting database: %s", err.Error())
+		instance.addJobReportItem(models.JobReportItemTypeError, "", "Error resetting database: "+err.Error())
 		return
 	}
 
@@ -46,14 +47,16 @@ func (t *ImportTask) Start(wg *sync.WaitGroup) {
 
 	t.ImportPerformers(ctx)
 	t.ImportStudios(ctx)
-	t.ImportGalleries(ctx)
 	t.ImportTags(ctx)
+	t.ImportGalleries(ctx)
+	t.ImportImages(ctx)
 
 	t.ImportScrapedItems(ctx)
 	t.ImportScenes(ctx)
 }
 
 func (t *ImportTask) ImportPerformers(ctx context.Context) {
+	var added []string
 	tx := database.DB.MustBeginTx(ctx, nil)
 	qb := models.NewPerformerQueryBuilder()
 
@@ -140,18 +143,24 @@ func (t *ImportTask) ImportPerformers(ctx context.Context) {
 		if err != nil {
 			_ = tx.Rollback()
 			logger.Errorf("[performers] <%s> failed to create: %s", mappingJSON.Checksum, err.Error())
+			instance.addJobReportItem(models.JobReportItemTypeError, mappingJSON.Name, err.Error())
 			return
 		}
+		added = append(added, mappingJSON.Name)
 	}
 
 	logger.Info("[performers] importing")
 	if err := tx.Commit(); err != nil {
 		logger.Errorf("[performers] import failed to commit: %s", err.Error())
+		instance.addJobReportItem(models.JobReportItemTypeError, "", "performers import failed to commit: "+err.Error())
+	} else {
+		t.reportAdded(added)
 	}
 	logger.Info("[performers] import complete")
 }
 
 func (t *ImportTask) ImportStudios(ctx context.Context) {
+	var added []string
 	tx := database.DB.MustBeginTx(ctx, nil)
 	qb := models.NewStudioQueryBuilder()
 
@@ -193,20 +202,27 @@ func (t *ImportTask) ImportStudios(ctx context.Context) {
 		if err != nil {
 			_ = tx.Rollback()
 			logger.Errorf("[studios] <%s> failed to create: %s", mappingJSON.Checksum, err.Error())
+			instance.addJobReportItem(models.JobReportItemTypeError, mappingJSON.Name, err.Error())
 			return
 		}
+		added = append(added, mappingJSON.Name)
 	}
 
 	logger.Info("[studios] importing")
 	if err := tx.Commit(); err != nil {
 		logger.Errorf("[studios] import failed to commit: %s", err.Error())
+		instance.addJobReportItem(models.JobReportItemTypeError, "", "studios import failed to commit: "+err.Error())
+	} else {
+		t.reportAdded(added)
 	}
 	logger.Info("[studios] import complete")
 }
 
 func (t *ImportTask) ImportGalleries(ctx context.Context) {
+	var added []string
 	tx := database.DB.MustBeginTx(ctx, nil)
 	qb := models.NewGalleryQueryBuilder()
+	jqb := models.NewJoinsQueryBuilder()
 
 	for i, mappingJSON := range t.Mappings.Galleries {
 		index := i + 1
@@ -225,22 +241,230 @@ func (t *ImportTask) ImportGalleries(ctx context.Context) {
 			UpdatedAt: models.SQLiteTimestamp{Timestamp: currentTime},
 		}
 
-		_, err := qb.Create(newGallery, tx)
+		// galleries exported before gallery metadata was added have no
+		// json file
+		galleryJSON, err := instance.JSON.getGallery(mappingJSON.Checksum)
+		if err != nil {
+			logger.Debugf("[galleries] <%s> json parse failure: %s", mappingJSON.Checksum, err.Error())
+		}
+
+		// Populate gallery fields
+		if galleryJSON != nil {
+			if galleryJSON.Title != "" {
+				newGallery.Title = sql.NullString{String: galleryJSON.Title, Valid: true}
+			}
+			if galleryJSON.Details != "" {
+				newGallery.Details = sql.NullString{String: galleryJSON.Details, Valid: true}
+			}
+			if galleryJSON.URL != "" {
+				newGallery.URL = sql.NullString{String: galleryJSON.URL, Valid: true}
+			}
+			if galleryJSON.Date != "" {
+				newGallery.Date = models.SQLiteDate{String: galleryJSON.Date, Valid: true}
+			}
+			if galleryJSON.Rating != 0 {
+				newGallery.Rating = sql.NullInt64{Int64: int64(galleryJSON.Rating), Valid: true}
+			}
+			newGallery.CreatedAt = models.SQLiteTimestamp{Timestamp: t.getTimeFromJSONTime(galleryJSON.CreatedAt)}
+			newGallery.UpdatedAt = models.SQLiteTimestamp{Timestamp: t.getTimeFromJSONTime(galleryJSON.UpdatedAt)}
+
+			// Populate the studio ID
+			if galleryJSON.Studio != "" {
+				sqb := models.NewStudioQueryBuilder()
+				studio, err := sqb.FindByName(galleryJSON.Studio, tx)
+				if err != nil || studio == nil {
+					logger.Warnf("[galleries] studio <%s> does not exist", galleryJSON.Studio)
+				} else {
+					newGallery.StudioID = sql.NullInt64{Int64: int64(studio.ID), Valid: true}
+				}
+			}
+		}
+
+		gallery, err := qb.Create(newGallery, tx)
 		if err != nil {
 			_ = tx.Rollback()
 			logger.Errorf("[galleries] <%s> failed to create: %s", mappingJSON.Checksum, err.Error())
+			instance.addJobReportItem(models.JobReportItemTypeError, mappingJSON.Path, err.Error())
 			return
 		}
+		added = append(added, mappingJSON.Path)
+
+		if galleryJSON == nil {
+			continue
+		}
+
+		// Relate the gallery to the performers
+		if len(galleryJSON.Performers) > 0 {
+			performers, err := t.getPerformers(galleryJSON.Performers, tx)
+			if err != nil {
+				logger.Warnf("[galleries] <%s> failed to fetch performers: %s", gallery.Checksum, err.Error())
+			} else {
+				var performerJoins []models.PerformersGalleries
+				for _, performer := range performers {
+					join := models.PerformersGalleries{
+						PerformerID: performer.ID,
+						GalleryID:   gallery.ID,
+					}
+					performerJoins = append(performerJoins, join)
+				}
+				if err := jqb.CreatePerformersGalleries(performerJoins, tx); err != nil {
+					logger.Errorf("[galleries] <%s> failed to associate performers: %s", gallery.Checksum, err.Error())
+				}
+			}
+		}
+
+		// Relate the gallery to the tags
+		if len(galleryJSON.Tags) > 0 {
+			tags, err := t.getTags(gallery.Checksum, galleryJSON.Tags, tx)
+			if err != nil {
+				logger.Warnf("[galleries] <%s> failed to fetch tags: %s", gallery.Checksum, err.Error())
+			} else {
+				var tagJoins []models.GalleriesTags
+				for _, tag := range tags {
+					join := models.GalleriesTags{
+						GalleryID: gallery.ID,
+						TagID:     tag.ID,
+					}
+					tagJoins = append(tagJoins, join)
+				}
+				if err := jqb.CreateGalleriesTags(tagJoins, tx); err != nil {
+					logger.Errorf("[galleries] <%s> failed to associate tags: %s", gallery.Checksum, err.Error())
+				}
+			}
+		}
 	}
 
 	logger.Info("[galleries] importing")
 	if err := tx.Commit(); err != nil {
 		logger.Errorf("[galleries] import failed to commit: %s", err.Error())
+		instance.addJobReportItem(models.JobReportItemTypeError, "", "galleries import failed to commit: "+err.Error())
+	} else {
+		t.reportAdded(added)
 	}
 	logger.Info("[galleries] import complete")
 }
 
+func (t *ImportTask) ImportImages(ctx context.Context) {
+	var added []string
+	tx := database.DB.MustBeginTx(ctx, nil)
+	qb := models.NewImageQueryBuilder()
+	jqb := models.NewJoinsQueryBuilder()
+
+	for i, mappingJSON := range t.Mappings.Images {
+		index := i + 1
+		if mappingJSON.Checksum == "" {
+			_ = tx.Rollback()
+			logger.Warn("[images] image mapping without checksum: ", mappingJSON)
+			return
+		}
+
+		logger.Progressf("[images] %d of %d", index, len(t.Mappings.Images))
+
+		// Populate a new image from the input
+		currentTime := time.Now()
+		newImage := models.Image{
+			Checksum:  mappingJSON.Checksum,
+			CreatedAt: models.SQLiteTimestamp{Timestamp: currentTime},
+			UpdatedAt: models.SQLiteTimestamp{Timestamp: currentTime},
+		}
+		if mappingJSON.Path != "" {
+			newImage.Path = sql.NullString{String: mappingJSON.Path, Valid: true}
+		}
+
+		imageJSON, err := instance.JSON.getImage(mappingJSON.Checksum)
+		if err != nil {
+			logger.Infof("[images] <%s> json parse failure: %s", mappingJSON.Checksum, err.Error())
+		}
+
+		// Populate image fields
+		if imageJSON != nil {
+			if imageJSON.Title != "" {
+				newImage.Title = sql.NullString{String: imageJSON.Title, Valid: true}
+			}
+			if imageJSON.Rating != 0 {
+				newImage.Rating = sql.NullInt64{Int64: int64(imageJSON.Rating), Valid: true}
+			}
+			if imageJSON.File != nil {
+				if imageJSON.File.Size != "" {
+					newImage.Size = sql.NullString{String: imageJSON.File.Size, Valid: true}
+				}
+				if imageJSON.File.Width != 0 {
+					newImage.Width = sql.NullInt64{Int64: int64(imageJSON.File.Width), Valid: true}
+				}
+				if imageJSON.File.Height != 0 {
+					newImage.Height = sql.NullInt64{Int64: int64(imageJSON.File.Height), Valid: true}
+				}
+			}
+			newImage.CreatedAt = models.SQLiteTimestamp{Timestamp: t.getTimeFromJSONTime(imageJSON.CreatedAt)}
+			newImage.UpdatedAt = models.SQLiteTimestamp{Timestamp: t.getTimeFromJSONTime(imageJSON.UpdatedAt)}
+		}
+
+		image, err := qb.Create(newImage, tx)
+		if err != nil {
+			_ = tx.Rollback()
+			logger.Errorf("[images] <%s> failed to create: %s", mappingJSON.Checksum, err.Error())
+			instance.addJobReportItem(models.JobReportItemTypeError, imageName(mappingJSON), err.Error())
+			return
+		}
+		added = append(added, imageName(mappingJSON))
+
+		if imageJSON == nil {
+			continue
+		}
+
+		// Relate the image to the performers
+		if len(imageJSON.Performers) > 0 {
+			performers, err := t.getPerformers(imageJSON.Performers, tx)
+			if err != nil {
+				logger.Warnf("[images] <%s> failed to fetch performers: %s", image.Checksum, err.Error())
+			} else {
+				var performerJoins []models.PerformersImages
+				for _, performer := range performers {
+					join := models.PerformersImages{
+						PerformerID: performer.ID,
+						ImageID:     image.ID,
+					}
+					performerJoins = append(performerJoins, join)
+				}
+				if err := jqb.CreatePerformersImages(performerJoins, tx); err != nil {
+					logger.Errorf("[images] <%s> failed to associate performers: %s", image.Checksum, err.Error())
+				}
+			}
+		}
+
+		// Relate the image to the tags
+		if len(imageJSON.Tags) > 0 {
+			tags, err := t.getTags(image.Checksum, imageJSON.Tags, tx)
+			if err != nil {
+				logger.Warnf("[images] <%s> failed to fetch tags: %s", image.Checksum, err.Error())
+			} else {
+				var tagJoins []models.ImagesTags
+				for _, tag := range tags {
+					join := models.ImagesTags{
+						ImageID: image.ID,
+						TagID:   tag.ID,
+					}
+					tagJoins = append(tagJoins, join)
+				}
+				if err := jqb.CreateImagesTags(tagJoins, tx); err != nil {
+					logger.Errorf("[images] <%s> failed to associate tags: %s", image.Checksum, err.Error())
+				}
+			}
+		}
+	}
+
+	logger.Info("[images] importing")
+	if err := tx.Commit(); err != nil {
+		logger.Errorf("[images] import failed to commit: %s", err.Error())
+		instance.addJobReportItem(models.JobReportItemTypeError, "", "images import failed to commit: "+err.Error())
+	} else {
+		t.reportAdded(added)
+	}
+	logger.Info("[images] import complete")
+}
+
 func (t *ImportTask) ImportTags(ctx context.Context) {
+	var added []string
 	tx := database.DB.MustBeginTx(ctx, nil)
 	qb := models.NewTagQueryBuilder()
 
@@ -248,7 +472,8 @@ func (t *ImportTask) ImportTags(ctx context.Context) {
 
 	for i, mappingJSON := range t.Mappings.Scenes {
 		index := i + 1
-		if mappingJSON.Checksum == "" || mappingJSON.Path == "" {
+		sceneHash := getSceneMappingHash(mappingJSON)
+		if sceneHash == "" || mappingJSON.Path == "" {
 			_ = tx.Rollback()
 			logger.Warn("[tags] scene mapping without checksum or path: ", mappingJSON)
 			return
@@ -256,9 +481,9 @@ func (t *ImportTask) ImportTags(ctx context.Context) {
 
 		logger.Progressf("[tags] %d of %d scenes", index, len(t.Mappings.Scenes))
 
-		sceneJSON, err := instance.JSON.getScene(mappingJSON.Checksum)
+		sceneJSON, err := instance.JSON.getScene(sceneHash)
 		if err != nil {
-			logger.Infof("[tags] <%s> json parse failure: %s", mappingJSON.Checksum, err.Error())
+			logger.Infof("[tags] <%s> json parse failure: %s", sceneHash, err.Error())
 		}
 		// Return early if we are missing a json file.
 		if sceneJSON == nil {
@@ -284,6 +509,20 @@ func (t *ImportTask) ImportTags(ctx context.Context) {
 		}
 	}
 
+	for _, mappingJSON := range t.Mappings.Galleries {
+		galleryJSON, _ := instance.JSON.getGallery(mappingJSON.Checksum)
+		if galleryJSON != nil && len(galleryJSON.Tags) > 0 {
+			tagNames = append(tagNames, galleryJSON.Tags...)
+		}
+	}
+
+	for _, mappingJSON := range t.Mappings.Images {
+		imageJSON, _ := instance.JSON.getImage(mappingJSON.Checksum)
+		if imageJSON != nil && len(imageJSON.Tags) > 0 {
+			tagNames = append(tagNames, imageJSON.Tags...)
+		}
+	}
+
 	uniqueTagNames := t.getUnique(tagNames)
 	for _, tagName := range uniqueTagNames {
 		currentTime := time.Now()
@@ -297,13 +536,18 @@ func (t *ImportTask) ImportTags(ctx context.Context) {
 		if err != nil {
 			_ = tx.Rollback()
 			logger.Errorf("[tags] <%s> failed to create: %s", tagName, err.Error())
+			instance.addJobReportItem(models.JobReportItemTypeError, tagName, err.Error())
 			return
 		}
+		added = append(added, tagName)
 	}
 
 	logger.Info("[tags] importing")
 	if err := tx.Commit(); err != nil {
 		logger.Errorf("[tags] import failed to commit: %s", err.Error())
+		instance.addJobReportItem(models.JobReportItemTypeError, "", "tags import failed to commit: "+err.Error())
+	} else {
+		t.reportAdded(added)
 	}
 	logger.Info("[tags] import complete")
 }
@@ -352,18 +596,21 @@ func (t *ImportTask) ImportScrapedItems(ctx context.Context) {
 	logger.Info("[scraped sites] importing")
 	if err := tx.Commit(); err != nil {
 		logger.Errorf("[scraped sites] import failed to commit: %s", err.Error())
+		instance.addJobReportItem(models.JobReportItemTypeError, "", "scraped sites import failed to commit: "+err.Error())
 	}
 	logger.Info("[scraped sites] import complete")
 }
 
 func (t *ImportTask) ImportScenes(ctx context.Context) {
+	var added []string
 	tx := database.DB.MustBeginTx(ctx, nil)
 	qb := models.NewSceneQueryBuilder()
 	jqb := models.NewJoinsQueryBuilder()
 
 	for i, mappingJSON := range t.Mappings.Scenes {
 		index := i + 1
-		if mappingJSON.Checksum == "" || mappingJSON.Path == "" {
+		sceneHash := getSceneMappingHash(mappingJSON)
+		if sceneHash == "" || mappingJSON.Path == "" {
 			_ = tx.Rollback()
 			logger.Warn("[scenes] scene mapping without checksum or path: ", mappingJSON)
 			return
@@ -372,13 +619,13 @@ func (t *ImportTask) ImportScenes(ctx context.Context) {
 		logger.Progressf("[scenes] %d of %d", index, len(t.Mappings.Scenes))
 
 		newScene := models.Scene{
-			Checksum: mappingJSON.Checksum,
+			Checksum: sql.NullString{String: mappingJSON.Checksum, Valid: mappingJSON.Checksum != ""},
 			Path:     mappingJSON.Path,
 		}
 
-		sceneJSON, err := instance.JSON.getScene(mappingJSON.Checksum)
+		sceneJSON, err := instance.JSON.getScene(sceneHash)
 		if err != nil {
-			logger.Infof("[scenes] <%s> json parse failure: %s", mappingJSON.Checksum, err.Error())
+			logger.Infof("[scenes] <%s> json parse failure: %s", sceneHash, err.Error())
 			continue
 		}
 
@@ -386,11 +633,11 @@ func (t *ImportTask) ImportScenes(ctx context.Context) {
 		if sceneJSON.Cover != "" {
 			_, coverImageData, err := utils.ProcessBase64Image(sceneJSON.Cover)
 			if err != nil {
-				logger.Warnf("[scenes] <%s> invalid cover image: %s", mappingJSON.Checksum, err.Error())
+				logger.Warnf("[scenes] <%s> invalid cover image: %s", sceneHash, err.Error())
 			}
 			if len(coverImageData) > 0 {
-				if err = SetSceneScreenshot(mappingJSON.Checksum, coverImageData); err != nil {
-					logger.Warnf("[scenes] <%s> failed to create cover image: %s", mappingJSON.Checksum, err.Error())
+				if err = SetSceneScreenshot(selectSceneHash(mappingJSON.Checksum, mappingJSON.Oshash), coverImageData); err != nil {
+					logger.Warnf("[scenes] <%s> failed to create cover image: %s", sceneHash, err.Error())
 				} else {
 					newScene.Cover = coverImageData
 				}
@@ -431,6 +678,9 @@ func (t *ImportTask) ImportScenes(ctx context.Context) {
 				if sceneJSON.File.AudioCodec != "" {
 					newScene.AudioCodec = sql.NullString{String: sceneJSON.File.AudioCodec, Valid: true}
 				}
+				if sceneJSON.File.Format != "" {
+					newScene.Format = sql.NullString{String: sceneJSON.File.Format, Valid: true}
+				}
 				if sceneJSON.File.Width != 0 {
 					newScene.Width = sql.NullInt64{Int64: int64(sceneJSON.File.Width), Valid: true}
 				}
@@ -464,27 +714,50 @@ func (t *ImportTask) ImportScenes(ctx context.Context) {
 		scene, err := qb.Create(newScene, tx)
 		if err != nil {
 			_ = tx.Rollback()
-			logger.Errorf("[scenes] <%s> failed to create: %s", mappingJSON.Checksum, err.Error())
+			logger.Errorf("[scenes] <%s> failed to create: %s", sceneHash, err.Error())
+			instance.addJobReportItem(models.JobReportItemTypeError, mappingJSON.Path, err.Error())
 			return
 		}
 		if scene.ID == 0 {
 			_ = tx.Rollback()
-			logger.Errorf("[scenes] <%s> invalid id after scene creation", mappingJSON.Checksum)
+			logger.Errorf("[scenes] <%s> invalid id after scene creation", sceneHash)
+			instance.addJobReportItem(models.JobReportItemTypeError, mappingJSON.Path, "invalid id after scene creation")
 			return
 		}
+		if mappingJSON.Oshash != "" {
+			fqb := models.NewSceneFingerprintQueryBuilder()
+			if err := fqb.Set(scene.ID, models.FingerprintTypeOshash, mappingJSON.Oshash, tx); err != nil {
+				_ = tx.Rollback()
+				logger.Errorf("[scenes] <%s> failed to set oshash: %s", sceneHash, err.Error())
+				instance.addJobReportItem(models.JobReportItemTypeError, mappingJSON.Path, err.Error())
+				return
+			}
+		}
+		added = append(added, mappingJSON.Path)
 
-		// Relate the scene to the gallery
+		// Relate the scene to the galleries. Scenes exported before scenes
+		// had multiple galleries have a single gallery.
+		galleryChecksums := sceneJSON.Galleries
 		if sceneJSON.Gallery != "" {
+			galleryChecksums = append(galleryChecksums, sceneJSON.Gallery)
+		}
+		if len(galleryChecksums) > 0 {
 			gqb := models.NewGalleryQueryBuilder()
-			gallery, err := gqb.FindByChecksum(sceneJSON.Gallery, tx)
-			if err != nil {
-				logger.Warnf("[scenes] gallery <%s> does not exist: %s", sceneJSON.Gallery, err.Error())
-			} else {
-				gallery.SceneID = sql.NullInt64{Int64: int64(scene.ID), Valid: true}
-				_, err := gqb.Update(*gallery, tx)
-				if err != nil {
-					logger.Errorf("[scenes] <%s> failed to update gallery: %s", scene.Checksum, err.Error())
+			var galleryJoins []models.ScenesGalleries
+			for _, checksum := range galleryChecksums {
+				gallery, err := gqb.FindByChecksum(checksum, tx)
+				if err != nil || gallery == nil {
+					logger.Warnf("[scenes] gallery <%s> does not exist", checksum)
+					continue
 				}
+				join := models.ScenesGalleries{
+					SceneID:   scene.ID,
+					GalleryID: gallery.ID,
+				}
+				galleryJoins = append(galleryJoins, join)
+			}
+			if err := jqb.CreateScenesGalleries(galleryJoins, tx); err != nil {
+				logger.Errorf("[scenes] <%s> failed to associate galleries: %s", sceneHash, err.Error())
 			}
 		}
 
@@ -492,7 +765,7 @@ func (t *ImportTask) ImportScenes(ctx context.Context) {
 		if len(sceneJSON.Performers) > 0 {
 			performers, err := t.getPerformers(sceneJSON.Performers, tx)
 			if err != nil {
-				logger.Warnf("[scenes] <%s> failed to fetch performers: %s", scene.Checksum, err.Error())
+				logger.Warnf("[scenes] <%s> failed to fetch performers: %s", sceneHash, err.Error())
 			} else {
 				var performerJoins []models.PerformersScenes
 				for _, performer := range performers {
@@ -503,16 +776,16 @@ func (t *ImportTask) ImportScenes(ctx context.Context) {
 					performerJoins = append(performerJoins, join)
 				}
 				if err := jqb.CreatePerformersScenes(performerJoins, tx); err != nil {
-					logger.Errorf("[scenes] <%s> failed to associate performers: %s", scene.Checksum, err.Error())
+					logger.Errorf("[scenes] <%s> failed to associate performers: %s", sceneHash, err.Error())
 				}
 			}
 		}
 
 		// Relate the scene to the tags
 		if len(sceneJSON.Tags) > 0 {
-			tags, err := t.getTags(scene.Checksum, sceneJSON.Tags, tx)
+			tags, err := t.getTags(sceneHash, sceneJSON.Tags, tx)
 			if err != nil {
-				logger.Warnf("[scenes] <%s> failed to fetch tags: %s", scene.Checksum, err.Error())
+				logger.Warnf("[scenes] <%s> failed to fetch tags: %s", sceneHash, err.Error())
 			} else {
 				var tagJoins []models.ScenesTags
 				for _, tag := range tags {
@@ -523,7 +796,7 @@ func (t *ImportTask) ImportScenes(ctx context.Context) {
 					tagJoins = append(tagJoins, join)
 				}
 				if err := jqb.CreateScenesTags(tagJoins, tx); err != nil {
-					logger.Errorf("[scenes] <%s> failed to associate tags: %s", scene.Checksum, err.Error())
+					logger.Errorf("[scenes] <%s> failed to associate tags: %s", sceneHash, err.Error())
 				}
 			}
 		}
@@ -544,7 +817,7 @@ func (t *ImportTask) ImportScenes(ctx context.Context) {
 
 				primaryTag, err := tqb.FindByName(marker.PrimaryTag, tx)
 				if err != nil {
-					logger.Errorf("[scenes] <%s> failed to find primary tag for marker: %s", scene.Checksum, err.Error())
+					logger.Errorf("[scenes] <%s> failed to find primary tag for marker: %s", sceneHash, err.Error())
 				} else {
 					newSceneMarker.PrimaryTagID = primaryTag.ID
 				}
@@ -552,18 +825,18 @@ func (t *ImportTask) ImportScenes(ctx context.Context) {
 				// Create the scene marker in the DB
 				sceneMarker, err := smqb.Create(newSceneMarker, tx)
 				if err != nil {
-					logger.Warnf("[scenes] <%s> failed to create scene marker: %s", scene.Checksum, err.Error())
+					logger.Warnf("[scenes] <%s> failed to create scene marker: %s", sceneHash, err.Error())
 					continue
 				}
 				if sceneMarker.ID == 0 {
-					logger.Warnf("[scenes] <%s> invalid scene marker id after scene marker creation", scene.Checksum)
+					logger.Warnf("[scenes] <%s> invalid scene marker id after scene marker creation", sceneHash)
 					continue
 				}
 
 				// Get the scene marker tags and create the joins
-				tags, err := t.getTags(scene.Checksum, marker.Tags, tx)
+				tags, err := t.getTags(sceneHash, marker.Tags, tx)
 				if err != nil {
-					logger.Warnf("[scenes] <%s> failed to fetch scene marker tags: %s", scene.Checksum, err.Error())
+					logger.Warnf("[scenes] <%s> failed to fetch scene marker tags: %s", sceneHash, err.Error())
 				} else {
 					var tagJoins []models.SceneMarkersTags
 					for _, tag := range tags {
@@ -574,7 +847,7 @@ func (t *ImportTask) ImportScenes(ctx context.Context) {
 						tagJoins = append(tagJoins, join)
 					}
 					if err := jqb.CreateSceneMarkersTags(tagJoins, tx); err != nil {
-						logger.Errorf("[scenes] <%s> failed to associate scene marker tags: %s", scene.Checksum, err.Error())
+						logger.Errorf("[scenes] <%s> failed to associate scene marker tags: %s", sceneHash, err.Error())
 					}
 				}
 			}
@@ -584,10 +857,38 @@ func (t *ImportTask) ImportScenes(ctx context.Context) {
 	logger.Info("[scenes] importing")
 	if err := tx.Commit(); err != nil {
 		logger.Errorf("[scenes] import failed to commit: %s", err.Error())
+		instance.addJobReportItem(models.JobReportItemTypeError, "", "scenes import failed to commit: "+err.Error())
+	} else {
+		t.reportAdded(added)
 	}
 	logger.Info("[scenes] import complete")
 }
 
+// reportAdded adds the imported items to the job report.
+func (t *ImportTask) reportAdded(names []string) {
+	for _, name := range names {
+		instance.addJobReportItem(models.JobReportItemTypeAdded, name, "")
+	}
+}
+
+// imageName returns the path of the image, or its checksum if it is only in
+// galleries.
+func imageName(mapping jsonschema.PathMapping) string {
+	if mapping.Path != "" {
+		return mapping.Path
+	}
+	return mapping.Checksum
+}
+
+// getSceneMappingHash returns the hash that names the json file of the
+// scene: its checksum, or its oshash if it has no checksum.
+func getSceneMappingHash(mapping jsonschema.PathMapping) string {
+	if mapping.Checksum != "" {
+		return mapping.Checksum
+	}
+	return mapping.Oshash
+}
+
 func (t *ImportTask) getPerformers(names []string, tx *sqlx.Tx) ([]*models.Performer, error) {
 	pqb := models.NewPerformerQueryBuilder()
 	performers, err := pqb.FindByNames(names, tx)
@@ -614,7 +915,7 @@ func (t *ImportTask) getPerformers(names []string, tx *sqlx.Tx) ([]*models.Perfo
 	return performers, nil
 }
 
-func (t *ImportTask) getTags(sceneChecksum string, names []string, tx *sqlx.Tx) ([]*models.Tag, error) {
+func (t *ImportTask) getTags(sceneHash string, names []string, tx *sqlx.Tx) ([]*models.Tag, error) {
 	tqb := models.NewTagQueryBuilder()
 	tags, err := tqb.FindByNames(names, tx)
 	if err != nil {
@@ -634,7 +935,7 @@ func (t *ImportTask) getTags(sceneChecksum string, names []string, tx *sqlx.Tx)
 	})
 
 	for _, missingTag := range missingTags {
-		logger.Warnf("[scenes] <%s> tag %s does not exist", sceneChecksum, missingTag)
+		logger.Warnf("[scenes] <%s> tag %s does not exist", sceneHash, missingTag)
 	}
 
 	return tags, nil
@@ -0,0 +1,43 @@
+package manager
+
+import (
+	"github.com/stashapp/stash/pkg/models"
+)
+
+type MigrateHashTask struct {
+	Scene models.Scene
+}
+
+// Start renames the generated files of the scene that are named with the
+// hash that the scene file naming hash setting no longer chooses. Scenes
+// without both an MD5 checksum and an oshash are named with the hash they
+// have, so they have nothing to rename.
+func (t *MigrateHashTask) Start() error {
+	if !t.Scene.Checksum.Valid {
+		return nil
+	}
+
+	fqb := models.NewSceneFingerprintQueryBuilder()
+	fingerprint, err := fqb.Find(t.Scene.ID, models.FingerprintTypeOshash, nil)
+	if err != nil || fingerprint == nil {
+		return err
+	}
+
+	checksum := t.Scene.Checksum.String
+	oshash := fingerprint.Fingerprint
+	newHash := selectSceneHash(checksum, oshash)
+	oldHash := checksum
+	if newHash == checksum {
+		oldHash = oshash
+	}
+
+	renamed, err := renameGeneratedSceneFiles(oldHash, newHash)
+	if err != nil {
+		return err
+	}
+
+	if renamed {
+		instance.addJobReportItem(models.JobReportItemTypeModified, t.Scene.Path, "Renamed generated files from "+oldHash+" to "+newHash)
+	}
+	return nil
+}
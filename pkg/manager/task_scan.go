@@ -1,49 +1,161 @@
 package manager
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
-	"path/filepath"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/stashapp/stash/pkg/database"
 	"github.com/stashapp/stash/pkg/ffmpeg"
 	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/utils"
 )
 
+// scanMutex serialises the database updates of concurrently running scan
+// tasks. SQLite only allows a single writer, and holding the lock while
+// checking for existing checksums prevents duplicates being created.
+var scanMutex sync.Mutex
+
 type ScanTask struct {
 	FilePath        string
 	UseFileMetadata bool
+
+	// GeneratePreview and GenerateSprite generate the preview and sprite
+	// of the scene if it is newly added.
+	GeneratePreview bool
+	GenerateSprite  bool
+
+	size     string
+	modTime  models.NullSQLiteTimestamp
+	newScene *models.Scene
+	// folder is true if the path is a directory scanned as a folder gallery
+	folder bool
+	// scanGalleryImages is the gallery whose images are read after it is
+	// scanned
+	scanGalleryImages *models.Gallery
 }
 
-func (t *ScanTask) Start(wg *sync.WaitGroup) {
-	if filepath.Ext(t.FilePath) == ".zip" {
-		t.scanGallery()
-	} else {
-		t.scanScene()
+func (t *ScanTask) Start() error {
+	info, err := os.Stat(t.FilePath)
+	if err != nil {
+		return err
+	}
+
+	// modification times are stored to the nearest second
+	t.size = strconv.FormatInt(info.Size(), 10)
+	t.modTime = models.NullSQLiteTimestamp{Timestamp: info.ModTime().Truncate(time.Second), Valid: true}
+
+	if info.IsDir() {
+		t.folder = true
+		size, err := getFolderGallerySize(t.FilePath)
+		if err != nil {
+			return err
+		}
+		t.size = strconv.FormatInt(size, 10)
+	}
+
+	if t.folder || isGallery(t.FilePath) {
+		if err := t.scanGallery(); err != nil {
+			return err
+		}
+
+		if t.scanGalleryImages != nil {
+			return t.readGalleryImages(t.scanGalleryImages)
+		}
+		return nil
+	}
+
+	if models.IsImage(t.FilePath) {
+		return t.scanImage()
+	}
+
+	if err := t.scanScene(); err != nil {
+		return err
 	}
 
-	wg.Done()
+	if t.newScene != nil {
+		return t.generateNewScene()
+	}
+
+	return nil
 }
 
-func (t *ScanTask) scanGallery() {
+// generateNewScene generates the requested files for a newly added scene.
+func (t *ScanTask) generateNewScene() error {
+	if skipGenerate(t.FilePath) {
+		return nil
+	}
+
+	if t.GeneratePreview {
+		task := GeneratePreviewTask{Scene: *t.newScene, Options: GetPreviewOptions(nil)}
+		if err := task.Start(); err != nil {
+			return err
+		}
+	}
+
+	if t.GenerateSprite {
+		task := GenerateSpriteTask{Scene: *t.newScene}
+		if err := task.Start(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fileUnchanged returns true if the stored size and modification time
+// match the file being scanned.
+func (t *ScanTask) fileUnchanged(size sql.NullString, modTime models.NullSQLiteTimestamp) bool {
+	return size.String == t.size && modTime.Valid && modTime.Timestamp.Equal(t.modTime.Timestamp)
+}
+
+func (t *ScanTask) scanGallery() error {
 	qb := models.NewGalleryQueryBuilder()
 	gallery, _ := qb.FindByPath(t.FilePath)
 	if gallery != nil {
-		// We already have this item in the database, keep going
-		return
+		if !t.fileUnchanged(gallery.Size, gallery.FileModTime) {
+			if err := t.rescanGallery(gallery); err != nil {
+				return err
+			}
+		} else if t.folder && gallery.Checksum == utils.MD5FromString(t.FilePath) {
+			// folder galleries were identified by their path
+			if err := t.updateFolderGalleryChecksum(gallery); err != nil {
+				return err
+			}
+		}
+
+		// galleries scanned before their images were stored are read once
+		corrupt := gallery.Corrupt.Valid && gallery.Corrupt.Bool
+		if t.scanGalleryImages == nil && !corrupt && !t.hasGalleryImages(gallery) {
+			t.scanGalleryImages = gallery
+		}
+		return nil
 	}
 
-	checksum, err := t.calculateChecksum()
+	// look for a missing gallery with the same size and modification time
+	// before falling back to the checksum
+	if moved := t.findMovedGallery(); moved != nil {
+		return t.updateGalleryPath(moved)
+	}
+
+	checksum, err := t.calculateGalleryChecksum()
 	if err != nil {
-		logger.Error(err.Error())
-		return
+		return err
 	}
 
+	scanMutex.Lock()
+	defer scanMutex.Unlock()
+
 	ctx := context.TODO()
 	tx := database.DB.MustBeginTx(ctx, nil)
 	gallery, _ = qb.FindByChecksum(checksum, tx)
@@ -51,45 +163,335 @@ func (t *ScanTask) scanGallery() {
 		exists, _ := utils.FileExists(gallery.Path)
 		if exists {
 			logger.Infof("%s already exists.  Duplicate of %s ", t.FilePath, gallery.Path)
+			instance.addJobReportItem(models.JobReportItemTypeDuplicate, t.FilePath, "Duplicate of "+gallery.Path)
 		} else {
 
 			logger.Infof("%s already exists.  Updating path...", t.FilePath)
+			instance.addJobReportItem(models.JobReportItemTypeMoved, t.FilePath, "Moved from "+gallery.Path)
 			gallery.Path = t.FilePath
+			gallery.Size = sql.NullString{String: t.size, Valid: true}
+			gallery.FileModTime = t.modTime
 			_, err = qb.Update(*gallery, tx)
 		}
 	} else {
 		logger.Infof("%s doesn't exist.  Creating new item...", t.FilePath)
+		instance.addJobReportItem(models.JobReportItemTypeAdded, t.FilePath, "")
 		currentTime := time.Now()
 		newGallery := models.Gallery{
-			Checksum:  checksum,
-			Path:      t.FilePath,
-			CreatedAt: models.SQLiteTimestamp{Timestamp: currentTime},
-			UpdatedAt: models.SQLiteTimestamp{Timestamp: currentTime},
+			Checksum:    checksum,
+			Path:        t.FilePath,
+			Size:        sql.NullString{String: t.size, Valid: true},
+			FileModTime: t.modTime,
+			CreatedAt:   models.SQLiteTimestamp{Timestamp: currentTime},
+			UpdatedAt:   models.SQLiteTimestamp{Timestamp: currentTime},
+		}
+		t.scanGalleryImages, err = qb.Create(newGallery, tx)
+	}
+
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// rescanGallery updates a gallery whose file has changed since it was last
+// scanned. Galleries scanned before the modification time was stored are
+// assumed to be unchanged.
+func (t *ScanTask) rescanGallery(gallery *models.Gallery) error {
+	updatedGallery := models.Gallery{
+		ID:          gallery.ID,
+		Size:        sql.NullString{String: t.size, Valid: true},
+		FileModTime: t.modTime,
+	}
+
+	modified := gallery.FileModTime.Valid
+	if modified {
+		logger.Infof("%s has been modified.  Updating...", t.FilePath)
+		checksum, err := t.calculateGalleryChecksum()
+		if err != nil {
+			return err
+		}
+		updatedGallery.Checksum = checksum
+		updatedGallery.UpdatedAt = models.SQLiteTimestamp{Timestamp: time.Now()}
+	}
+
+	// the stored images are out of date if the file has changed. The images
+	// of galleries scanned before the modification time was stored are
+	// checked once, so that the stored names and checksums can be trusted
+	// until the file changes.
+	corrupt := gallery.Corrupt.Valid && gallery.Corrupt.Bool
+	imagesChanged := modified || (!corrupt && !galleryImagesMatch(*gallery))
+
+	scanMutex.Lock()
+	defer scanMutex.Unlock()
+
+	qb := models.NewGalleryQueryBuilder()
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+
+	if updatedGallery.Checksum != "" && updatedGallery.Checksum != gallery.Checksum {
+		existing, _ := qb.FindByChecksum(updatedGallery.Checksum, tx)
+		if existing != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("modified file is a duplicate of %s", existing.Path)
+		}
+	}
+
+	if _, err := qb.Update(updatedGallery, tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	// the thumbnails of out of date images are regenerated
+	if imagesChanged {
+		giqb := models.NewGalleryImageQueryBuilder()
+		if err := giqb.DestroyByGalleryID(gallery.ID, tx); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if modified {
+		instance.addJobReportItem(models.JobReportItemTypeModified, t.FilePath, "")
+	}
+	if imagesChanged {
+		t.scanGalleryImages = gallery
+	}
+	return nil
+}
+
+// updateFolderGalleryChecksum replaces the path checksum of a folder gallery
+// with the checksum of its contents.
+func (t *ScanTask) updateFolderGalleryChecksum(gallery *models.Gallery) error {
+	checksum, err := t.calculateGalleryChecksum()
+	if err != nil {
+		return err
+	}
+
+	scanMutex.Lock()
+	defer scanMutex.Unlock()
+
+	qb := models.NewGalleryQueryBuilder()
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+	if existing, _ := qb.FindByChecksum(checksum, tx); existing != nil {
+		// keep the path checksum of a duplicate folder
+		_ = tx.Rollback()
+		return nil
+	}
+
+	if _, err := qb.Update(models.Gallery{ID: gallery.ID, Checksum: checksum}, tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// hasGalleryImages returns true if the images of the gallery are stored.
+func (t *ScanTask) hasGalleryImages(gallery *models.Gallery) bool {
+	qb := models.NewGalleryImageQueryBuilder()
+	images, err := qb.FindByGalleryID(gallery.ID, nil)
+	return err == nil && len(images) > 0
+}
+
+// readGalleryImages stores the images of the gallery, creating the image
+// objects of images that have not been seen before.
+func (t *ScanTask) readGalleryImages(gallery *models.Gallery) error {
+	images, err := readGalleryImages(*gallery, nil)
+	if err != nil {
+		return err
+	}
+	return saveGalleryImages(gallery.ID, images)
+}
+
+// scanImage scans a loose image file. Images are identified by their
+// checksum, so an image file with the contents of an image in a gallery
+// sets the path of that image.
+func (t *ScanTask) scanImage() error {
+	qb := models.NewImageQueryBuilder()
+	existing, _ := qb.FindByPath(t.FilePath)
+	if existing != nil && t.fileUnchanged(existing.Size, existing.FileModTime) {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(t.FilePath)
+	if err != nil {
+		return err
+	}
+	checksum := utils.MD5FromBytes(data)
+
+	var width, height sql.NullInt64
+	if imageConfig, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		width = sql.NullInt64{Int64: int64(imageConfig.Width), Valid: true}
+		height = sql.NullInt64{Int64: int64(imageConfig.Height), Valid: true}
+	} else {
+		logger.Warnf("error decoding %s: %s", t.FilePath, err.Error())
+	}
+
+	scanMutex.Lock()
+	defer scanMutex.Unlock()
+
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+	size := sql.NullString{String: t.size, Valid: true}
+	updatedTime := models.SQLiteTimestamp{Timestamp: time.Now()}
+	sameImage, _ := qb.FindByChecksum(checksum, tx)
+
+	switch {
+	case existing != nil && (sameImage == nil || sameImage.ID == existing.ID):
+		if existing.Checksum != checksum {
+			logger.Infof("%s has been modified.  Updating...", t.FilePath)
+			instance.addJobReportItem(models.JobReportItemTypeModified, t.FilePath, "")
+		}
+		imagePartial := models.ImagePartial{
+			ID:          existing.ID,
+			Checksum:    &checksum,
+			Size:        &size,
+			Width:       &width,
+			Height:      &height,
+			FileModTime: &t.modTime,
+			UpdatedAt:   &updatedTime,
 		}
-		_, err = qb.Create(newGallery, tx)
+		_, err = qb.Update(imagePartial, tx)
+	case existing != nil:
+		_ = tx.Rollback()
+		return fmt.Errorf("modified file is a duplicate of image %d", sameImage.ID)
+	case sameImage != nil && sameImage.Path.Valid:
+		if exists, _ := utils.FileExists(sameImage.Path.String); exists {
+			_ = tx.Rollback()
+			logger.Infof("%s already exists.  Duplicate of %s ", t.FilePath, sameImage.Path.String)
+			instance.addJobReportItem(models.JobReportItemTypeDuplicate, t.FilePath, "Duplicate of "+sameImage.Path.String)
+			return nil
+		}
+		fallthrough
+	case sameImage != nil:
+		if sameImage.Path.Valid {
+			logger.Infof("%s already exists.  Updating path...", t.FilePath)
+			instance.addJobReportItem(models.JobReportItemTypeMoved, t.FilePath, "Moved from "+sameImage.Path.String)
+		} else {
+			instance.addJobReportItem(models.JobReportItemTypeAdded, t.FilePath, "")
+		}
+		path := sql.NullString{String: t.FilePath, Valid: true}
+		imagePartial := models.ImagePartial{
+			ID:          sameImage.ID,
+			Path:        &path,
+			Size:        &size,
+			FileModTime: &t.modTime,
+			UpdatedAt:   &updatedTime,
+		}
+		_, err = qb.Update(imagePartial, tx)
+	default:
+		logger.Infof("%s doesn't exist.  Creating new item...", t.FilePath)
+		instance.addJobReportItem(models.JobReportItemTypeAdded, t.FilePath, "")
+		newImage := models.Image{
+			Path:        sql.NullString{String: t.FilePath, Valid: true},
+			Checksum:    checksum,
+			Size:        size,
+			Width:       width,
+			Height:      height,
+			FileModTime: t.modTime,
+			CreatedAt:   updatedTime,
+			UpdatedAt:   updatedTime,
+		}
+		_, err = qb.Create(newImage, tx)
 	}
 
 	if err != nil {
-		logger.Error(err.Error())
 		_ = tx.Rollback()
-	} else if err := tx.Commit(); err != nil {
-		logger.Error(err.Error())
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// findMovedGallery returns the gallery with the same size and modification
+// time as the file, if there is exactly one and its file no longer exists.
+func (t *ScanTask) findMovedGallery() *models.Gallery {
+	qb := models.NewGalleryQueryBuilder()
+	galleries, _ := qb.FindBySize(t.size)
+
+	var ret *models.Gallery
+	for _, gallery := range galleries {
+		if !t.fileUnchanged(gallery.Size, gallery.FileModTime) {
+			continue
+		}
+		if exists, _ := utils.FileExists(gallery.Path); exists {
+			continue
+		}
+		if ret != nil {
+			return nil
+		}
+		ret = gallery
+	}
+
+	return ret
+}
+
+func (t *ScanTask) updateGalleryPath(gallery *models.Gallery) error {
+	scanMutex.Lock()
+	defer scanMutex.Unlock()
+
+	logger.Infof("%s has been moved from %s.  Updating path...", t.FilePath, gallery.Path)
+
+	qb := models.NewGalleryQueryBuilder()
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+	updatedGallery := models.Gallery{
+		ID:        gallery.ID,
+		Path:      t.FilePath,
+		UpdatedAt: models.SQLiteTimestamp{Timestamp: time.Now()},
+	}
+	if _, err := qb.Update(updatedGallery, tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
 	}
+
+	instance.addJobReportItem(models.JobReportItemTypeMoved, t.FilePath, "Moved from "+gallery.Path)
+	return nil
 }
 
-func (t *ScanTask) scanScene() {
+func (t *ScanTask) scanScene() error {
 	qb := models.NewSceneQueryBuilder()
 	scene, _ := qb.FindByPath(t.FilePath)
 	if scene != nil {
+		if !t.fileUnchanged(scene.Size, scene.FileModTime) {
+			var err error
+			scene, err = t.rescanScene(scene)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := t.ensureOshash(scene); err != nil {
+			return err
+		}
+
+		if err := t.ensureChecksum(scene); err != nil {
+			return err
+		}
+
 		// We already have this item in the database, check for thumbnails,screenshots
-		t.makeScreenshots(nil, scene.Checksum)
-		return
+		return t.makeScreenshots(nil, GetSceneHash(scene))
+	}
+
+	// look for a missing scene with the same size and modification time
+	// before falling back to the checksum
+	if moved := t.findMovedScene(); moved != nil {
+		if err := t.updateScenePath(moved); err != nil {
+			return err
+		}
+		return t.makeScreenshots(nil, GetSceneHash(moved))
 	}
 
 	videoFile, err := ffmpeg.NewVideoFile(instance.FFProbePath, t.FilePath)
 	if err != nil {
-		logger.Error(err.Error())
-		return
+		return err
 	}
 
 	// Override title to be filename if UseFileMetadata is false
@@ -97,73 +499,391 @@ func (t *ScanTask) scanScene() {
 		videoFile.SetTitleFromPath()
 	}
 
-	checksum, err := t.calculateChecksum()
+	checksum := ""
+	if config.IsCalculateMD5() {
+		checksum, err = t.calculateChecksum()
+		if err != nil {
+			return err
+		}
+	}
+
+	oshash, err := t.calculateOshash()
 	if err != nil {
-		logger.Error(err.Error())
-		return
+		return err
+	}
+
+	if err := t.makeScreenshots(videoFile, selectSceneHash(checksum, oshash)); err != nil {
+		return err
 	}
 
-	t.makeScreenshots(videoFile, checksum)
+	scanMutex.Lock()
+	defer scanMutex.Unlock()
 
-	scene, _ = qb.FindByChecksum(checksum)
+	fqb := models.NewSceneFingerprintQueryBuilder()
+	sameScenes, _ := findSameScenes(checksum, oshash)
 	ctx := context.TODO()
 	tx := database.DB.MustBeginTx(ctx, nil)
+
+	// a scene with the same hash whose file is missing has been moved.
+	// Otherwise the file is a duplicate, which is added as a scene of its
+	// own so that it can be found and merged.
+	scene = nil
+	for _, sameScene := range sameScenes {
+		if exists, _ := utils.FileExists(sameScene.Path); !exists {
+			scene = sameScene
+			break
+		}
+	}
+
+	oldHash := ""
+	newHash := ""
 	if scene != nil {
-		exists, _ := utils.FileExists(scene.Path)
-		if exists {
-			logger.Infof("%s already exists.  Duplicate of %s ", t.FilePath, scene.Path)
-		} else {
-			logger.Infof("%s already exists.  Updating path...", t.FilePath)
-			scenePartial := models.ScenePartial{
-				ID:   scene.ID,
-				Path: &t.FilePath,
-			}
-			_, err = qb.Update(scenePartial, tx)
+		logger.Infof("%s already exists.  Updating path...", t.FilePath)
+		instance.addJobReportItem(models.JobReportItemTypeMoved, t.FilePath, "Moved from "+scene.Path)
+		oldHash = GetSceneHash(scene)
+		size := sql.NullString{String: t.size, Valid: true}
+		scenePartial := models.ScenePartial{
+			ID:          scene.ID,
+			Path:        &t.FilePath,
+			Size:        &size,
+			FileModTime: &t.modTime,
+		}
+		// scenes found by their oshash may not have a checksum
+		sceneChecksum := scene.Checksum.String
+		if checksum != "" {
+			scenePartial.Checksum = &sql.NullString{String: checksum, Valid: true}
+			sceneChecksum = checksum
+		}
+		newHash = selectSceneHash(sceneChecksum, oshash)
+		_, err = qb.Update(scenePartial, tx)
+		if err == nil {
+			err = fqb.Set(scene.ID, models.FingerprintTypeOshash, oshash, tx)
 		}
 	} else {
-		logger.Infof("%s doesn't exist.  Creating new item...", t.FilePath)
+		if len(sameScenes) > 0 {
+			logger.Infof("%s already exists.  Duplicate of %s ", t.FilePath, sameScenes[0].Path)
+			instance.addJobReportItem(models.JobReportItemTypeDuplicate, t.FilePath, "Duplicate of "+sameScenes[0].Path)
+		} else {
+			logger.Infof("%s doesn't exist.  Creating new item...", t.FilePath)
+			instance.addJobReportItem(models.JobReportItemTypeAdded, t.FilePath, "")
+		}
 		currentTime := time.Now()
 		newScene := models.Scene{
-			Checksum:   checksum,
-			Path:       t.FilePath,
-			Title:      sql.NullString{String: videoFile.Title, Valid: true},
-			Duration:   sql.NullFloat64{Float64: videoFile.Duration, Valid: true},
-			VideoCodec: sql.NullString{String: videoFile.VideoCodec, Valid: true},
-			AudioCodec: sql.NullString{String: videoFile.AudioCodec, Valid: true},
-			Width:      sql.NullInt64{Int64: int64(videoFile.Width), Valid: true},
-			Height:     sql.NullInt64{Int64: int64(videoFile.Height), Valid: true},
-			Framerate:  sql.NullFloat64{Float64: videoFile.FrameRate, Valid: true},
-			Bitrate:    sql.NullInt64{Int64: videoFile.Bitrate, Valid: true},
-			Size:       sql.NullString{String: strconv.Itoa(int(videoFile.Size)), Valid: true},
-			CreatedAt:  models.SQLiteTimestamp{Timestamp: currentTime},
-			UpdatedAt:  models.SQLiteTimestamp{Timestamp: currentTime},
+			Checksum:    sql.NullString{String: checksum, Valid: checksum != ""},
+			Path:        t.FilePath,
+			Title:       sql.NullString{String: videoFile.Title, Valid: true},
+			Duration:    sql.NullFloat64{Float64: videoFile.Duration, Valid: true},
+			VideoCodec:  sql.NullString{String: videoFile.VideoCodec, Valid: true},
+			AudioCodec:  sql.NullString{String: videoFile.AudioCodec, Valid: true},
+			Format:      sql.NullString{String: string(ffmpeg.MatchContainer(videoFile.Container, t.FilePath)), Valid: true},
+			Width:       sql.NullInt64{Int64: int64(videoFile.Width), Valid: true},
+			Height:      sql.NullInt64{Int64: int64(videoFile.Height), Valid: true},
+			Framerate:   sql.NullFloat64{Float64: videoFile.FrameRate, Valid: true},
+			Bitrate:     sql.NullInt64{Int64: videoFile.Bitrate, Valid: true},
+			Size:        sql.NullString{String: strconv.Itoa(int(videoFile.Size)), Valid: true},
+			FileModTime: t.modTime,
+			CreatedAt:   models.SQLiteTimestamp{Timestamp: currentTime},
+			UpdatedAt:   models.SQLiteTimestamp{Timestamp: currentTime},
 		}
 
 		if t.UseFileMetadata {
 			newScene.Details = sql.NullString{String: videoFile.Comment, Valid: true}
 			newScene.Date = models.SQLiteDate{String: videoFile.CreationTime.Format("2006-01-02")}
 		}
-		_, err = qb.Create(newScene, tx)
+
+		t.newScene, err = qb.Create(newScene, tx)
+		if err == nil {
+			err = fqb.Set(t.newScene.ID, models.FingerprintTypeOshash, oshash, tx)
+		}
+	}
+
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if newHash != oldHash {
+		if _, err := renameGeneratedSceneFiles(oldHash, newHash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findSameScenes returns the scenes with the same file contents: the scenes
+// with the checksum, and the scenes with the oshash that have no checksum to
+// compare. Only the oshash is compared if the checksum was not calculated.
+func findSameScenes(checksum string, oshash string) ([]*models.Scene, error) {
+	qb := models.NewSceneQueryBuilder()
+	var ret []*models.Scene
+	if checksum != "" {
+		scenes, err := qb.FindAllByChecksum(checksum)
+		if err != nil {
+			return nil, err
+		}
+		ret = scenes
+	}
+
+	fqb := models.NewSceneFingerprintQueryBuilder()
+	fingerprints, err := fqb.FindByFingerprint(models.FingerprintTypeOshash, oshash, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fingerprint := range fingerprints {
+		scene, err := qb.Find(fingerprint.SceneID)
+		if err != nil {
+			return nil, err
+		}
+		if scene != nil && (checksum == "" || !scene.Checksum.Valid) {
+			ret = append(ret, scene)
+		}
+	}
+
+	return ret, nil
+}
+
+// rescanScene updates a scene whose file has changed since it was last
+// scanned, probing and hashing the file again. Scenes scanned before the
+// modification time was stored are assumed to be unchanged. It returns the
+// updated scene.
+func (t *ScanTask) rescanScene(scene *models.Scene) (*models.Scene, error) {
+	size := sql.NullString{String: t.size, Valid: true}
+	scenePartial := models.ScenePartial{
+		ID:          scene.ID,
+		Size:        &size,
+		FileModTime: &t.modTime,
+	}
+
+	// generated files are named after the hash of the old contents
+	oldHash := GetSceneHash(scene)
+	var oshash string
+
+	modified := scene.FileModTime.Valid
+	if modified {
+		logger.Infof("%s has been modified.  Updating...", t.FilePath)
+		videoFile, err := ffmpeg.NewVideoFile(instance.FFProbePath, t.FilePath)
+		if err != nil {
+			return nil, err
+		}
+
+		// a checksum that is not calculated is cleared, since it no
+		// longer matches the file
+		checksum := sql.NullString{}
+		if config.IsCalculateMD5() {
+			checksum.String, err = t.calculateChecksum()
+			if err != nil {
+				return nil, err
+			}
+			checksum.Valid = true
+		}
+
+		oshash, err = t.calculateOshash()
+		if err != nil {
+			return nil, err
+		}
+
+		duration := sql.NullFloat64{Float64: videoFile.Duration, Valid: true}
+		videoCodec := sql.NullString{String: videoFile.VideoCodec, Valid: true}
+		audioCodec := sql.NullString{String: videoFile.AudioCodec, Valid: true}
+		format := sql.NullString{String: string(ffmpeg.MatchContainer(videoFile.Container, t.FilePath)), Valid: true}
+		width := sql.NullInt64{Int64: int64(videoFile.Width), Valid: true}
+		height := sql.NullInt64{Int64: int64(videoFile.Height), Valid: true}
+		framerate := sql.NullFloat64{Float64: videoFile.FrameRate, Valid: true}
+		bitrate := sql.NullInt64{Int64: videoFile.Bitrate, Valid: true}
+		updatedTime := models.SQLiteTimestamp{Timestamp: time.Now()}
+
+		scenePartial.Checksum = &checksum
+		scenePartial.Duration = &duration
+		scenePartial.VideoCodec = &videoCodec
+		scenePartial.AudioCodec = &audioCodec
+		scenePartial.Format = &format
+		scenePartial.Width = &width
+		scenePartial.Height = &height
+		scenePartial.Framerate = &framerate
+		scenePartial.Bitrate = &bitrate
+		scenePartial.UpdatedAt = &updatedTime
+	}
+
+	scanMutex.Lock()
+	defer scanMutex.Unlock()
+
+	qb := models.NewSceneQueryBuilder()
+	if modified {
+		sameScenes, _ := findSameScenes(scenePartial.Checksum.String, oshash)
+		for _, existing := range sameScenes {
+			if existing.ID != scene.ID {
+				logger.Infof("%s is now a duplicate of %s", t.FilePath, existing.Path)
+				instance.addJobReportItem(models.JobReportItemTypeDuplicate, t.FilePath, "Duplicate of "+existing.Path)
+				break
+			}
+		}
 	}
 
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+	updatedScene, err := qb.Update(scenePartial, tx)
 	if err != nil {
-		logger.Error(err.Error())
 		_ = tx.Rollback()
-	} else if err := tx.Commit(); err != nil {
-		logger.Error(err.Error())
+		return nil, err
+	}
+
+	if modified {
+		fqb := models.NewSceneFingerprintQueryBuilder()
+		if err := fqb.Set(scene.ID, models.FingerprintTypeOshash, oshash, tx); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if !modified {
+		return updatedScene, nil
 	}
+
+	invalidateHLSStreams(oldHash)
+	if newHash := selectSceneHash(scenePartial.Checksum.String, oshash); newHash != oldHash {
+		DeleteGeneratedSceneFiles(oldHash)
+	}
+	instance.addJobReportItem(models.JobReportItemTypeModified, t.FilePath, "")
+	return updatedScene, nil
 }
 
-func (t *ScanTask) makeScreenshots(probeResult *ffmpeg.VideoFile, checksum string) {
-	thumbPath := instance.Paths.Scene.GetThumbnailScreenshotPath(checksum)
-	normalPath := instance.Paths.Scene.GetScreenshotPath(checksum)
+// ensureOshash calculates and stores the oshash of a scene scanned before
+// oshashes were stored.
+func (t *ScanTask) ensureOshash(scene *models.Scene) error {
+	fqb := models.NewSceneFingerprintQueryBuilder()
+	if fingerprint, _ := fqb.Find(scene.ID, models.FingerprintTypeOshash, nil); fingerprint != nil {
+		return nil
+	}
+
+	oshash, err := t.calculateOshash()
+	if err != nil {
+		return err
+	}
+
+	scanMutex.Lock()
+	defer scanMutex.Unlock()
+
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+	if err := fqb.Set(scene.ID, models.FingerprintTypeOshash, oshash, tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ensureChecksum calculates and stores the MD5 checksum of a scene scanned
+// while checksums were not calculated. Generated files named with the oshash
+// are renamed if the checksum names them instead.
+func (t *ScanTask) ensureChecksum(scene *models.Scene) error {
+	if scene.Checksum.Valid || !config.IsCalculateMD5() {
+		return nil
+	}
+
+	checksum, err := t.calculateChecksum()
+	if err != nil {
+		return err
+	}
+
+	oldHash := GetSceneHash(scene)
+
+	scanMutex.Lock()
+	defer scanMutex.Unlock()
+
+	qb := models.NewSceneQueryBuilder()
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+	scenePartial := models.ScenePartial{
+		ID:       scene.ID,
+		Checksum: &sql.NullString{String: checksum, Valid: true},
+	}
+	if _, err := qb.Update(scenePartial, tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	scene.Checksum = *scenePartial.Checksum
+	if newHash := GetSceneHash(scene); newHash != oldHash {
+		if _, err := renameGeneratedSceneFiles(oldHash, newHash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findMovedScene returns the scene with the same size and modification
+// time as the file, if there is exactly one and its file no longer exists.
+func (t *ScanTask) findMovedScene() *models.Scene {
+	qb := models.NewSceneQueryBuilder()
+	scenes, _ := qb.FindBySize(t.size)
+
+	var ret *models.Scene
+	for _, scene := range scenes {
+		if !t.fileUnchanged(scene.Size, scene.FileModTime) {
+			continue
+		}
+		if exists, _ := utils.FileExists(scene.Path); exists {
+			continue
+		}
+		if ret != nil {
+			return nil
+		}
+		ret = scene
+	}
+
+	return ret
+}
+
+func (t *ScanTask) updateScenePath(scene *models.Scene) error {
+	scanMutex.Lock()
+	defer scanMutex.Unlock()
+
+	logger.Infof("%s has been moved from %s.  Updating path...", t.FilePath, scene.Path)
+
+	qb := models.NewSceneQueryBuilder()
+	tx := database.DB.MustBeginTx(context.TODO(), nil)
+	updatedTime := models.SQLiteTimestamp{Timestamp: time.Now()}
+	scenePartial := models.ScenePartial{
+		ID:        scene.ID,
+		Path:      &t.FilePath,
+		UpdatedAt: &updatedTime,
+	}
+	if _, err := qb.Update(scenePartial, tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	instance.addJobReportItem(models.JobReportItemTypeMoved, t.FilePath, "Moved from "+scene.Path)
+	return nil
+}
+
+func (t *ScanTask) makeScreenshots(probeResult *ffmpeg.VideoFile, sceneHash string) error {
+	defer lockSceneHash(sceneHash)()
+
+	thumbPath := instance.Paths.Scene.GetThumbnailScreenshotPath(sceneHash)
+	normalPath := instance.Paths.Scene.GetScreenshotPath(sceneHash)
 
 	thumbExists, _ := utils.FileExists(thumbPath)
 	normalExists, _ := utils.FileExists(normalPath)
 
 	if thumbExists && normalExists {
 		logger.Debug("Screenshots already exist for this path... skipping")
-		return
+		return nil
 	}
 
 	if probeResult == nil {
@@ -171,32 +891,49 @@ func (t *ScanTask) makeScreenshots(probeResult *ffmpeg.VideoFile, checksum strin
 		probeResult, err = ffmpeg.NewVideoFile(instance.FFProbePath, t.FilePath)
 
 		if err != nil {
-			logger.Error(err.Error())
-			return
+			return err
 		}
 		logger.Infof("Regenerating images for %s", t.FilePath)
 	}
 
 	if !thumbExists {
 		logger.Debugf("Creating thumbnail for %s", t.FilePath)
-		t.makeScreenshot(*probeResult, thumbPath, 5, 320)
+		if err := t.makeScreenshot(*probeResult, thumbPath, 5, 320); err != nil {
+			if instance.Status.isStopping() {
+				return err
+			}
+			logger.Warnf("Error creating screenshot for %s: %s", t.FilePath, err.Error())
+		}
 	}
 
 	if !normalExists {
 		logger.Debugf("Creating screenshot for %s", t.FilePath)
-		t.makeScreenshot(*probeResult, normalPath, 2, probeResult.Width)
+		if err := t.makeScreenshot(*probeResult, normalPath, 2, probeResult.Width); err != nil {
+			if instance.Status.isStopping() {
+				return err
+			}
+			logger.Warnf("Error creating screenshot for %s: %s", t.FilePath, err.Error())
+		}
 	}
+
+	return nil
 }
 
-func (t *ScanTask) makeScreenshot(probeResult ffmpeg.VideoFile, outputPath string, quality int, width int) {
-	encoder := ffmpeg.NewEncoder(instance.FFMPEGPath)
+// makeScreenshot writes a screenshot of the video to outputPath. A partial
+// screenshot is removed if ffmpeg fails or the job is stopped.
+func (t *ScanTask) makeScreenshot(probeResult ffmpeg.VideoFile, outputPath string, quality int, width int) error {
+	encoder := instance.newJobEncoder()
 	options := ffmpeg.ScreenshotOptions{
 		OutputPath: outputPath,
 		Quality:    quality,
 		Time:       float64(probeResult.Duration) * 0.2,
 		Width:      width,
 	}
-	encoder.Screenshot(probeResult, options)
+	if err := encoder.Screenshot(probeResult, options); err != nil {
+		_ = os.Remove(outputPath)
+		return err
+	}
+	return nil
 }
 
 func (t *ScanTask) calculateChecksum() (string, error) {
@@ -209,13 +946,73 @@ func (t *ScanTask) calculateChecksum() (string, error) {
 	return checksum, nil
 }
 
+// calculateGalleryChecksum returns the checksum of the gallery file. Folder
+// galleries use the checksum of their contents, so that a moved folder is
+// still the same gallery.
+func (t *ScanTask) calculateGalleryChecksum() (string, error) {
+	if t.folder {
+		return getFolderGalleryChecksum(t.FilePath)
+	}
+	return t.calculateChecksum()
+}
+
+// getFolderGalleryChecksum returns the checksum of the names and sizes of
+// the images directly within the directory. It does not depend on the path
+// of the directory.
+func getFolderGalleryChecksum(path string) (string, error) {
+	infos, err := ioutil.ReadDir(path)
+	if err != nil {
+		return "", err
+	}
+
+	var contents strings.Builder
+	for _, info := range infos {
+		if !info.IsDir() && models.IsImage(info.Name()) {
+			fmt.Fprintf(&contents, "%s\x00%d\n", info.Name(), info.Size())
+		}
+	}
+	return utils.MD5FromString(contents.String()), nil
+}
+
+// getFolderGallerySize returns the total size of the images directly within
+// the directory.
+func getFolderGallerySize(path string) (int64, error) {
+	infos, err := ioutil.ReadDir(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var ret int64
+	for _, info := range infos {
+		if !info.IsDir() && models.IsImage(info.Name()) {
+			ret += info.Size()
+		}
+	}
+	return ret, nil
+}
+
+func (t *ScanTask) calculateOshash() (string, error) {
+	oshash, err := utils.OSHashFromFilePath(t.FilePath)
+	if err != nil {
+		return "", err
+	}
+	logger.Debugf("Oshash calculated: %s", oshash)
+	return oshash, nil
+}
+
 func (t *ScanTask) doesPathExist() bool {
-	if filepath.Ext(t.FilePath) == ".zip" {
+	if isGalleryPath(t.FilePath) {
 		qb := models.NewGalleryQueryBuilder()
 		gallery, _ := qb.FindByPath(t.FilePath)
 		if gallery != nil {
 			return true
 		}
+	} else if models.IsImage(t.FilePath) {
+		qb := models.NewImageQueryBuilder()
+		image, _ := qb.FindByPath(t.FilePath)
+		if image != nil {
+			return true
+		}
 	} else {
 		qb := models.NewSceneQueryBuilder()
 		scene, _ := qb.FindByPath(t.FilePath)
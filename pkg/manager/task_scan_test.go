@@ -0,0 +1,54 @@
+package manager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetFolderGalleryChecksum(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gallery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeFiles := func(folder string, files map[string]string) string {
+		path := filepath.Join(dir, folder)
+		if err := os.Mkdir(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for name, contents := range files {
+			if err := ioutil.WriteFile(filepath.Join(path, name), []byte(contents), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return path
+	}
+
+	original := writeFiles("original", map[string]string{"a.jpg": "a", "b.png": "bb", "notes.txt": "notes"})
+	// the same images in another folder, without the other files
+	moved := writeFiles("moved", map[string]string{"a.jpg": "a", "b.png": "bb"})
+	changed := writeFiles("changed", map[string]string{"a.jpg": "a", "b.png": "bbb"})
+
+	originalChecksum, err := getFolderGalleryChecksum(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	movedChecksum, err := getFolderGalleryChecksum(moved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changedChecksum, err := getFolderGalleryChecksum(changed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if originalChecksum != movedChecksum {
+		t.Errorf("Was expecting moved folder to have checksum %s, found %s", originalChecksum, movedChecksum)
+	}
+	if originalChecksum == changedChecksum {
+		t.Errorf("Was expecting changed folder to have a different checksum than %s", originalChecksum)
+	}
+}
@@ -0,0 +1,28 @@
+package manager
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTaskStatusFileProgress(t *testing.T) {
+	status := newTaskStatus()
+
+	status.setFileProgress("a.mp4", 0.5, time.Second)
+	status.setFileProgress("b.mp4", 0.1, time.Second)
+	if current := status.GetStatus(); current.CurrentFile != "a.mp4" || current.SubProgress != 0.5 {
+		t.Errorf("Was expecting a.mp4 at 0.5, found %s at %v", current.CurrentFile, current.SubProgress)
+	}
+
+	// clearing another file must not clear the reported file
+	status.clearFileProgress("b.mp4")
+	if current := status.GetStatus(); current.CurrentFile != "a.mp4" {
+		t.Errorf("Was expecting a.mp4, found %s", current.CurrentFile)
+	}
+
+	status.clearFileProgress("a.mp4")
+	status.setFileProgress("b.mp4", 0.2, time.Second)
+	if current := status.GetStatus(); current.CurrentFile != "b.mp4" || current.SubProgress != 0.2 {
+		t.Errorf("Was expecting b.mp4 at 0.2, found %s at %v", current.CurrentFile, current.SubProgress)
+	}
+}
@@ -1,8 +1,8 @@
 package manager
 
 import (
+	"fmt"
 	"os"
-	"sync"
 
 	"github.com/stashapp/stash/pkg/ffmpeg"
 	"github.com/stashapp/stash/pkg/logger"
@@ -14,53 +14,60 @@ type GenerateTranscodeTask struct {
 	Scene models.Scene
 }
 
-func (t *GenerateTranscodeTask) Start(wg *sync.WaitGroup) {
-	defer wg.Done()
-	videoCodec := t.Scene.VideoCodec.String
-	if ffmpeg.IsValidCodec(videoCodec) {
-		return
+func (t *GenerateTranscodeTask) Start() error {
+	sceneHash := GetSceneHash(&t.Scene)
+	defer lockSceneHash(sceneHash)()
+	if !t.isTranscodeNeeded() {
+		return nil
 	}
 
-	hasTranscode, _ := HasTranscode(&t.Scene)
-	if hasTranscode {
-		return
-	}
-
-	logger.Infof("[transcode] <%s> scene has codec %s", t.Scene.Checksum, t.Scene.VideoCodec.String)
+	logger.Infof("[transcode] <%s> scene has codecs %s/%s in %s", t.Scene.Path, t.Scene.VideoCodec.String, t.Scene.AudioCodec.String, GetSceneContainer(&t.Scene))
 
 	videoFile, err := ffmpeg.NewVideoFile(instance.FFProbePath, t.Scene.Path)
 	if err != nil {
-		logger.Errorf("[transcode] error reading video file: %s", err.Error())
-		return
+		return fmt.Errorf("[transcode] error reading video file: %s", err.Error())
 	}
 
-	outputPath := instance.Paths.Generated.GetTmpPath(t.Scene.Checksum + ".mp4")
+	outputPath := instance.Paths.Generated.GetTmpPath(sceneHash + ".mp4")
 	transcodeSize := config.GetMaxTranscodeSize()
 	options := ffmpeg.TranscodeOptions{
 		OutputPath:       outputPath,
 		MaxTranscodeSize: transcodeSize,
+		Profile:          GetTranscodeProfile(),
 	}
-	encoder := ffmpeg.NewEncoder(instance.FFMPEGPath)
-	encoder.Transcode(*videoFile, options)
-	if err := os.Rename(outputPath, instance.Paths.Scene.GetTranscodePath(t.Scene.Checksum)); err != nil {
-		logger.Errorf("[transcode] error generating transcode: %s", err.Error())
-		return
-	}
-	logger.Debugf("[transcode] <%s> created transcode: %s", t.Scene.Checksum, outputPath)
-	return
-}
+	encoder := instance.newJobProgressEncoder(t.Scene.Path)
+	defer instance.Status.clearFileProgress(t.Scene.Path)
 
-func (t *GenerateTranscodeTask) isTranscodeNeeded() bool {
+	// the video is only re-encoded if browsers cannot play it in mp4
+	switch {
+	case !ffmpeg.IsValidVideoForContainer(videoFile.VideoCodec, ffmpeg.Mp4):
+		err = encoder.Transcode(*videoFile, options)
+	case !ffmpeg.IsValidAudioForContainer(videoFile.AudioCodec, ffmpeg.Mp4):
+		logger.Debugf("[transcode] <%s> transcoding audio only", t.Scene.Path)
+		err = encoder.TranscodeAudio(*videoFile, options)
+	default:
+		logger.Debugf("[transcode] <%s> remuxing to mp4", t.Scene.Path)
+		err = encoder.Remux(*videoFile, options)
+	}
 
-	videoCodec := t.Scene.VideoCodec.String
-	hasTranscode, _ := HasTranscode(&t.Scene)
+	if err != nil {
+		_ = os.Remove(outputPath)
+		return fmt.Errorf("[transcode] error generating transcode: %s", err.Error())
+	}
 
-	if ffmpeg.IsValidCodec(videoCodec) {
-		return false
+	if err := os.Rename(outputPath, instance.Paths.Scene.GetTranscodePath(sceneHash)); err != nil {
+		return fmt.Errorf("[transcode] error generating transcode: %s", err.Error())
 	}
+	logger.Debugf("[transcode] <%s> created transcode: %s", t.Scene.Path, outputPath)
+	instance.addJobReportItem(models.JobReportItemTypeGenerated, t.Scene.Path, "Transcode")
+	return nil
+}
 
-	if hasTranscode {
+func (t *GenerateTranscodeTask) isTranscodeNeeded() bool {
+	if IsDirectStreamable(&t.Scene) {
 		return false
 	}
-	return true
+
+	hasTranscode, _ := HasTranscode(&t.Scene)
+	return !hasTranscode
 }
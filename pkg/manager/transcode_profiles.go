@@ -0,0 +1,129 @@
+package manager
+
+import (
+	"fmt"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// GetTranscodeProfiles returns the built-in and configured transcode
+// profiles. Configured profiles replace built-in profiles of the same name.
+func GetTranscodeProfiles() []*models.TranscodeProfile {
+	defaultProfile := ffmpeg.DefaultTranscodeProfile()
+	defaultStreamingProfile := ffmpeg.DefaultStreamingTranscodeProfile()
+	defaultHLSProfile := ffmpeg.DefaultHLSTranscodeProfile()
+	ret := []*models.TranscodeProfile{&defaultProfile, &defaultStreamingProfile, &defaultHLSProfile}
+
+	for _, profile := range config.GetTranscodeProfiles() {
+		replaced := false
+		for i, p := range ret {
+			if p.Name == profile.Name {
+				ret[i] = profile
+				replaced = true
+			}
+		}
+		if !replaced {
+			ret = append(ret, profile)
+		}
+	}
+
+	return ret
+}
+
+// ValidateTranscodeProfile returns an error if there is no valid transcode
+// profile with the given name. Generated transcodes are always mp4 files, so
+// profiles used for them must output mp4.
+func ValidateTranscodeProfile(name string, streaming bool) error {
+	var profile *models.TranscodeProfile
+	for _, p := range GetTranscodeProfiles() {
+		if p.Name == name {
+			profile = p
+		}
+	}
+	if profile == nil {
+		return fmt.Errorf("transcode profile %s not found", name)
+	}
+
+	if err := ffmpeg.ValidateTranscodeProfile(*profile, instance.ffmpegEncoders); err != nil {
+		return err
+	}
+
+	if !streaming && profile.Container != "mp4" {
+		return fmt.Errorf("transcode profile %s must use the mp4 container for generated transcodes", name)
+	}
+	return nil
+}
+
+// GetTranscodeProfile returns the transcode profile used for generated
+// transcodes. The default profile is returned if the configured profile is
+// not valid.
+func GetTranscodeProfile() models.TranscodeProfile {
+	return getTranscodeProfile(config.GetTranscodeProfile(), false, ffmpeg.DefaultTranscodeProfile())
+}
+
+// GetStreamingTranscodeProfile returns the transcode profile used for live
+// transcoded streams. The default profile is returned if the configured
+// profile is not valid.
+func GetStreamingTranscodeProfile() models.TranscodeProfile {
+	return getTranscodeProfile(config.GetStreamingTranscodeProfile(), true, ffmpeg.DefaultStreamingTranscodeProfile())
+}
+
+// GetHLSTranscodeProfile returns the transcode profile used for HLS
+// streams. The default profile is returned if the configured profile is not
+// valid.
+func GetHLSTranscodeProfile() models.TranscodeProfile {
+	return getTranscodeProfile(config.GetHLSTranscodeProfile(), true, ffmpeg.DefaultHLSTranscodeProfile())
+}
+
+func getTranscodeProfile(name string, streaming bool, defaultProfile models.TranscodeProfile) models.TranscodeProfile {
+	if name == "" {
+		name = defaultProfile.Name
+	}
+
+	if err := ValidateTranscodeProfile(name, streaming); err != nil {
+		return defaultProfile
+	}
+
+	for _, profile := range GetTranscodeProfiles() {
+		if profile.Name == name {
+			return *profile
+		}
+	}
+	return defaultProfile
+}
+
+// validateTranscodeProfiles reads the encoders supported by ffmpeg and logs
+// the configured transcode profiles that cannot be used.
+func (s *singleton) validateTranscodeProfiles() {
+	encoder := ffmpeg.NewEncoder(s.FFMPEGPath)
+	encoders, err := encoder.GetEncoders()
+	if err != nil {
+		logger.Warnf("could not read the ffmpeg encoders: %s", err.Error())
+	}
+	s.ffmpegEncoders = encoders
+
+	for _, profile := range config.GetTranscodeProfiles() {
+		if err := ffmpeg.ValidateTranscodeProfile(*profile, encoders); err != nil {
+			logger.Errorf("invalid transcode profile: %s", err.Error())
+		}
+	}
+
+	if name := config.GetTranscodeProfile(); name != "" {
+		if err := ValidateTranscodeProfile(name, false); err != nil {
+			logger.Errorf("%s. Using the default transcode profile", err.Error())
+		}
+	}
+	if name := config.GetStreamingTranscodeProfile(); name != "" {
+		if err := ValidateTranscodeProfile(name, true); err != nil {
+			logger.Errorf("%s. Using the default streaming transcode profile", err.Error())
+		}
+	}
+	if name := config.GetHLSTranscodeProfile(); name != "" {
+		if err := ValidateTranscodeProfile(name, true); err != nil {
+			logger.Errorf("%s. Using the default HLS transcode profile", err.Error())
+		}
+	}
+}
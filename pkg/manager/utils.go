@@ -2,8 +2,11 @@ package manager
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/utils"
 )
@@ -13,8 +16,7 @@ func IsStreamable(scene *models.Scene) (bool, error) {
 		return false, fmt.Errorf("nil scene")
 	}
 
-	videoCodec := scene.VideoCodec.String
-	if ffmpeg.IsValidCodec(videoCodec) {
+	if IsDirectStreamable(scene) {
 		return true, nil
 	} else {
 		hasTranscode, _ := HasTranscode(scene)
@@ -22,10 +24,71 @@ func IsStreamable(scene *models.Scene) (bool, error) {
 	}
 }
 
+// GetSceneContainer returns the container of the scene file. The container
+// of scenes scanned before it was stored is guessed from the file extension.
+func GetSceneContainer(scene *models.Scene) ffmpeg.Container {
+	return ffmpeg.MatchContainer(scene.Format.String, scene.Path)
+}
+
+// IsDirectStreamable returns true if the scene file can be played by
+// browsers without remuxing or transcoding it.
+func IsDirectStreamable(scene *models.Scene) bool {
+	return ffmpeg.IsStreamable(scene.VideoCodec.String, scene.AudioCodec.String, GetSceneContainer(scene))
+}
+
+// IsRemuxStreamable returns true if the scene file cannot be played by
+// browsers directly, but can be streamed by copying its video into mp4.
+func IsRemuxStreamable(scene *models.Scene) bool {
+	return !IsDirectStreamable(scene) && ffmpeg.IsValidVideoForContainer(scene.VideoCodec.String, ffmpeg.Mp4)
+}
+
 func HasTranscode(scene *models.Scene) (bool, error) {
 	if scene == nil {
 		return false, fmt.Errorf("nil scene")
 	}
-	transcodePath := instance.Paths.Scene.GetTranscodePath(scene.Checksum)
+	transcodePath := instance.Paths.Scene.GetTranscodePath(GetSceneHash(scene))
 	return utils.FileExists(transcodePath)
 }
+
+var videoMimeTypes = map[string]string{
+	".mp4":  "video/mp4",
+	".m4v":  "video/mp4",
+	".mov":  "video/quicktime",
+	".webm": "video/webm",
+	".mkv":  "video/x-matroska",
+	".ogv":  "video/ogg",
+}
+
+// GetVideoMimeType returns the mime type of the video file from its
+// extension.
+func GetVideoMimeType(path string) string {
+	if mimeType, ok := videoMimeTypes[strings.ToLower(filepath.Ext(path))]; ok {
+		return mimeType
+	}
+	return "application/octet-stream"
+}
+
+// GetStreamingResolutions returns the resolutions that the scene can be live
+// transcoded to, from the smallest to the largest. They are bounded by the
+// max streaming transcode size and by the scene resolution.
+func GetStreamingResolutions(scene *models.Scene) []models.StreamingResolutionEnum {
+	renditions := hlsRenditions(int(scene.Width.Int64), int(scene.Height.Int64), config.GetMaxStreamingTranscodeSize())
+
+	var ret []models.StreamingResolutionEnum
+	for _, rendition := range renditions {
+		ret = append(ret, rendition.Resolution)
+	}
+	return ret
+}
+
+// GetStreamingResolution returns the requested resolution, bounded by the max
+// streaming transcode size.
+func GetStreamingResolution(resolution models.StreamingResolutionEnum) models.StreamingResolutionEnum {
+	maxResolution := config.GetMaxStreamingTranscodeSize()
+	maxSize := ffmpeg.StreamingResolutionSize(maxResolution)
+	size := ffmpeg.StreamingResolutionSize(resolution)
+	if maxSize != 0 && (size == 0 || size > maxSize) {
+		return maxResolution
+	}
+	return resolution
+}
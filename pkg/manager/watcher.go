@@ -0,0 +1,407 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// watchDelay is how long the stash paths must go without changes before
+// the changed paths are scanned. This avoids scanning files that are still
+// being copied, and scans a burst of changes in a single job.
+const watchDelay = 10 * time.Second
+
+// watcherMutex serialises starting and stopping the watcher.
+var watcherMutex sync.Mutex
+
+// watchInput is the input of a watch job.
+type watchInput struct {
+	// Paths are the files and directories that have changed. Existing paths
+	// are scanned, and scenes under paths that no longer exist are cleaned.
+	Paths []string `json:"paths"`
+}
+
+// stashWatcher watches the stash paths for changes, and queues a watch job
+// for the changed paths once the changes have stopped.
+type stashWatcher struct {
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+
+	mutex   sync.Mutex
+	changed map[string]struct{}
+	timer   *time.Timer
+}
+
+// RefreshWatcher starts or stops watching the stash paths, depending on the
+// configuration. It must be called after the stash paths are changed.
+func (s *singleton) RefreshWatcher() {
+	watcherMutex.Lock()
+	defer watcherMutex.Unlock()
+
+	if s.watcher != nil {
+		s.watcher.stop()
+		s.watcher = nil
+		logger.Info("Stopped watching stash paths")
+	}
+
+	if !config.GetWatchStashPaths() {
+		return
+	}
+
+	stashPaths := config.GetStashPaths()
+	w, err := newStashWatcher(stashPaths)
+	if err != nil {
+		logger.Errorf("Error watching stash paths: %s", err.Error())
+		return
+	}
+
+	s.watcher = w
+	logger.Infof("Watching %d stash paths for changes", len(stashPaths))
+}
+
+func newStashWatcher(stashPaths []string) (*stashWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &stashWatcher{
+		watcher: watcher,
+		done:    make(chan struct{}),
+		changed: make(map[string]struct{}),
+	}
+
+	for _, stashPath := range stashPaths {
+		w.addDirectory(stashPath)
+	}
+
+	go w.run()
+	return w, nil
+}
+
+// addDirectory watches the directory and all of its subdirectories. Events
+// are only received for directories that are watched.
+func (w *stashWatcher) addDirectory(path string) {
+	_ = filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			logger.Warnf("Error watching %s: %s", path, err.Error())
+			return nil
+		}
+
+		if info.IsDir() {
+			if err := w.watcher.Add(path); err != nil {
+				logger.Warnf("Error watching %s: %s", path, err.Error())
+			}
+		}
+		return nil
+	})
+}
+
+func (w *stashWatcher) run() {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			w.handleEvent(event)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Errorf("Error watching stash paths: %s", err.Error())
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *stashWatcher) handleEvent(event fsnotify.Event) {
+	if event.Op == fsnotify.Chmod {
+		return
+	}
+
+	if event.Op&fsnotify.Create != 0 {
+		// watch new directories, including directories moved into the stash
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			w.addDirectory(event.Name)
+		}
+	}
+
+	logger.Debugf("[watcher] %s", event.String())
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.changed[event.Name] = struct{}{}
+
+	// wait for the changes to stop before queueing the scan
+	if w.timer == nil {
+		w.timer = time.AfterFunc(watchDelay, w.flush)
+	} else {
+		w.timer.Reset(watchDelay)
+	}
+}
+
+// flush queues a watch job for the paths that have changed.
+func (w *stashWatcher) flush() {
+	w.mutex.Lock()
+	var paths []string
+	for path := range w.changed {
+		paths = append(paths, path)
+	}
+	w.changed = make(map[string]struct{})
+	w.timer = nil
+	w.mutex.Unlock()
+
+	if len(paths) == 0 {
+		return
+	}
+
+	logger.Infof("[watcher] %d paths changed, queueing scan", len(paths))
+	if _, err := instance.enqueueJob(Watch, watchInput{Paths: paths}); err != nil {
+		logger.Errorf("[watcher] error queueing scan: %s", err.Error())
+	}
+}
+
+func (w *stashWatcher) stop() {
+	close(w.done)
+	_ = w.watcher.Close()
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+}
+
+// watchScan scans the changed paths that still exist, then cleans the
+// scenes, galleries and images under the paths that no longer exist. A moved
+// file is found by the scan, which updates the path of its scene, gallery or
+// image, so the items to clean are checked again after the scan.
+func (s *singleton) watchScan(input watchInput) {
+	var existingPaths []string
+	var removedPaths []string
+	for _, path := range input.Paths {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			removedPaths = append(removedPaths, path)
+
+			// rescan the folder gallery that contained a removed image
+			if config.GetCreateGalleriesFromFolders() && models.IsImage(path) {
+				if exists, _ := utils.DirExists(filepath.Dir(path)); exists {
+					existingPaths = append(existingPaths, filepath.Dir(path))
+				}
+			}
+		} else {
+			existingPaths = append(existingPaths, path)
+		}
+	}
+
+	scanPaths, _ := excludeFiles(getScanFiles(existingPaths), config.GetExcludes())
+
+	var scenes []*models.Scene
+	var galleries []*models.Gallery
+	var images []*models.Image
+	qb := models.NewSceneQueryBuilder()
+	gqb := models.NewGalleryQueryBuilder()
+	iqb := models.NewImageQueryBuilder()
+	for _, path := range removedPaths {
+		// the path may be a file or a directory
+		regex := "^" + regexp.QuoteMeta(path) + "($|" + regexp.QuoteMeta(string(filepath.Separator)) + ")"
+		found, err := qb.QueryAllByPathRegex(regex)
+		if err != nil {
+			logger.Errorf("Error finding scenes under %s: %s", path, err.Error())
+			continue
+		}
+		scenes = append(scenes, found...)
+
+		foundGalleries, err := gqb.QueryAllByPathRegex(regex)
+		if err != nil {
+			logger.Errorf("Error finding galleries under %s: %s", path, err.Error())
+			continue
+		}
+		galleries = append(galleries, foundGalleries...)
+
+		foundImages, err := iqb.QueryAllByPathRegex(regex)
+		if err != nil {
+			logger.Errorf("Error finding images under %s: %s", path, err.Error())
+			continue
+		}
+		images = append(images, foundImages...)
+	}
+
+	logger.Infof("[watcher] scanning %d changed files and cleaning %d removed scenes, %d removed galleries and %d removed images", len(scanPaths), len(scenes), len(galleries), len(images))
+	s.Status.setProgress(0, len(scanPaths)+len(scenes)+len(galleries)+len(images))
+
+	pool := newWorkerPool(s.jobContext(), config.GetParallelTasks(), s.Status.incrementProgress)
+	for _, path := range scanPaths {
+		if s.Status.isStopping() {
+			logger.Info("Stopping due to user request")
+			break
+		}
+
+		task := ScanTask{FilePath: path}
+		pool.Run(path, task.Start)
+	}
+
+	errors := pool.Wait()
+	s.addJobReportErrors(errors)
+
+	scenes = getRemovedScenes(scenes, qb.Find)
+	galleries = getRemovedGalleries(galleries, gqb.Find)
+
+	// images in galleries that are kept only lose their file path, in the
+	// same way as the full clean
+	removedGalleries := make(map[int]bool)
+	for _, gallery := range galleries {
+		removedGalleries[gallery.ID] = true
+	}
+	var imageItems []cleanItem
+	for _, image := range getRemovedImages(images, iqb.Find) {
+		task := &CleanImageTask{Image: *image, RemovedGalleries: removedGalleries}
+		if reason := task.getCleanReason(); reason != "" {
+			imageItems = append(imageItems, cleanItem{task: task, path: task.getName(), reason: reason})
+		}
+	}
+
+	// the watcher always removes files, so it is never a dry run
+	if s.abortClean(checkLibraryCleanThreshold(len(scenes)+len(galleries)+len(imageItems)), false) {
+		return
+	}
+	total := len(scanPaths) + len(scenes) + len(galleries) + len(imageItems)
+	s.Status.setProgress(len(scanPaths), total)
+
+	var cleanErrors []taskError
+	for _, scene := range scenes {
+		if s.Status.isStopping() {
+			logger.Info("Stopping due to user request")
+			break
+		}
+
+		task := CleanTask{Scene: *scene}
+		if err := task.Start(); err != nil {
+			logger.Errorf("Error cleaning %s: %s", scene.Path, err.Error())
+			cleanErrors = append(cleanErrors, taskError{Path: scene.Path, Err: err})
+		}
+		s.Status.incrementProgress()
+	}
+
+	for _, gallery := range galleries {
+		if s.Status.isStopping() {
+			logger.Info("Stopping due to user request")
+			break
+		}
+
+		task := CleanGalleryTask{Gallery: *gallery}
+		if err := task.Start(); err != nil {
+			logger.Errorf("Error cleaning %s: %s", gallery.Path, err.Error())
+			cleanErrors = append(cleanErrors, taskError{Path: gallery.Path, Err: err})
+		}
+		s.Status.incrementProgress()
+	}
+
+	for _, item := range imageItems {
+		if s.Status.isStopping() {
+			logger.Info("Stopping due to user request")
+			break
+		}
+
+		if err := item.task.clean(item.reason); err != nil {
+			logger.Errorf("Error cleaning %s: %s", item.path, err.Error())
+			cleanErrors = append(cleanErrors, taskError{Path: item.path, Err: err})
+		}
+		s.Status.incrementProgress()
+	}
+	s.addJobReportErrors(cleanErrors)
+
+	logger.Infof("[watcher] finished scanning changed files. %d files failed", len(errors))
+}
+
+// getRemovedScenes returns the current state of the scenes whose files are
+// still missing. Scenes that were moved to an existing file, or that no
+// longer exist, are skipped.
+func getRemovedScenes(scenes []*models.Scene, find func(id int) (*models.Scene, error)) []*models.Scene {
+	var ret []*models.Scene
+	for _, scene := range scenes {
+		current, err := find(scene.ID)
+		if err != nil {
+			logger.Errorf("Error finding scene %d: %s", scene.ID, err.Error())
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		if exists, _ := utils.FileExists(current.Path); exists {
+			logger.Debugf("[watcher] %s was moved to %s", scene.Path, current.Path)
+			continue
+		}
+
+		ret = append(ret, current)
+	}
+	return ret
+}
+
+// getRemovedGalleries returns the current state of the galleries whose files
+// are still missing, in the same way as getRemovedScenes.
+func getRemovedGalleries(galleries []*models.Gallery, find func(id int) (*models.Gallery, error)) []*models.Gallery {
+	var ret []*models.Gallery
+	for _, gallery := range galleries {
+		current, err := find(gallery.ID)
+		if err != nil {
+			logger.Errorf("Error finding gallery %d: %s", gallery.ID, err.Error())
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		if exists, _ := utils.FileExists(current.Path); exists {
+			logger.Debugf("[watcher] %s was moved to %s", gallery.Path, current.Path)
+			continue
+		}
+
+		ret = append(ret, current)
+	}
+	return ret
+}
+
+// getRemovedImages returns the current state of the images whose files are
+// still missing, in the same way as getRemovedScenes. Images whose path was
+// cleared by the scan of their gallery are returned as well, since they are
+// removed if they are no longer in any gallery.
+func getRemovedImages(images []*models.Image, find func(id int) (*models.Image, error)) []*models.Image {
+	var ret []*models.Image
+	for _, image := range images {
+		current, err := find(image.ID)
+		if err != nil {
+			logger.Errorf("Error finding image %d: %s", image.ID, err.Error())
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		if current.Path.Valid {
+			if exists, _ := utils.FileExists(current.Path.String); exists {
+				logger.Debugf("[watcher] %s was moved to %s", image.Path.String, current.Path.String)
+				continue
+			}
+		}
+
+		ret = append(ret, current)
+	}
+	return ret
+}
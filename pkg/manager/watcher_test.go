@@ -0,0 +1,79 @@
+package manager
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func TestGetRemovedScenes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watcher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// renamed.mp4 was renamed to new.mp4 and the scan updated the scene
+	newPath := filepath.Join(dir, "new.mp4")
+	if err := ioutil.WriteFile(newPath, []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	current := map[int]*models.Scene{
+		1: {ID: 1, Path: newPath},
+		2: {ID: 2, Path: filepath.Join(dir, "deleted.mp4")},
+	}
+	find := func(id int) (*models.Scene, error) {
+		return current[id], nil
+	}
+
+	scenes := []*models.Scene{
+		{ID: 1, Path: filepath.Join(dir, "renamed.mp4")},
+		{ID: 2, Path: filepath.Join(dir, "deleted.mp4")},
+		{ID: 3, Path: filepath.Join(dir, "destroyed.mp4")},
+	}
+
+	removed := getRemovedScenes(scenes, find)
+	if len(removed) != 1 || removed[0].ID != 2 {
+		t.Errorf("Was expecting only scene 2 to be removed, found %v", removed)
+	}
+}
+
+func TestGetRemovedImages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watcher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	newPath := filepath.Join(dir, "new.jpg")
+	if err := ioutil.WriteFile(newPath, []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	current := map[int]*models.Image{
+		1: {ID: 1, Path: sql.NullString{String: newPath, Valid: true}},
+		2: {ID: 2, Path: sql.NullString{String: filepath.Join(dir, "deleted.jpg"), Valid: true}},
+		// the path was cleared by the scan of the image's gallery
+		3: {ID: 3},
+	}
+	find := func(id int) (*models.Image, error) {
+		return current[id], nil
+	}
+
+	images := []*models.Image{
+		{ID: 1, Path: sql.NullString{String: filepath.Join(dir, "renamed.jpg"), Valid: true}},
+		{ID: 2, Path: sql.NullString{String: filepath.Join(dir, "deleted.jpg"), Valid: true}},
+		{ID: 3, Path: sql.NullString{String: filepath.Join(dir, "gallery", "image.jpg"), Valid: true}},
+		{ID: 4, Path: sql.NullString{String: filepath.Join(dir, "destroyed.jpg"), Valid: true}},
+	}
+
+	removed := getRemovedImages(images, find)
+	if len(removed) != 2 || removed[0].ID != 2 || removed[1].ID != 3 {
+		t.Errorf("Was expecting images 2 and 3 to be removed, found %v", removed)
+	}
+}
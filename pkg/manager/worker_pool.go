@@ -0,0 +1,102 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+// taskError records the error returned by a task, along with the path of
+// the file that the task was processing.
+type taskError struct {
+	Path string
+	Err  error
+}
+
+// workerPool runs tasks concurrently, with no more than a fixed number of
+// tasks running at once.
+type workerPool struct {
+	ctx    context.Context
+	wg     sync.WaitGroup
+	slots  chan struct{}
+	mutex  sync.Mutex
+	errors []taskError
+
+	// onDone is called after each task completes. Calls are serialised, so
+	// it may safely update shared state such as the task progress.
+	onDone func()
+}
+
+// newWorkerPool returns a pool of the given size. Tasks that fail after ctx
+// is cancelled were interrupted rather than failed, so their errors are not
+// recorded.
+func newWorkerPool(ctx context.Context, size int, onDone func()) *workerPool {
+	if size < 1 {
+		size = 1
+	}
+
+	return &workerPool{
+		ctx:    ctx,
+		slots:  make(chan struct{}, size),
+		onDone: onDone,
+	}
+}
+
+// Run runs fn in a new goroutine, blocking until a worker is free. Any error
+// returned by fn is logged and recorded against path. A panic in fn is
+// recovered and recorded as an error, so that it does not stop the other
+// tasks.
+func (p *workerPool) Run(path string, fn func() error) {
+	p.slots <- struct{}{}
+	p.wg.Add(1)
+
+	go func() {
+		defer func() {
+			<-p.slots
+			p.wg.Done()
+		}()
+
+		panicked, err := runRecovered(fn)
+
+		p.mutex.Lock()
+		defer p.mutex.Unlock()
+
+		if err != nil && !panicked && p.ctx.Err() != nil {
+			logger.Debugf("Stopped processing %s: %s", path, err.Error())
+		} else if err != nil {
+			logger.Errorf("Error processing %s: %s", path, err.Error())
+			p.errors = append(p.errors, taskError{Path: path, Err: err})
+		}
+
+		if p.onDone != nil {
+			p.onDone()
+		}
+	}()
+}
+
+// runRecovered returns the error returned by fn. If fn panics, it returns
+// true and an error describing the panic.
+func runRecovered(fn func() error) (panicked bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Debugf("Recovered from panic: %v\n%s", r, debug.Stack())
+			err = fmt.Errorf("panic: %v", r)
+			panicked = true
+		}
+	}()
+
+	return false, fn()
+}
+
+// Wait blocks until all tasks have completed, and returns the errors of the
+// tasks that failed.
+func (p *workerPool) Wait() []taskError {
+	p.wg.Wait()
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.errors
+}
@@ -0,0 +1,87 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWorkerPool(t *testing.T) {
+	const size = 3
+	const tasks = 20
+
+	var mutex sync.Mutex
+	running := 0
+	maxRunning := 0
+	done := 0
+
+	pool := newWorkerPool(context.Background(), size, func() {
+		done++
+	})
+
+	for i := 0; i < tasks; i++ {
+		path := fmt.Sprintf("file%d.mp4", i)
+		fail := i%5 == 0
+		pool.Run(path, func() error {
+			mutex.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mutex.Unlock()
+
+			time.Sleep(time.Millisecond)
+
+			mutex.Lock()
+			running--
+			mutex.Unlock()
+
+			if fail {
+				return fmt.Errorf("failed")
+			}
+			return nil
+		})
+	}
+
+	errors := pool.Wait()
+
+	if maxRunning > size {
+		t.Errorf("Was expecting at most %d tasks running, found %d", size, maxRunning)
+	}
+	if done != tasks {
+		t.Errorf("Was expecting %d completed tasks, found %d", tasks, done)
+	}
+	if len(errors) != 4 {
+		t.Errorf("Was expecting 4 errors, found %d", len(errors))
+	}
+	for _, e := range errors {
+		if e.Path == "" || e.Err == nil {
+			t.Errorf("Error missing path or error: %v", e)
+		}
+	}
+}
+
+func TestWorkerPoolPanic(t *testing.T) {
+	done := 0
+	pool := newWorkerPool(context.Background(), 1, func() {
+		done++
+	})
+
+	pool.Run("panic.mp4", func() error {
+		panic("unexpected")
+	})
+	pool.Run("file.mp4", func() error {
+		return nil
+	})
+
+	errors := pool.Wait()
+
+	if done != 2 {
+		t.Errorf("Was expecting 2 completed tasks, found %d", done)
+	}
+	if len(errors) != 1 || errors[0].Path != "panic.mp4" || errors[0].Err == nil {
+		t.Errorf("Was expecting a single error for panic.mp4, found %v", errors)
+	}
+}
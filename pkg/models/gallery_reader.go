@@ -0,0 +1,137 @@
+package models
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// GalleryReader reads the images of a gallery.
+type GalleryReader interface {
+	// Names returns the names of the images in the gallery, in natural order.
+	Names() []string
+	// Read returns the contents of the image at the given index.
+	Read(index int) ([]byte, error)
+	Close() error
+}
+
+// NewGalleryReader returns a reader for the gallery at the given path.
+// Directories are read as folders of images, and files as zip archives,
+// which includes cbz comic archives.
+func NewGalleryReader(path string) (GalleryReader, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return newFolderGalleryReader(path)
+	}
+	return newZipGalleryReader(path)
+}
+
+type zipGalleryReader struct {
+	readCloser *zip.ReadCloser
+	files      []*zip.File
+}
+
+func newZipGalleryReader(path string) (*zipGalleryReader, error) {
+	readCloser, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*zip.File
+	for _, file := range readCloser.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		if !IsImage(file.Name) {
+			continue
+		}
+		if strings.Contains(file.Name, "__MACOSX") {
+			continue
+		}
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return utils.NaturalCompare(files[i].Name, files[j].Name)
+	})
+
+	return &zipGalleryReader{readCloser: readCloser, files: files}, nil
+}
+
+func (r *zipGalleryReader) Names() []string {
+	var ret []string
+	for _, file := range r.files {
+		ret = append(ret, file.Name)
+	}
+	return ret
+}
+
+func (r *zipGalleryReader) Read(index int) ([]byte, error) {
+	if index < 0 || index >= len(r.files) {
+		return nil, fmt.Errorf("image index %d out of range", index)
+	}
+
+	readCloser, err := r.files[index].Open()
+	if err != nil {
+		return nil, err
+	}
+	defer readCloser.Close()
+
+	return ioutil.ReadAll(readCloser)
+}
+
+func (r *zipGalleryReader) Close() error {
+	return r.readCloser.Close()
+}
+
+// folderGalleryReader reads the images directly within a directory.
+// Subdirectories are not included.
+type folderGalleryReader struct {
+	path  string
+	names []string
+}
+
+func newFolderGalleryReader(path string) (*folderGalleryReader, error) {
+	infos, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &folderGalleryReader{path: path}
+	for _, info := range infos {
+		if info.IsDir() || !IsImage(info.Name()) {
+			continue
+		}
+		ret.names = append(ret.names, info.Name())
+	}
+	sort.Slice(ret.names, func(i, j int) bool {
+		return utils.NaturalCompare(ret.names[i], ret.names[j])
+	})
+
+	return ret, nil
+}
+
+func (r *folderGalleryReader) Names() []string {
+	return r.names
+}
+
+func (r *folderGalleryReader) Read(index int) ([]byte, error) {
+	if index < 0 || index >= len(r.names) {
+		return nil, fmt.Errorf("image index %d out of range", index)
+	}
+
+	return ioutil.ReadFile(filepath.Join(r.path, r.names[index]))
+}
+
+func (r *folderGalleryReader) Close() error {
+	return nil
+}
@@ -1,116 +1,180 @@
 package models
 
 import (
-	"archive/zip"
 	"bytes"
 	"database/sql"
 	"github.com/disintegration/imaging"
 	"github.com/stashapp/stash/pkg/api/urlbuilders"
 	"github.com/stashapp/stash/pkg/logger"
-	"github.com/stashapp/stash/pkg/utils"
 	"image"
 	"image/jpeg"
-	"io/ioutil"
 	"path/filepath"
-	"sort"
 	"strings"
+	"sync"
+
+	// registers the webp decoder with the image package
+	_ "golang.org/x/image/webp"
 )
 
+// DefaultImageExtensions are the default extensions of the images read from
+// galleries, without the leading dot. Only formats that can be decoded are
+// included, so avif is not a default: there is no avif decoder.
+var DefaultImageExtensions = []string{"jpg", "jpeg", "png", "gif", "webp"}
+
+var (
+	imageExtensions      = DefaultImageExtensions
+	imageExtensionsMutex sync.RWMutex
+)
+
+// SetImageExtensions sets the extensions of the images read from galleries.
+func SetImageExtensions(extensions []string) {
+	imageExtensionsMutex.Lock()
+	defer imageExtensionsMutex.Unlock()
+	imageExtensions = extensions
+}
+
+// IsImage returns true if the file has one of the image extensions. The
+// extension is not case sensitive.
+func IsImage(name string) bool {
+	imageExtensionsMutex.RLock()
+	defer imageExtensionsMutex.RUnlock()
+
+	ext := strings.TrimPrefix(filepath.Ext(name), ".")
+	for _, imageExt := range imageExtensions {
+		if strings.EqualFold(ext, imageExt) {
+			return true
+		}
+	}
+	return false
+}
+
 type Gallery struct {
-	ID        int             `db:"id" json:"id"`
-	Path      string          `db:"path" json:"path"`
-	Checksum  string          `db:"checksum" json:"checksum"`
-	SceneID   sql.NullInt64   `db:"scene_id,omitempty" json:"scene_id"`
-	CreatedAt SQLiteTimestamp `db:"created_at" json:"created_at"`
-	UpdatedAt SQLiteTimestamp `db:"updated_at" json:"updated_at"`
+	ID          int                 `db:"id" json:"id"`
+	Path        string              `db:"path" json:"path"`
+	Checksum    string              `db:"checksum" json:"checksum"`
+	Title       sql.NullString      `db:"title" json:"title"`
+	Details     sql.NullString      `db:"details" json:"details"`
+	URL         sql.NullString      `db:"url" json:"url"`
+	Date        SQLiteDate          `db:"date" json:"date"`
+	Rating      sql.NullInt64       `db:"rating" json:"rating"`
+	StudioID    sql.NullInt64       `db:"studio_id,omitempty" json:"studio_id"`
+	Size        sql.NullString      `db:"size" json:"size"`
+	FileModTime NullSQLiteTimestamp `db:"file_mod_time" json:"file_mod_time"`
+	Corrupt     sql.NullBool        `db:"corrupt" json:"corrupt"`
+	CreatedAt   SQLiteTimestamp     `db:"created_at" json:"created_at"`
+	UpdatedAt   SQLiteTimestamp     `db:"updated_at" json:"updated_at"`
+}
+
+// GalleryPartial holds the gallery fields to update. Nil fields are not
+// changed.
+type GalleryPartial struct {
+	ID        int              `db:"id" json:"id"`
+	Title     *sql.NullString  `db:"title" json:"title"`
+	Details   *sql.NullString  `db:"details" json:"details"`
+	URL       *sql.NullString  `db:"url" json:"url"`
+	Date      *SQLiteDate      `db:"date" json:"date"`
+	Rating    *sql.NullInt64   `db:"rating" json:"rating"`
+	StudioID  *sql.NullInt64   `db:"studio_id,omitempty" json:"studio_id"`
+	UpdatedAt *SQLiteTimestamp `db:"updated_at" json:"updated_at"`
 }
 
 func (g *Gallery) GetFiles(baseURL string) []*GalleryFilesType {
 	var galleryFiles []*GalleryFilesType
-	filteredFiles, readCloser, err := g.listZipContents()
+	reader, err := NewGalleryReader(g.Path)
 	if err != nil {
+		logger.Warnf("failed to read gallery %s: %s", g.Path, err.Error())
 		return nil
 	}
-	defer readCloser.Close()
+	defer reader.Close()
+
+	// the image metadata is stored when the thumbnails are generated
+	images := make(map[int]*GalleryImage)
+	qb := NewGalleryImageQueryBuilder()
+	if stored, err := qb.FindByGalleryID(g.ID, nil); err == nil {
+		for _, image := range stored {
+			images[image.FileIndex] = image
+		}
+	}
 
 	builder := urlbuilders.NewGalleryURLBuilder(baseURL, g.ID)
-	for i, file := range filteredFiles {
+	for i, name := range reader.Names() {
+		name := name
 		galleryURL := builder.GetGalleryImageURL(i)
 		galleryFile := GalleryFilesType{
 			Index: i,
-			Name:  &file.Name,
+			Name:  &name,
 			Path:  &galleryURL,
 		}
+		if image := images[i]; image != nil && image.Name == name {
+			galleryFile.Checksum = &image.Checksum
+			if image.Width.Valid && image.Height.Valid {
+				width := int(image.Width.Int64)
+				height := int(image.Height.Int64)
+				galleryFile.Width = &width
+				galleryFile.Height = &height
+			}
+		}
 		galleryFiles = append(galleryFiles, &galleryFile)
 	}
 
 	return galleryFiles
 }
 
+// CheckFile returns an error if the gallery file cannot be read.
+func (g *Gallery) CheckFile() error {
+	reader, err := NewGalleryReader(g.Path)
+	if err != nil {
+		return err
+	}
+	return reader.Close()
+}
+
 func (g *Gallery) GetImage(index int) []byte {
-	data, _ := g.readZipFile(index)
+	data, _ := g.readFile(index)
 	return data
 }
 
+// GetThumbnail returns the thumbnail of the image at the index. The image
+// is returned unchanged if it cannot be decoded.
 func (g *Gallery) GetThumbnail(index int) []byte {
-	data, _ := g.readZipFile(index)
-	srcImage, _, err := image.Decode(bytes.NewReader(data))
-	if err != nil {
-		return data
-	}
-	resizedImage := imaging.Resize(srcImage, 100, 0, imaging.NearestNeighbor)
-	buf := new(bytes.Buffer)
-	err = jpeg.Encode(buf, resizedImage, nil)
+	data, _ := g.readFile(index)
+	thumbnail, err := GetGalleryThumbnail(data)
 	if err != nil {
 		return data
 	}
-	return buf.Bytes()
+	return thumbnail
 }
 
-func (g *Gallery) readZipFile(index int) ([]byte, error) {
-	filteredFiles, readCloser, err := g.listZipContents()
+// GalleryThumbnailWidth is the width of the gallery image thumbnails.
+const GalleryThumbnailWidth = 100
+
+// GetGalleryThumbnail returns the image data resized to the thumbnail width
+// and encoded as a jpeg.
+func GetGalleryThumbnail(data []byte) ([]byte, error) {
+	srcImage, _, err := image.Decode(bytes.NewReader(data))
 	if err != nil {
 		return nil, err
 	}
-	defer readCloser.Close()
-
-	zipFile := filteredFiles[index]
-	zipFileReadCloser, err := zipFile.Open()
-	if err != nil {
-		logger.Warn("failed to read file inside zip file")
+	resizedImage := imaging.Resize(srcImage, GalleryThumbnailWidth, 0, imaging.Box)
+	buf := new(bytes.Buffer)
+	if err := jpeg.Encode(buf, resizedImage, nil); err != nil {
 		return nil, err
 	}
-	defer zipFileReadCloser.Close()
-
-	return ioutil.ReadAll(zipFileReadCloser)
+	return buf.Bytes(), nil
 }
 
-func (g *Gallery) listZipContents() ([]*zip.File, *zip.ReadCloser, error) {
-	readCloser, err := zip.OpenReader(g.Path)
+func (g *Gallery) readFile(index int) ([]byte, error) {
+	reader, err := NewGalleryReader(g.Path)
 	if err != nil {
-		logger.Warn("failed to read zip file")
-		return nil, nil, err
+		logger.Warnf("failed to read gallery %s: %s", g.Path, err.Error())
+		return nil, err
 	}
+	defer reader.Close()
 
-	filteredFiles := make([]*zip.File, 0)
-	for _, file := range readCloser.File {
-		if file.FileInfo().IsDir() {
-			continue
-		}
-		ext := filepath.Ext(file.Name)
-		if ext != ".jpg" && ext != ".jpeg" && ext != ".png" && ext != ".gif" {
-			continue
-		}
-		if strings.Contains(file.Name, "__MACOSX") {
-			continue
-		}
-		filteredFiles = append(filteredFiles, file)
+	data, err := reader.Read(index)
+	if err != nil {
+		logger.Warnf("failed to read image %d of gallery %s: %s", index, g.Path, err.Error())
+		return nil, err
 	}
-	sort.Slice(filteredFiles, func(i, j int) bool {
-		a := filteredFiles[i]
-		b := filteredFiles[j]
-		return utils.NaturalCompare(a.Name, b.Name)
-	})
-
-	return filteredFiles, readCloser, nil
+	return data, nil
 }
@@ -0,0 +1,15 @@
+package models
+
+import "database/sql"
+
+// GalleryImage is an image within a gallery, as found when the gallery
+// thumbnails were last generated.
+type GalleryImage struct {
+	ID        int           `db:"id" json:"id"`
+	GalleryID int           `db:"gallery_id" json:"gallery_id"`
+	FileIndex int           `db:"file_index" json:"file_index"`
+	Name      string        `db:"name" json:"name"`
+	Checksum  string        `db:"checksum" json:"checksum"`
+	Width     sql.NullInt64 `db:"width" json:"width"`
+	Height    sql.NullInt64 `db:"height" json:"height"`
+}
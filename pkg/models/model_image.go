@@ -0,0 +1,73 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// Image is a loose image file, or an image within one or more galleries.
+// Images are identified by their checksum, and images in galleries are
+// found through the gallery images with the same checksum.
+type Image struct {
+	ID          int                 `db:"id" json:"id"`
+	Path        sql.NullString      `db:"path" json:"path"`
+	Checksum    string              `db:"checksum" json:"checksum"`
+	Title       sql.NullString      `db:"title" json:"title"`
+	Rating      sql.NullInt64       `db:"rating" json:"rating"`
+	Size        sql.NullString      `db:"size" json:"size"`
+	Width       sql.NullInt64       `db:"width" json:"width"`
+	Height      sql.NullInt64       `db:"height" json:"height"`
+	FileModTime NullSQLiteTimestamp `db:"file_mod_time" json:"file_mod_time"`
+	CreatedAt   SQLiteTimestamp     `db:"created_at" json:"created_at"`
+	UpdatedAt   SQLiteTimestamp     `db:"updated_at" json:"updated_at"`
+}
+
+// ImagePartial holds the image fields to update. Nil fields are not
+// changed.
+type ImagePartial struct {
+	ID          int                  `db:"id" json:"id"`
+	Path        *sql.NullString      `db:"path" json:"path"`
+	Checksum    *string              `db:"checksum" json:"checksum"`
+	Title       *sql.NullString      `db:"title" json:"title"`
+	Rating      *sql.NullInt64       `db:"rating" json:"rating"`
+	Size        *sql.NullString      `db:"size" json:"size"`
+	Width       *sql.NullInt64       `db:"width" json:"width"`
+	Height      *sql.NullInt64       `db:"height" json:"height"`
+	FileModTime *NullSQLiteTimestamp `db:"file_mod_time" json:"file_mod_time"`
+	UpdatedAt   *SQLiteTimestamp     `db:"updated_at" json:"updated_at"`
+}
+
+// GetData returns the contents of the image. The image file is read if it
+// exists, otherwise the image is read from a gallery that contains it.
+func (i *Image) GetData() ([]byte, error) {
+	if i.Path.Valid {
+		data, err := ioutil.ReadFile(i.Path.String)
+		if err == nil {
+			return data, nil
+		}
+	}
+
+	qb := NewGalleryImageQueryBuilder()
+	galleryImages, err := qb.FindByChecksum(i.Checksum, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	gqb := NewGalleryQueryBuilder()
+	for _, galleryImage := range galleryImages {
+		gallery, err := gqb.Find(galleryImage.GalleryID)
+		if err != nil || gallery == nil {
+			continue
+		}
+
+		data, err := gallery.readFile(galleryImage.FileIndex)
+		if err == nil && utils.MD5FromBytes(data) == i.Checksum {
+			return data, nil
+		}
+	}
+
+	return nil, fmt.Errorf("image %s not found", i.Checksum)
+}
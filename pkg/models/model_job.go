@@ -0,0 +1,25 @@
+package models
+
+import (
+	"database/sql"
+)
+
+type Job struct {
+	ID         int                 `db:"id" json:"id"`
+	Type       int                 `db:"type" json:"type"`
+	State      string              `db:"state" json:"state"`
+	Position   int                 `db:"position" json:"position"`
+	Input      sql.NullString      `db:"input" json:"input"`
+	StartedAt  NullSQLiteTimestamp `db:"started_at" json:"started_at"`
+	FinishedAt NullSQLiteTimestamp `db:"finished_at" json:"finished_at"`
+	CreatedAt  SQLiteTimestamp     `db:"created_at" json:"created_at"`
+	UpdatedAt  SQLiteTimestamp     `db:"updated_at" json:"updated_at"`
+}
+
+type JobReportItem struct {
+	ID      int            `db:"id" json:"id"`
+	JobID   int            `db:"job_id" json:"job_id"`
+	Type    string         `db:"type" json:"type"`
+	Path    string         `db:"path" json:"path"`
+	Message sql.NullString `db:"message" json:"message"`
+}
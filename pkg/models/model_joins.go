@@ -14,3 +14,28 @@ type SceneMarkersTags struct {
 	SceneMarkerID int `db:"scene_marker_id" json:"scene_marker_id"`
 	TagID         int `db:"tag_id" json:"tag_id"`
 }
+
+type ScenesGalleries struct {
+	SceneID   int `db:"scene_id" json:"scene_id"`
+	GalleryID int `db:"gallery_id" json:"gallery_id"`
+}
+
+type PerformersGalleries struct {
+	PerformerID int `db:"performer_id" json:"performer_id"`
+	GalleryID   int `db:"gallery_id" json:"gallery_id"`
+}
+
+type GalleriesTags struct {
+	GalleryID int `db:"gallery_id" json:"gallery_id"`
+	TagID     int `db:"tag_id" json:"tag_id"`
+}
+
+type PerformersImages struct {
+	PerformerID int `db:"performer_id" json:"performer_id"`
+	ImageID     int `db:"image_id" json:"image_id"`
+}
+
+type ImagesTags struct {
+	ImageID int `db:"image_id" json:"image_id"`
+	TagID   int `db:"tag_id" json:"tag_id"`
+}
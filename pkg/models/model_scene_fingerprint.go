@@ -0,0 +1,19 @@
+package models
+
+const (
+	// FingerprintTypeMD5 is the MD5 checksum of the scene file. It is stored
+	// in the scenes table rather than as a scene fingerprint.
+	FingerprintTypeMD5 = "md5"
+	// FingerprintTypeOshash is the OpenSubtitles hash of the scene file.
+	FingerprintTypeOshash = "oshash"
+	// FingerprintTypePhash is the perceptual hash of frames sampled from
+	// the scene.
+	FingerprintTypePhash = "phash"
+)
+
+type SceneFingerprint struct {
+	ID          int    `db:"id" json:"id"`
+	SceneID     int    `db:"scene_id" json:"scene_id"`
+	Type        string `db:"type" json:"type"`
+	Fingerprint string `db:"fingerprint" json:"fingerprint"`
+}
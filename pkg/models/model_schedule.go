@@ -0,0 +1,18 @@
+package models
+
+import (
+	"database/sql"
+)
+
+type Schedule struct {
+	ID        int                 `db:"id" json:"id"`
+	Name      string              `db:"name" json:"name"`
+	Schedule  string              `db:"schedule" json:"schedule"`
+	Type      int                 `db:"type" json:"type"`
+	Input     sql.NullString      `db:"input" json:"input"`
+	Enabled   bool                `db:"enabled" json:"enabled"`
+	LastRunAt NullSQLiteTimestamp `db:"last_run_at" json:"last_run_at"`
+	NextRunAt NullSQLiteTimestamp `db:"next_run_at" json:"next_run_at"`
+	CreatedAt SQLiteTimestamp     `db:"created_at" json:"created_at"`
+	UpdatedAt SQLiteTimestamp     `db:"updated_at" json:"updated_at"`
+}
@@ -2,10 +2,12 @@ package models
 
 import (
 	"database/sql"
+	"fmt"
 	"path/filepath"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/utils"
 )
 
 type GalleryQueryBuilder struct{}
@@ -17,8 +19,8 @@ func NewGalleryQueryBuilder() GalleryQueryBuilder {
 func (qb *GalleryQueryBuilder) Create(newGallery Gallery, tx *sqlx.Tx) (*Gallery, error) {
 	ensureTx(tx)
 	result, err := tx.NamedExec(
-		`INSERT INTO galleries (path, checksum, scene_id, created_at, updated_at)
-				VALUES (:path, :checksum, :scene_id, :created_at, :updated_at)
+		`INSERT INTO galleries (path, checksum, title, details, url, date, rating, studio_id, size, file_mod_time, created_at, updated_at)
+				VALUES (:path, :checksum, :title, :details, :url, :date, :rating, :studio_id, :size, :file_mod_time, :created_at, :updated_at)
 		`,
 		newGallery,
 	)
@@ -51,24 +53,31 @@ func (qb *GalleryQueryBuilder) Update(updatedGallery Gallery, tx *sqlx.Tx) (*Gal
 	return &updatedGallery, nil
 }
 
-type GalleryNullSceneID struct {
-	SceneID sql.NullInt64
-}
-
-func (qb *GalleryQueryBuilder) ClearGalleryId(sceneID int, tx *sqlx.Tx) error {
+func (qb *GalleryQueryBuilder) UpdatePartial(updatedGallery GalleryPartial, tx *sqlx.Tx) (*Gallery, error) {
 	ensureTx(tx)
 	_, err := tx.NamedExec(
-		`UPDATE galleries SET scene_id = null WHERE scene_id = :sceneid`,
-		GalleryNullSceneID{
-			SceneID: sql.NullInt64{
-				Int64: int64(sceneID),
-				Valid: true,
-			},
-		},
+		`UPDATE galleries SET `+SQLGenKeysPartial(updatedGallery)+` WHERE galleries.id = :id`,
+		updatedGallery,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	return qb.queryGallery("SELECT * FROM galleries WHERE id = ? LIMIT 1", []interface{}{updatedGallery.ID}, tx)
+}
+
+// SetCorrupt sets whether the file of the gallery with the given id could
+// not be read.
+func (qb *GalleryQueryBuilder) SetCorrupt(id int, corrupt bool, tx *sqlx.Tx) error {
+	ensureTx(tx)
+	_, err := tx.Exec(`UPDATE galleries SET corrupt = ? WHERE id = ?`, corrupt, id)
 	return err
 }
 
+func (qb *GalleryQueryBuilder) Destroy(id string, tx *sqlx.Tx) error {
+	return executeDeleteQuery("galleries", id, tx)
+}
+
 func (qb *GalleryQueryBuilder) Find(id int) (*Gallery, error) {
 	query := "SELECT * FROM galleries WHERE id = ? LIMIT 1"
 	args := []interface{}{id}
@@ -87,18 +96,50 @@ func (qb *GalleryQueryBuilder) FindByPath(path string) (*Gallery, error) {
 	return qb.queryGallery(query, args, nil)
 }
 
-func (qb *GalleryQueryBuilder) FindBySceneID(sceneID int, tx *sqlx.Tx) (*Gallery, error) {
-	query := "SELECT galleries.* FROM galleries JOIN scenes ON scenes.id = galleries.scene_id WHERE scenes.id = ? LIMIT 1"
+// FindBySize returns the galleries with the given file size.
+func (qb *GalleryQueryBuilder) FindBySize(size string) ([]*Gallery, error) {
+	query := "SELECT * FROM galleries WHERE size = ?"
+	args := []interface{}{size}
+	return qb.queryGalleries(query, args, nil)
+}
+
+func (qb *GalleryQueryBuilder) FindBySceneID(sceneID int, tx *sqlx.Tx) ([]*Gallery, error) {
+	query := `
+		SELECT galleries.* FROM galleries
+		LEFT JOIN scenes_galleries as scenes_join on scenes_join.gallery_id = galleries.id
+		WHERE scenes_join.scene_id = ?
+		GROUP BY galleries.id
+		ORDER BY galleries.path ASC
+	`
 	args := []interface{}{sceneID}
-	return qb.queryGallery(query, args, tx)
+	return qb.queryGalleries(query, args, tx)
+}
+
+// FindByImageChecksum returns the galleries that contain the image with the
+// given checksum.
+func (qb *GalleryQueryBuilder) FindByImageChecksum(checksum string, tx *sqlx.Tx) ([]*Gallery, error) {
+	query := `
+		SELECT DISTINCT galleries.* FROM galleries
+		JOIN gallery_images ON gallery_images.gallery_id = galleries.id
+		WHERE gallery_images.checksum = ?
+		ORDER BY galleries.path ASC
+	`
+	args := []interface{}{checksum}
+	return qb.queryGalleries(query, args, tx)
 }
 
 func (qb *GalleryQueryBuilder) ValidGalleriesForScenePath(scenePath string) ([]*Gallery, error) {
 	sceneDirPath := filepath.Dir(scenePath)
-	query := "SELECT galleries.* FROM galleries WHERE galleries.scene_id IS NULL AND galleries.path LIKE '" + sceneDirPath + "%' ORDER BY path ASC"
+	query := "SELECT galleries.* FROM galleries WHERE galleries.path LIKE '" + sceneDirPath + "%' ORDER BY path ASC"
 	return qb.queryGalleries(query, nil, nil)
 }
 
+func (qb *GalleryQueryBuilder) QueryAllByPathRegex(regex string) ([]*Gallery, error) {
+	query := "SELECT * FROM galleries WHERE path regexp ?"
+	args := []interface{}{"(?i)" + regex}
+	return qb.queryGalleries(query, args, nil)
+}
+
 func (qb *GalleryQueryBuilder) Count() (int, error) {
 	return runCountQuery(buildCountQuery("SELECT galleries.id FROM galleries"), nil)
 }
@@ -107,7 +148,14 @@ func (qb *GalleryQueryBuilder) All() ([]*Gallery, error) {
 	return qb.queryGalleries(selectAll("galleries")+qb.getGallerySort(nil), nil, nil)
 }
 
-func (qb *GalleryQueryBuilder) Query(findFilter *FindFilterType) ([]*Gallery, int) {
+// galleryMissingColumns are the gallery columns that can be filtered on
+// being missing.
+var galleryMissingColumns = []string{"title", "details", "url", "rating"}
+
+func (qb *GalleryQueryBuilder) Query(galleryFilter *GalleryFilterType, findFilter *FindFilterType) ([]*Gallery, int, error) {
+	if galleryFilter == nil {
+		galleryFilter = &GalleryFilterType{}
+	}
 	if findFilter == nil {
 		findFilter = &FindFilterType{}
 	}
@@ -116,12 +164,80 @@ func (qb *GalleryQueryBuilder) Query(findFilter *FindFilterType) ([]*Gallery, in
 	var havingClauses []string
 	var args []interface{}
 	body := selectDistinctIDs("galleries")
+	body = body + `
+		left join performers_galleries as performers_join on performers_join.gallery_id = galleries.id
+		left join performers on performers_join.performer_id = performers.id
+		left join studios as studio on studio.id = galleries.studio_id
+		left join galleries_tags as tags_join on tags_join.gallery_id = galleries.id
+		left join tags on tags_join.tag_id = tags.id
+		left join scenes_galleries as scenes_join on scenes_join.gallery_id = galleries.id
+	`
 
 	if q := findFilter.Q; q != nil && *q != "" {
-		searchColumns := []string{"galleries.path", "galleries.checksum"}
+		searchColumns := []string{"galleries.title", "galleries.details", "galleries.path", "galleries.checksum"}
 		whereClauses = append(whereClauses, getSearch(searchColumns, *q))
 	}
 
+	if rating := galleryFilter.Rating; rating != nil {
+		clause, count := getIntCriterionWhereClause("galleries.rating", *galleryFilter.Rating)
+		whereClauses = append(whereClauses, clause)
+		if count == 1 {
+			args = append(args, galleryFilter.Rating.Value)
+		}
+	}
+
+	if isMissingFilter := galleryFilter.IsMissing; isMissingFilter != nil && *isMissingFilter != "" {
+		switch *isMissingFilter {
+		case "file":
+			whereClauses = append(whereClauses, "galleries.corrupt = 1")
+		case "scene":
+			whereClauses = append(whereClauses, "scenes_join.gallery_id IS NULL")
+		case "studio":
+			whereClauses = append(whereClauses, "galleries.studio_id IS NULL")
+		case "performers":
+			whereClauses = append(whereClauses, "performers_join.gallery_id IS NULL")
+		case "tags":
+			whereClauses = append(whereClauses, "tags_join.gallery_id IS NULL")
+		case "date":
+			whereClauses = append(whereClauses, "galleries.date IS \"\" OR galleries.date IS \"0001-01-01\"")
+		default:
+			if !utils.StrInclude(galleryMissingColumns, *isMissingFilter) {
+				return nil, 0, fmt.Errorf("invalid is_missing value %s", *isMissingFilter)
+			}
+			whereClauses = append(whereClauses, "galleries."+*isMissingFilter+" IS NULL")
+		}
+	}
+
+	if tagsFilter := galleryFilter.Tags; tagsFilter != nil && len(tagsFilter.Value) > 0 {
+		for _, tagID := range tagsFilter.Value {
+			args = append(args, tagID)
+		}
+
+		whereClause, havingClause := getMultiCriterionClauseForTable("galleries", "gallery_id", "tags", "galleries_tags", "tag_id", tagsFilter)
+		whereClauses = appendClause(whereClauses, whereClause)
+		havingClauses = appendClause(havingClauses, havingClause)
+	}
+
+	if performersFilter := galleryFilter.Performers; performersFilter != nil && len(performersFilter.Value) > 0 {
+		for _, performerID := range performersFilter.Value {
+			args = append(args, performerID)
+		}
+
+		whereClause, havingClause := getMultiCriterionClauseForTable("galleries", "gallery_id", "performers", "performers_galleries", "performer_id", performersFilter)
+		whereClauses = appendClause(whereClauses, whereClause)
+		havingClauses = appendClause(havingClauses, havingClause)
+	}
+
+	if studiosFilter := galleryFilter.Studios; studiosFilter != nil && len(studiosFilter.Value) > 0 {
+		for _, studioID := range studiosFilter.Value {
+			args = append(args, studioID)
+		}
+
+		whereClause, havingClause := getMultiCriterionClauseForTable("galleries", "gallery_id", "studio", "", "studio_id", studiosFilter)
+		whereClauses = appendClause(whereClauses, whereClause)
+		havingClauses = appendClause(havingClauses, havingClause)
+	}
+
 	sortAndPagination := qb.getGallerySort(findFilter) + getPagination(findFilter)
 	idsResult, countResult := executeFindQuery("galleries", body, args, sortAndPagination, whereClauses, havingClauses)
 
@@ -131,19 +247,19 @@ func (qb *GalleryQueryBuilder) Query(findFilter *FindFilterType) ([]*Gallery, in
 		galleries = append(galleries, gallery)
 	}
 
-	return galleries, countResult
+	return galleries, countResult, nil
 }
 
 func (qb *GalleryQueryBuilder) getGallerySort(findFilter *FindFilterType) string {
 	var sort string
 	var direction string
-	//if findFilter == nil { // TODO temp until title is removed from schema and UI
-	sort = "path"
-	direction = "ASC"
-	//} else {
-	//	sort = findFilter.getSort("path")
-	//	direction = findFilter.getDirection()
-	//}
+	if findFilter == nil {
+		sort = "path"
+		direction = "ASC"
+	} else {
+		sort = findFilter.GetSort("path")
+		direction = findFilter.GetDirection()
+	}
 	return getSort(sort, direction, "galleries")
 }
 
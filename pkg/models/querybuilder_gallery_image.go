@@ -0,0 +1,112 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/database"
+)
+
+type GalleryImageQueryBuilder struct{}
+
+func NewGalleryImageQueryBuilder() GalleryImageQueryBuilder {
+	return GalleryImageQueryBuilder{}
+}
+
+// Replace replaces the images of the gallery with the given images.
+func (qb *GalleryImageQueryBuilder) Replace(galleryID int, images []GalleryImage, tx *sqlx.Tx) error {
+	ensureTx(tx)
+	if err := qb.DestroyByGalleryID(galleryID, tx); err != nil {
+		return err
+	}
+
+	for _, image := range images {
+		image.GalleryID = galleryID
+		_, err := tx.NamedExec(
+			`INSERT INTO gallery_images (gallery_id, file_index, name, checksum, width, height)
+					VALUES (:gallery_id, :file_index, :name, :checksum, :width, :height)
+			`,
+			image,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (qb *GalleryImageQueryBuilder) DestroyByGalleryID(galleryID int, tx *sqlx.Tx) error {
+	ensureTx(tx)
+	_, err := tx.Exec("DELETE FROM gallery_images WHERE gallery_id = ?", galleryID)
+	return err
+}
+
+// Find returns the image of the gallery at the given index, or nil if the
+// gallery thumbnails have not been generated.
+func (qb *GalleryImageQueryBuilder) Find(galleryID int, fileIndex int, tx *sqlx.Tx) (*GalleryImage, error) {
+	query := "SELECT * FROM gallery_images WHERE gallery_id = ? AND file_index = ? LIMIT 1"
+	args := []interface{}{galleryID, fileIndex}
+	return qb.queryImage(query, args, tx)
+}
+
+func (qb *GalleryImageQueryBuilder) FindByGalleryID(galleryID int, tx *sqlx.Tx) ([]*GalleryImage, error) {
+	query := "SELECT * FROM gallery_images WHERE gallery_id = ? ORDER BY file_index ASC"
+	args := []interface{}{galleryID}
+	return qb.queryImages(query, args, tx)
+}
+
+// FindByChecksum returns the images in any gallery with the checksum.
+func (qb *GalleryImageQueryBuilder) FindByChecksum(checksum string, tx *sqlx.Tx) ([]*GalleryImage, error) {
+	query := "SELECT * FROM gallery_images WHERE checksum = ? ORDER BY gallery_id ASC, file_index ASC"
+	args := []interface{}{checksum}
+	return qb.queryImages(query, args, tx)
+}
+
+// ThumbnailChecksums returns the checksums of the images in galleries and of
+// the loose images, which name the thumbnails that are in use.
+func (qb *GalleryImageQueryBuilder) ThumbnailChecksums() ([]string, error) {
+	var ret []string
+	query := "SELECT checksum FROM gallery_images UNION SELECT checksum FROM images"
+	if err := database.DB.Select(&ret, query); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (qb *GalleryImageQueryBuilder) queryImage(query string, args []interface{}, tx *sqlx.Tx) (*GalleryImage, error) {
+	results, err := qb.queryImages(query, args, tx)
+	if err != nil || len(results) < 1 {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+func (qb *GalleryImageQueryBuilder) queryImages(query string, args []interface{}, tx *sqlx.Tx) ([]*GalleryImage, error) {
+	var rows *sqlx.Rows
+	var err error
+	if tx != nil {
+		rows, err = tx.Queryx(query, args...)
+	} else {
+		rows, err = database.DB.Queryx(query, args...)
+	}
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	defer rows.Close()
+
+	images := make([]*GalleryImage, 0)
+	for rows.Next() {
+		image := GalleryImage{}
+		if err := rows.StructScan(&image); err != nil {
+			return nil, err
+		}
+		images = append(images, &image)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return images, nil
+}
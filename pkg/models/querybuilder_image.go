@@ -0,0 +1,245 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+type ImageQueryBuilder struct{}
+
+func NewImageQueryBuilder() ImageQueryBuilder {
+	return ImageQueryBuilder{}
+}
+
+func (qb *ImageQueryBuilder) Create(newImage Image, tx *sqlx.Tx) (*Image, error) {
+	ensureTx(tx)
+	result, err := tx.NamedExec(
+		`INSERT INTO images (path, checksum, title, rating, size, width, height, file_mod_time, created_at, updated_at)
+				VALUES (:path, :checksum, :title, :rating, :size, :width, :height, :file_mod_time, :created_at, :updated_at)
+		`,
+		newImage,
+	)
+	if err != nil {
+		return nil, err
+	}
+	imageID, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return qb.find(int(imageID), tx)
+}
+
+func (qb *ImageQueryBuilder) Update(updatedImage ImagePartial, tx *sqlx.Tx) (*Image, error) {
+	ensureTx(tx)
+	_, err := tx.NamedExec(
+		`UPDATE images SET `+SQLGenKeysPartial(updatedImage)+` WHERE images.id = :id`,
+		updatedImage,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return qb.find(updatedImage.ID, tx)
+}
+
+func (qb *ImageQueryBuilder) Destroy(id string, tx *sqlx.Tx) error {
+	return executeDeleteQuery("images", id, tx)
+}
+
+func (qb *ImageQueryBuilder) Find(id int) (*Image, error) {
+	return qb.find(id, nil)
+}
+
+func (qb *ImageQueryBuilder) find(id int, tx *sqlx.Tx) (*Image, error) {
+	query := "SELECT * FROM images WHERE id = ? LIMIT 1"
+	args := []interface{}{id}
+	return qb.queryImage(query, args, tx)
+}
+
+func (qb *ImageQueryBuilder) FindByChecksum(checksum string, tx *sqlx.Tx) (*Image, error) {
+	query := "SELECT * FROM images WHERE checksum = ? LIMIT 1"
+	args := []interface{}{checksum}
+	return qb.queryImage(query, args, tx)
+}
+
+func (qb *ImageQueryBuilder) FindByPath(path string) (*Image, error) {
+	query := "SELECT * FROM images WHERE path = ? LIMIT 1"
+	args := []interface{}{path}
+	return qb.queryImage(query, args, nil)
+}
+
+// FindByGalleryID returns the images in the gallery, in gallery order.
+func (qb *ImageQueryBuilder) FindByGalleryID(galleryID int, tx *sqlx.Tx) ([]*Image, error) {
+	query := `
+		SELECT images.* FROM images
+		JOIN gallery_images ON gallery_images.checksum = images.checksum
+		WHERE gallery_images.gallery_id = ?
+		GROUP BY images.id
+		ORDER BY MIN(gallery_images.file_index) ASC
+	`
+	args := []interface{}{galleryID}
+	return qb.queryImages(query, args, tx)
+}
+
+func (qb *ImageQueryBuilder) All() ([]*Image, error) {
+	return qb.queryImages(selectAll("images")+qb.getImageSort(nil), nil, nil)
+}
+
+func (qb *ImageQueryBuilder) QueryAllByPathRegex(regex string) ([]*Image, error) {
+	query := "SELECT * FROM images WHERE path regexp ?"
+	args := []interface{}{"(?i)" + regex}
+	return qb.queryImages(query, args, nil)
+}
+
+func (qb *ImageQueryBuilder) Count() (int, error) {
+	return runCountQuery(buildCountQuery("SELECT images.id FROM images"), nil)
+}
+
+// imageMissingColumns are the image columns that can be filtered on being
+// missing.
+var imageMissingColumns = []string{"path", "title", "rating"}
+
+func (qb *ImageQueryBuilder) Query(imageFilter *ImageFilterType, findFilter *FindFilterType) ([]*Image, int, error) {
+	if imageFilter == nil {
+		imageFilter = &ImageFilterType{}
+	}
+	if findFilter == nil {
+		findFilter = &FindFilterType{}
+	}
+
+	var whereClauses []string
+	var havingClauses []string
+	var args []interface{}
+	body := selectDistinctIDs("images")
+	body = body + `
+		left join performers_images as performers_join on performers_join.image_id = images.id
+		left join performers on performers_join.performer_id = performers.id
+		left join images_tags as tags_join on tags_join.image_id = images.id
+		left join tags on tags_join.tag_id = tags.id
+		left join gallery_images on gallery_images.checksum = images.checksum
+	`
+
+	if q := findFilter.Q; q != nil && *q != "" {
+		searchColumns := []string{"images.title", "images.path", "images.checksum", "gallery_images.name"}
+		whereClauses = append(whereClauses, getSearch(searchColumns, *q))
+	}
+
+	if rating := imageFilter.Rating; rating != nil {
+		clause, count := getIntCriterionWhereClause("images.rating", *imageFilter.Rating)
+		whereClauses = append(whereClauses, clause)
+		if count == 1 {
+			args = append(args, imageFilter.Rating.Value)
+		}
+	}
+
+	if isMissingFilter := imageFilter.IsMissing; isMissingFilter != nil && *isMissingFilter != "" {
+		switch *isMissingFilter {
+		case "gallery":
+			whereClauses = append(whereClauses, "gallery_images.gallery_id IS NULL")
+		case "performers":
+			whereClauses = append(whereClauses, "performers_join.image_id IS NULL")
+		case "tags":
+			whereClauses = append(whereClauses, "tags_join.image_id IS NULL")
+		default:
+			if !utils.StrInclude(imageMissingColumns, *isMissingFilter) {
+				return nil, 0, fmt.Errorf("invalid is_missing value %s", *isMissingFilter)
+			}
+			whereClauses = append(whereClauses, "images."+*isMissingFilter+" IS NULL")
+		}
+	}
+
+	if galleriesFilter := imageFilter.Galleries; galleriesFilter != nil && len(galleriesFilter.Value) > 0 {
+		for _, galleryID := range galleriesFilter.Value {
+			args = append(args, galleryID)
+		}
+
+		whereClause := "gallery_images.gallery_id IN " + getInBinding(len(galleriesFilter.Value))
+		whereClauses = append(whereClauses, whereClause)
+	}
+
+	if tagsFilter := imageFilter.Tags; tagsFilter != nil && len(tagsFilter.Value) > 0 {
+		for _, tagID := range tagsFilter.Value {
+			args = append(args, tagID)
+		}
+
+		whereClause, havingClause := getMultiCriterionClauseForTable("images", "image_id", "tags", "images_tags", "tag_id", tagsFilter)
+		whereClauses = appendClause(whereClauses, whereClause)
+		havingClauses = appendClause(havingClauses, havingClause)
+	}
+
+	if performersFilter := imageFilter.Performers; performersFilter != nil && len(performersFilter.Value) > 0 {
+		for _, performerID := range performersFilter.Value {
+			args = append(args, performerID)
+		}
+
+		whereClause, havingClause := getMultiCriterionClauseForTable("images", "image_id", "performers", "performers_images", "performer_id", performersFilter)
+		whereClauses = appendClause(whereClauses, whereClause)
+		havingClauses = appendClause(havingClauses, havingClause)
+	}
+
+	sortAndPagination := qb.getImageSort(findFilter) + getPagination(findFilter)
+	idsResult, countResult := executeFindQuery("images", body, args, sortAndPagination, whereClauses, havingClauses)
+
+	var images []*Image
+	for _, id := range idsResult {
+		image, _ := qb.Find(id)
+		images = append(images, image)
+	}
+
+	return images, countResult, nil
+}
+
+func (qb *ImageQueryBuilder) getImageSort(findFilter *FindFilterType) string {
+	var sort string
+	var direction string
+	if findFilter == nil {
+		sort = "path"
+		direction = "ASC"
+	} else {
+		sort = findFilter.GetSort("path")
+		direction = findFilter.GetDirection()
+	}
+	return getSort(sort, direction, "images")
+}
+
+func (qb *ImageQueryBuilder) queryImage(query string, args []interface{}, tx *sqlx.Tx) (*Image, error) {
+	results, err := qb.queryImages(query, args, tx)
+	if err != nil || len(results) < 1 {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+func (qb *ImageQueryBuilder) queryImages(query string, args []interface{}, tx *sqlx.Tx) ([]*Image, error) {
+	var rows *sqlx.Rows
+	var err error
+	if tx != nil {
+		rows, err = tx.Queryx(query, args...)
+	} else {
+		rows, err = database.DB.Queryx(query, args...)
+	}
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	defer rows.Close()
+
+	images := make([]*Image, 0)
+	for rows.Next() {
+		image := Image{}
+		if err := rows.StructScan(&image); err != nil {
+			return nil, err
+		}
+		images = append(images, &image)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return images, nil
+}
@@ -0,0 +1,236 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/database"
+)
+
+type JobQueryBuilder struct{}
+
+func NewJobQueryBuilder() JobQueryBuilder {
+	return JobQueryBuilder{}
+}
+
+func (qb *JobQueryBuilder) Create(newJob Job, tx *sqlx.Tx) (*Job, error) {
+	ensureTx(tx)
+	result, err := tx.NamedExec(
+		`INSERT INTO jobs (type, state, position, input, created_at, updated_at)
+				VALUES (:type, :state, :position, :input, :created_at, :updated_at)
+		`,
+		newJob,
+	)
+	if err != nil {
+		return nil, err
+	}
+	jobID, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Get(&newJob, `SELECT * FROM jobs WHERE id = ? LIMIT 1`, jobID); err != nil {
+		return nil, err
+	}
+	return &newJob, nil
+}
+
+func (qb *JobQueryBuilder) Update(updatedJob Job, tx *sqlx.Tx) (*Job, error) {
+	ensureTx(tx)
+	_, err := tx.NamedExec(
+		`UPDATE jobs SET `+SQLGenKeys(updatedJob)+` WHERE jobs.id = :id`,
+		updatedJob,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return qb.Find(updatedJob.ID, tx)
+}
+
+// UpdateState sets the state of the job with the given id. It is not an
+// error if the job no longer exists.
+func (qb *JobQueryBuilder) UpdateState(id int, state JobState, tx *sqlx.Tx) error {
+	ensureTx(tx)
+	_, err := tx.Exec(
+		`UPDATE jobs SET state = ?, updated_at = ? WHERE id = ?`,
+		state.String(), SQLiteTimestamp{Timestamp: time.Now()}, id,
+	)
+	return err
+}
+
+// Start marks the job with the given id as running.
+func (qb *JobQueryBuilder) Start(id int, tx *sqlx.Tx) error {
+	ensureTx(tx)
+	currentTime := SQLiteTimestamp{Timestamp: time.Now()}
+	_, err := tx.Exec(
+		`UPDATE jobs SET state = ?, started_at = ?, updated_at = ? WHERE id = ?`,
+		JobStateRunning.String(), currentTime, currentTime, id,
+	)
+	return err
+}
+
+// Finish sets the final state of the job with the given id.
+func (qb *JobQueryBuilder) Finish(id int, state JobState, tx *sqlx.Tx) error {
+	ensureTx(tx)
+	currentTime := SQLiteTimestamp{Timestamp: time.Now()}
+	_, err := tx.Exec(
+		`UPDATE jobs SET state = ?, finished_at = ?, updated_at = ? WHERE id = ?`,
+		state.String(), currentTime, currentTime, id,
+	)
+	return err
+}
+
+// ResetRunning returns jobs that were left running, for example because
+// the server was stopped, to the queue.
+func (qb *JobQueryBuilder) ResetRunning(tx *sqlx.Tx) error {
+	ensureTx(tx)
+	_, err := tx.Exec(
+		`UPDATE jobs SET state = ? WHERE state = ?`,
+		JobStateQueued.String(), JobStateRunning.String(),
+	)
+	return err
+}
+
+func (qb *JobQueryBuilder) Destroy(id string, tx *sqlx.Tx) error {
+	return executeDeleteQuery("jobs", id, tx)
+}
+
+func (qb *JobQueryBuilder) Find(id int, tx *sqlx.Tx) (*Job, error) {
+	query := "SELECT * FROM jobs WHERE id = ? LIMIT 1"
+	args := []interface{}{id}
+	return qb.queryJob(query, args, tx)
+}
+
+// FindByState returns the jobs in the given states in queue order.
+func (qb *JobQueryBuilder) FindByState(states []JobState, tx *sqlx.Tx) ([]*Job, error) {
+	query := "SELECT * FROM jobs WHERE state IN " + getInBinding(len(states)) + " ORDER BY position ASC, id ASC"
+	var args []interface{}
+	for _, state := range states {
+		args = append(args, state.String())
+	}
+	return qb.queryJobs(query, args, tx)
+}
+
+// FindNextQueued returns the queued job that should be run next, or nil if
+// the queue is empty.
+func (qb *JobQueryBuilder) FindNextQueued(tx *sqlx.Tx) (*Job, error) {
+	query := "SELECT * FROM jobs WHERE state = ? ORDER BY position ASC, id ASC LIMIT 1"
+	args := []interface{}{JobStateQueued.String()}
+	return qb.queryJob(query, args, tx)
+}
+
+// FindHistory returns the jobs that have finished, been cancelled or failed,
+// most recent first. All jobs are returned if count is less than 1.
+func (qb *JobQueryBuilder) FindHistory(count int, tx *sqlx.Tx) ([]*Job, error) {
+	states := []JobState{JobStateFinished, JobStateCancelled, JobStateFailed}
+	query := "SELECT * FROM jobs WHERE state IN " + getInBinding(len(states)) + " ORDER BY updated_at DESC, id DESC"
+	var args []interface{}
+	for _, state := range states {
+		args = append(args, state.String())
+	}
+	if count > 0 {
+		query += " LIMIT ?"
+		args = append(args, count)
+	}
+	return qb.queryJobs(query, args, tx)
+}
+
+// GetMaxPosition returns the largest queue position in use, or 0 if there
+// are no jobs.
+func (qb *JobQueryBuilder) GetMaxPosition(tx *sqlx.Tx) (int, error) {
+	ensureTx(tx)
+	var ret sql.NullInt64
+	if err := tx.Get(&ret, `SELECT MAX(position) FROM jobs`); err != nil {
+		return 0, err
+	}
+	return int(ret.Int64), nil
+}
+
+func (qb *JobQueryBuilder) queryJob(query string, args []interface{}, tx *sqlx.Tx) (*Job, error) {
+	results, err := qb.queryJobs(query, args, tx)
+	if err != nil || len(results) < 1 {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+func (qb *JobQueryBuilder) queryJobs(query string, args []interface{}, tx *sqlx.Tx) ([]*Job, error) {
+	var rows *sqlx.Rows
+	var err error
+	if tx != nil {
+		rows, err = tx.Queryx(query, args...)
+	} else {
+		rows, err = database.DB.Queryx(query, args...)
+	}
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := make([]*Job, 0)
+	for rows.Next() {
+		job := Job{}
+		if err := rows.StructScan(&job); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, &job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+func (qb *JobQueryBuilder) CreateReportItems(jobID int, items []JobReportItem, tx *sqlx.Tx) error {
+	ensureTx(tx)
+	for _, item := range items {
+		item.JobID = jobID
+		_, err := tx.NamedExec(
+			`INSERT INTO job_report_items (job_id, type, path, message)
+					VALUES (:job_id, :type, :path, :message)
+			`,
+			item,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (qb *JobQueryBuilder) GetReportItems(jobID int, tx *sqlx.Tx) ([]*JobReportItem, error) {
+	query := "SELECT * FROM job_report_items WHERE job_id = ? ORDER BY id ASC"
+	args := []interface{}{jobID}
+
+	var rows *sqlx.Rows
+	var err error
+	if tx != nil {
+		rows, err = tx.Queryx(query, args...)
+	} else {
+		rows, err = database.DB.Queryx(query, args...)
+	}
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]*JobReportItem, 0)
+	for rows.Next() {
+		item := JobReportItem{}
+		if err := rows.StructScan(&item); err != nil {
+			return nil, err
+		}
+		items = append(items, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
@@ -242,11 +242,100 @@ func (qb *JoinsQueryBuilder) DestroySceneMarkersTags(sceneMarkerID int, updatedJ
 	return err
 }
 
+func (qb *JoinsQueryBuilder) GetSceneGalleries(sceneID int, tx *sqlx.Tx) ([]ScenesGalleries, error) {
+	ensureTx(tx)
+
+	query := `SELECT * from scenes_galleries WHERE scene_id = ?`
+
+	var rows *sqlx.Rows
+	var err error
+	if tx != nil {
+		rows, err = tx.Queryx(query, sceneID)
+	} else {
+		rows, err = database.DB.Queryx(query, sceneID)
+	}
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sceneGalleries := make([]ScenesGalleries, 0)
+	for rows.Next() {
+		sceneGallery := ScenesGalleries{}
+		if err := rows.StructScan(&sceneGallery); err != nil {
+			return nil, err
+		}
+		sceneGalleries = append(sceneGalleries, sceneGallery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sceneGalleries, nil
+}
+
+func (qb *JoinsQueryBuilder) CreateScenesGalleries(newJoins []ScenesGalleries, tx *sqlx.Tx) error {
+	ensureTx(tx)
+	for _, join := range newJoins {
+		_, err := tx.NamedExec(
+			`INSERT INTO scenes_galleries (scene_id, gallery_id) VALUES (:scene_id, :gallery_id)`,
+			join,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (qb *JoinsQueryBuilder) UpdateScenesGalleries(sceneID int, updatedJoins []ScenesGalleries, tx *sqlx.Tx) error {
+	ensureTx(tx)
+
+	// Delete the existing joins and then create new ones
+	_, err := tx.Exec("DELETE FROM scenes_galleries WHERE scene_id = ?", sceneID)
+	if err != nil {
+		return err
+	}
+	return qb.CreateScenesGalleries(updatedJoins, tx)
+}
+
+// AddSceneGallery links a gallery to a scene. It does not make any change
+// if the gallery is already linked to the scene. It returns true if the
+// scene gallery was added.
+func (qb *JoinsQueryBuilder) AddSceneGallery(sceneID int, galleryID int, tx *sqlx.Tx) (bool, error) {
+	ensureTx(tx)
+
+	existingGalleries, err := qb.GetSceneGalleries(sceneID, tx)
+
+	if err != nil {
+		return false, err
+	}
+
+	// ensure not already present
+	for _, g := range existingGalleries {
+		if g.GalleryID == galleryID && g.SceneID == sceneID {
+			return false, nil
+		}
+	}
+
+	galleryJoin := ScenesGalleries{
+		SceneID:   sceneID,
+		GalleryID: galleryID,
+	}
+	galleryJoins := append(existingGalleries, galleryJoin)
+
+	err = qb.UpdateScenesGalleries(sceneID, galleryJoins, tx)
+
+	return err == nil, err
+}
+
 func (qb *JoinsQueryBuilder) DestroyScenesGalleries(sceneID int, tx *sqlx.Tx) error {
 	ensureTx(tx)
 
-	// Unset the existing scene id from galleries
-	_, err := tx.Exec("UPDATE galleries SET scene_id = null WHERE scene_id = ?", sceneID)
+	// Delete the existing joins
+	_, err := tx.Exec("DELETE FROM scenes_galleries WHERE scene_id = ?", sceneID)
 
 	return err
 }
@@ -262,3 +351,103 @@ func (qb *JoinsQueryBuilder) DestroyScenesMarkers(sceneID int, tx *sqlx.Tx) erro
 
 	return err
 }
+
+func (qb *JoinsQueryBuilder) CreatePerformersGalleries(newJoins []PerformersGalleries, tx *sqlx.Tx) error {
+	ensureTx(tx)
+	for _, join := range newJoins {
+		_, err := tx.NamedExec(
+			`INSERT INTO performers_galleries (performer_id, gallery_id) VALUES (:performer_id, :gallery_id)`,
+			join,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (qb *JoinsQueryBuilder) UpdatePerformersGalleries(galleryID int, updatedJoins []PerformersGalleries, tx *sqlx.Tx) error {
+	ensureTx(tx)
+
+	// Delete the existing joins and then create new ones
+	_, err := tx.Exec("DELETE FROM performers_galleries WHERE gallery_id = ?", galleryID)
+	if err != nil {
+		return err
+	}
+	return qb.CreatePerformersGalleries(updatedJoins, tx)
+}
+
+func (qb *JoinsQueryBuilder) CreateGalleriesTags(newJoins []GalleriesTags, tx *sqlx.Tx) error {
+	ensureTx(tx)
+	for _, join := range newJoins {
+		_, err := tx.NamedExec(
+			`INSERT INTO galleries_tags (gallery_id, tag_id) VALUES (:gallery_id, :tag_id)`,
+			join,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (qb *JoinsQueryBuilder) UpdateGalleriesTags(galleryID int, updatedJoins []GalleriesTags, tx *sqlx.Tx) error {
+	ensureTx(tx)
+
+	// Delete the existing joins and then create new ones
+	_, err := tx.Exec("DELETE FROM galleries_tags WHERE gallery_id = ?", galleryID)
+	if err != nil {
+		return err
+	}
+	return qb.CreateGalleriesTags(updatedJoins, tx)
+}
+
+func (qb *JoinsQueryBuilder) CreatePerformersImages(newJoins []PerformersImages, tx *sqlx.Tx) error {
+	ensureTx(tx)
+	for _, join := range newJoins {
+		_, err := tx.NamedExec(
+			`INSERT INTO performers_images (performer_id, image_id) VALUES (:performer_id, :image_id)`,
+			join,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (qb *JoinsQueryBuilder) UpdatePerformersImages(imageID int, updatedJoins []PerformersImages, tx *sqlx.Tx) error {
+	ensureTx(tx)
+
+	// Delete the existing joins and then create new ones
+	_, err := tx.Exec("DELETE FROM performers_images WHERE image_id = ?", imageID)
+	if err != nil {
+		return err
+	}
+	return qb.CreatePerformersImages(updatedJoins, tx)
+}
+
+func (qb *JoinsQueryBuilder) CreateImagesTags(newJoins []ImagesTags, tx *sqlx.Tx) error {
+	ensureTx(tx)
+	for _, join := range newJoins {
+		_, err := tx.NamedExec(
+			`INSERT INTO images_tags (image_id, tag_id) VALUES (:image_id, :tag_id)`,
+			join,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (qb *JoinsQueryBuilder) UpdateImagesTags(imageID int, updatedJoins []ImagesTags, tx *sqlx.Tx) error {
+	ensureTx(tx)
+
+	// Delete the existing joins and then create new ones
+	_, err := tx.Exec("DELETE FROM images_tags WHERE image_id = ?", imageID)
+	if err != nil {
+		return err
+	}
+	return qb.CreateImagesTags(updatedJoins, tx)
+}
@@ -63,6 +63,16 @@ func (qb *PerformerQueryBuilder) Destroy(id string, tx *sqlx.Tx) error {
 		return err
 	}
 
+	_, err = tx.Exec("DELETE FROM performers_galleries WHERE performer_id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM performers_images WHERE performer_id = ?", id)
+	if err != nil {
+		return err
+	}
+
 	return executeDeleteQuery("performers", id, tx)
 }
 
@@ -88,6 +98,28 @@ func (qb *PerformerQueryBuilder) FindBySceneID(sceneID int, tx *sqlx.Tx) ([]*Per
 	return qb.queryPerformers(query, args, tx)
 }
 
+func (qb *PerformerQueryBuilder) FindByGalleryID(galleryID int, tx *sqlx.Tx) ([]*Performer, error) {
+	query := `
+		SELECT performers.* FROM performers
+		LEFT JOIN performers_galleries as galleries_join on galleries_join.performer_id = performers.id
+		WHERE galleries_join.gallery_id = ?
+		GROUP BY performers.id
+	`
+	args := []interface{}{galleryID}
+	return qb.queryPerformers(query, args, tx)
+}
+
+func (qb *PerformerQueryBuilder) FindByImageID(imageID int, tx *sqlx.Tx) ([]*Performer, error) {
+	query := `
+		SELECT performers.* FROM performers
+		LEFT JOIN performers_images as images_join on images_join.performer_id = performers.id
+		WHERE images_join.image_id = ?
+		GROUP BY performers.id
+	`
+	args := []interface{}{imageID}
+	return qb.queryPerformers(query, args, tx)
+}
+
 func (qb *PerformerQueryBuilder) FindByNames(names []string, tx *sqlx.Tx) ([]*Performer, error) {
 	query := "SELECT * FROM performers WHERE name IN " + getInBinding(len(names))
 	var args []interface{}
@@ -42,11 +42,11 @@ func (qb *SceneQueryBuilder) Create(newScene Scene, tx *sqlx.Tx) (*Scene, error)
 	ensureTx(tx)
 	result, err := tx.NamedExec(
 		`INSERT INTO scenes (checksum, path, title, details, url, date, rating, size, duration, video_codec,
-                    			    audio_codec, width, height, framerate, bitrate, studio_id, cover,
-                    				created_at, updated_at)
+                    			    audio_codec, format, width, height, framerate, bitrate, studio_id, cover,
+                    				file_mod_time, created_at, updated_at)
 				VALUES (:checksum, :path, :title, :details, :url, :date, :rating, :size, :duration, :video_codec,
-				        :audio_codec, :width, :height, :framerate, :bitrate, :studio_id, :cover,
-				        :created_at, :updated_at)
+				        :audio_codec, :format, :width, :height, :framerate, :bitrate, :studio_id, :cover,
+				        :file_mod_time, :created_at, :updated_at)
 		`,
 		newScene,
 	)
@@ -95,12 +95,27 @@ func (qb *SceneQueryBuilder) FindByChecksum(checksum string) (*Scene, error) {
 	return qb.queryScene(query, args, nil)
 }
 
+// FindAllByChecksum returns the scenes with the checksum. Duplicate files
+// are separate scenes with the same checksum.
+func (qb *SceneQueryBuilder) FindAllByChecksum(checksum string) ([]*Scene, error) {
+	query := "SELECT * FROM scenes WHERE checksum = ? ORDER BY id ASC"
+	args := []interface{}{checksum}
+	return qb.queryScenes(query, args, nil)
+}
+
 func (qb *SceneQueryBuilder) FindByPath(path string) (*Scene, error) {
 	query := "SELECT * FROM scenes WHERE path = ? LIMIT 1"
 	args := []interface{}{path}
 	return qb.queryScene(query, args, nil)
 }
 
+// FindBySize returns the scenes with the given file size.
+func (qb *SceneQueryBuilder) FindBySize(size string) ([]*Scene, error) {
+	query := "SELECT * FROM scenes WHERE size = ?"
+	args := []interface{}{size}
+	return qb.queryScenes(query, args, nil)
+}
+
 func (qb *SceneQueryBuilder) FindByPerformerID(performerID int) ([]*Scene, error) {
 	args := []interface{}{performerID}
 	return qb.queryScenes(scenesForPerformerQuery, args, nil)
@@ -116,6 +131,19 @@ func (qb *SceneQueryBuilder) FindByStudioID(studioID int) ([]*Scene, error) {
 	return qb.queryScenes(scenesForStudioQuery, args, nil)
 }
 
+// FindByGalleryID returns the scenes linked to the gallery.
+func (qb *SceneQueryBuilder) FindByGalleryID(galleryID int, tx *sqlx.Tx) ([]*Scene, error) {
+	query := `
+		SELECT scenes.* FROM scenes
+		LEFT JOIN scenes_galleries as galleries_join on galleries_join.scene_id = scenes.id
+		WHERE galleries_join.gallery_id = ?
+		GROUP BY scenes.id
+		ORDER BY scenes.path ASC
+	`
+	args := []interface{}{galleryID}
+	return qb.queryScenes(query, args, tx)
+}
+
 func (qb *SceneQueryBuilder) Count() (int, error) {
 	return runCountQuery(buildCountQuery("SELECT scenes.id FROM scenes"), nil)
 }
@@ -160,7 +188,7 @@ func (qb *SceneQueryBuilder) Query(sceneFilter *SceneFilterType, findFilter *Fin
 		left join performers_scenes as performers_join on performers_join.scene_id = scenes.id
 		left join performers on performers_join.performer_id = performers.id
 		left join studios as studio on studio.id = scenes.studio_id
-		left join galleries as gallery on gallery.scene_id = scenes.id
+		left join scenes_galleries as galleries_join on galleries_join.scene_id = scenes.id
 		left join scenes_tags as tags_join on tags_join.scene_id = scenes.id
 		left join tags on tags_join.tag_id = tags.id
 	`
@@ -208,7 +236,7 @@ func (qb *SceneQueryBuilder) Query(sceneFilter *SceneFilterType, findFilter *Fin
 	if isMissingFilter := sceneFilter.IsMissing; isMissingFilter != nil && *isMissingFilter != "" {
 		switch *isMissingFilter {
 		case "gallery":
-			whereClauses = append(whereClauses, "gallery.scene_id IS NULL")
+			whereClauses = append(whereClauses, "galleries_join.scene_id IS NULL")
 		case "studio":
 			whereClauses = append(whereClauses, "scenes.studio_id IS NULL")
 		case "performers":
@@ -272,6 +300,13 @@ func appendClause(clauses []string, clause string) []string {
 
 // returns where clause and having clause
 func getMultiCriterionClause(table string, joinTable string, joinTableField string, criterion *MultiCriterionInput) (string, string) {
+	return getMultiCriterionClauseForTable("scenes", "scene_id", table, joinTable, joinTableField, criterion)
+}
+
+// getMultiCriterionClauseForTable returns the where clause and having clause
+// of a criterion on the objects of the primary table. The join table refers
+// to the primary table with the foreign key column.
+func getMultiCriterionClauseForTable(primaryTable string, foreignKey string, table string, joinTable string, joinTableField string, criterion *MultiCriterionInput) (string, string) {
 	whereClause := ""
 	havingClause := ""
 	if criterion.Modifier == CriterionModifierIncludes {
@@ -284,9 +319,9 @@ func getMultiCriterionClause(table string, joinTable string, joinTableField stri
 	} else if criterion.Modifier == CriterionModifierExcludes {
 		// excludes all of the provided ids
 		if joinTable != "" {
-			whereClause = "not exists (select " + joinTable + ".scene_id from " + joinTable + " where " + joinTable + ".scene_id = scenes.id and " + joinTable + "." + joinTableField + " in " + getInBinding(len(criterion.Value)) + ")"
+			whereClause = "not exists (select " + joinTable + "." + foreignKey + " from " + joinTable + " where " + joinTable + "." + foreignKey + " = " + primaryTable + ".id and " + joinTable + "." + joinTableField + " in " + getInBinding(len(criterion.Value)) + ")"
 		} else {
-			whereClause = "not exists (select s.id from scenes as s where s.id = scenes.id and s." + joinTableField + " in " + getInBinding(len(criterion.Value)) + ")"
+			whereClause = "not exists (select s.id from " + primaryTable + " as s where s.id = " + primaryTable + ".id and s." + joinTableField + " in " + getInBinding(len(criterion.Value)) + ")"
 		}
 	}
 
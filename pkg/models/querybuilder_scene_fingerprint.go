@@ -0,0 +1,96 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/database"
+)
+
+type SceneFingerprintQueryBuilder struct{}
+
+func NewSceneFingerprintQueryBuilder() SceneFingerprintQueryBuilder {
+	return SceneFingerprintQueryBuilder{}
+}
+
+// Set creates or replaces the fingerprint of the given type for the scene.
+func (qb *SceneFingerprintQueryBuilder) Set(sceneID int, fingerprintType string, fingerprint string, tx *sqlx.Tx) error {
+	ensureTx(tx)
+	_, err := tx.NamedExec(
+		`INSERT OR REPLACE INTO scene_fingerprints (scene_id, type, fingerprint)
+				VALUES (:scene_id, :type, :fingerprint)
+		`,
+		SceneFingerprint{
+			SceneID:     sceneID,
+			Type:        fingerprintType,
+			Fingerprint: fingerprint,
+		},
+	)
+	return err
+}
+
+// Find returns the fingerprint of the given type for the scene, or nil if
+// it has not been calculated.
+func (qb *SceneFingerprintQueryBuilder) Find(sceneID int, fingerprintType string, tx *sqlx.Tx) (*SceneFingerprint, error) {
+	query := "SELECT * FROM scene_fingerprints WHERE scene_id = ? AND type = ? LIMIT 1"
+	args := []interface{}{sceneID, fingerprintType}
+	return qb.queryFingerprint(query, args, tx)
+}
+
+func (qb *SceneFingerprintQueryBuilder) FindBySceneID(sceneID int, tx *sqlx.Tx) ([]*SceneFingerprint, error) {
+	query := "SELECT * FROM scene_fingerprints WHERE scene_id = ? ORDER BY type ASC"
+	args := []interface{}{sceneID}
+	return qb.queryFingerprints(query, args, tx)
+}
+
+// FindByFingerprint returns the fingerprints of the given type and value.
+func (qb *SceneFingerprintQueryBuilder) FindByFingerprint(fingerprintType string, fingerprint string, tx *sqlx.Tx) ([]*SceneFingerprint, error) {
+	query := "SELECT * FROM scene_fingerprints WHERE type = ? AND fingerprint = ?"
+	args := []interface{}{fingerprintType, fingerprint}
+	return qb.queryFingerprints(query, args, tx)
+}
+
+// AllByType returns all fingerprints of the given type.
+func (qb *SceneFingerprintQueryBuilder) AllByType(fingerprintType string, tx *sqlx.Tx) ([]*SceneFingerprint, error) {
+	query := "SELECT * FROM scene_fingerprints WHERE type = ? ORDER BY scene_id ASC"
+	args := []interface{}{fingerprintType}
+	return qb.queryFingerprints(query, args, tx)
+}
+
+func (qb *SceneFingerprintQueryBuilder) queryFingerprint(query string, args []interface{}, tx *sqlx.Tx) (*SceneFingerprint, error) {
+	results, err := qb.queryFingerprints(query, args, tx)
+	if err != nil || len(results) < 1 {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+func (qb *SceneFingerprintQueryBuilder) queryFingerprints(query string, args []interface{}, tx *sqlx.Tx) ([]*SceneFingerprint, error) {
+	var rows *sqlx.Rows
+	var err error
+	if tx != nil {
+		rows, err = tx.Queryx(query, args...)
+	} else {
+		rows, err = database.DB.Queryx(query, args...)
+	}
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fingerprints := make([]*SceneFingerprint, 0)
+	for rows.Next() {
+		fingerprint := SceneFingerprint{}
+		if err := rows.StructScan(&fingerprint); err != nil {
+			return nil, err
+		}
+		fingerprints = append(fingerprints, &fingerprint)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return fingerprints, nil
+}
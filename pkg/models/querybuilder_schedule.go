@@ -0,0 +1,117 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/database"
+)
+
+type ScheduleQueryBuilder struct{}
+
+func NewScheduleQueryBuilder() ScheduleQueryBuilder {
+	return ScheduleQueryBuilder{}
+}
+
+func (qb *ScheduleQueryBuilder) Create(newSchedule Schedule, tx *sqlx.Tx) (*Schedule, error) {
+	ensureTx(tx)
+	result, err := tx.NamedExec(
+		`INSERT INTO schedules (name, schedule, type, input, enabled, last_run_at, next_run_at, created_at, updated_at)
+				VALUES (:name, :schedule, :type, :input, :enabled, :last_run_at, :next_run_at, :created_at, :updated_at)
+		`,
+		newSchedule,
+	)
+	if err != nil {
+		return nil, err
+	}
+	scheduleID, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Get(&newSchedule, `SELECT * FROM schedules WHERE id = ? LIMIT 1`, scheduleID); err != nil {
+		return nil, err
+	}
+	return &newSchedule, nil
+}
+
+// Update replaces all of the fields of the schedule, other than the created
+// time.
+func (qb *ScheduleQueryBuilder) Update(updatedSchedule Schedule, tx *sqlx.Tx) (*Schedule, error) {
+	ensureTx(tx)
+	_, err := tx.NamedExec(
+		`UPDATE schedules SET name = :name, schedule = :schedule, type = :type, input = :input, enabled = :enabled,
+				last_run_at = :last_run_at, next_run_at = :next_run_at, updated_at = :updated_at
+				WHERE schedules.id = :id
+		`,
+		updatedSchedule,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return qb.Find(updatedSchedule.ID, tx)
+}
+
+// SetRuns sets the last and next run times of the schedule with the given
+// id, leaving its other fields unchanged.
+func (qb *ScheduleQueryBuilder) SetRuns(id int, lastRunAt NullSQLiteTimestamp, nextRunAt NullSQLiteTimestamp, tx *sqlx.Tx) error {
+	ensureTx(tx)
+	_, err := tx.Exec(`UPDATE schedules SET last_run_at = ?, next_run_at = ? WHERE id = ?`, lastRunAt, nextRunAt, id)
+	return err
+}
+
+func (qb *ScheduleQueryBuilder) Destroy(id string, tx *sqlx.Tx) error {
+	return executeDeleteQuery("schedules", id, tx)
+}
+
+func (qb *ScheduleQueryBuilder) Find(id int, tx *sqlx.Tx) (*Schedule, error) {
+	query := "SELECT * FROM schedules WHERE id = ? LIMIT 1"
+	args := []interface{}{id}
+	return qb.querySchedule(query, args, tx)
+}
+
+func (qb *ScheduleQueryBuilder) All(tx *sqlx.Tx) ([]*Schedule, error) {
+	return qb.querySchedules("SELECT * FROM schedules ORDER BY name ASC, id ASC", nil, tx)
+}
+
+func (qb *ScheduleQueryBuilder) FindEnabled(tx *sqlx.Tx) ([]*Schedule, error) {
+	return qb.querySchedules("SELECT * FROM schedules WHERE enabled = 1 ORDER BY id ASC", nil, tx)
+}
+
+func (qb *ScheduleQueryBuilder) querySchedule(query string, args []interface{}, tx *sqlx.Tx) (*Schedule, error) {
+	results, err := qb.querySchedules(query, args, tx)
+	if err != nil || len(results) < 1 {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+func (qb *ScheduleQueryBuilder) querySchedules(query string, args []interface{}, tx *sqlx.Tx) ([]*Schedule, error) {
+	var rows *sqlx.Rows
+	var err error
+	if tx != nil {
+		rows, err = tx.Queryx(query, args...)
+	} else {
+		rows, err = database.DB.Queryx(query, args...)
+	}
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := make([]*Schedule, 0)
+	for rows.Next() {
+		schedule := Schedule{}
+		if err := rows.StructScan(&schedule); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, &schedule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
@@ -353,6 +353,10 @@ func sqlGenKeys(i interface{}, partial bool) string {
 			if partial || !t.Timestamp.IsZero() {
 				query = append(query, fmt.Sprintf("%s=:%s", key, key))
 			}
+		case NullSQLiteTimestamp:
+			if partial || t.Valid {
+				query = append(query, fmt.Sprintf("%s=:%s", key, key))
+			}
 		case SQLiteDate:
 			if partial || t.Valid {
 				query = append(query, fmt.Sprintf("%s=:%s", key, key))
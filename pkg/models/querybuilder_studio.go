@@ -58,6 +58,12 @@ func (qb *StudioQueryBuilder) Destroy(id string, tx *sqlx.Tx) error {
 		return err
 	}
 
+	// remove studio from galleries
+	_, err = tx.Exec("UPDATE galleries SET studio_id = null WHERE studio_id = ?", id)
+	if err != nil {
+		return err
+	}
+
 	// remove studio from scraped items
 	_, err = tx.Exec("UPDATE scraped_items SET studio_id = null WHERE studio_id = ?", id)
 	if err != nil {
@@ -79,6 +85,12 @@ func (qb *StudioQueryBuilder) FindBySceneID(sceneID int) (*Studio, error) {
 	return qb.queryStudio(query, args, nil)
 }
 
+func (qb *StudioQueryBuilder) FindByGalleryID(galleryID int) (*Studio, error) {
+	query := "SELECT studios.* FROM studios JOIN galleries ON studios.id = galleries.studio_id WHERE galleries.id = ? LIMIT 1"
+	args := []interface{}{galleryID}
+	return qb.queryStudio(query, args, nil)
+}
+
 func (qb *StudioQueryBuilder) FindByName(name string, tx *sqlx.Tx) (*Studio, error) {
 	query := "SELECT * FROM studios WHERE name = ? LIMIT 1"
 	args := []interface{}{name}
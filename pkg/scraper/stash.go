@@ -98,11 +98,16 @@ func scrapeSceneFragmentStash(c scraperTypeConfig, scene models.SceneUpdateInput
 		return nil, err
 	}
 
+	// scenes are only matched by MD5, which is not calculated for every scene
+	if !storedScene.Checksum.Valid {
+		return nil, nil
+	}
+
 	var q struct {
 		FindScene *models.ScrapedScene `graphql:"findScene(checksum: $c)"`
 	}
 
-	checksum := graphql.String(storedScene.Checksum)
+	checksum := graphql.String(storedScene.Checksum.String)
 	vars := map[string]interface{}{
 		"c": &checksum,
 	}
@@ -0,0 +1,195 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed cron expression.
+type CronSchedule struct {
+	minute  uint64
+	hour    uint64
+	day     uint64
+	month   uint64
+	weekday uint64
+
+	// standard cron runs when either the day of month or the day of week
+	// matches if both are restricted, and when both match otherwise
+	anyDay     bool
+	anyWeekday bool
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var cronMonthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var cronWeekdayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// ParseCron parses a cron expression with the five standard fields: minute,
+// hour, day of month, month and day of week. Fields may be lists, ranges and
+// steps, such as "0,30", "1-5" and "*/15". Months and days of the week may be
+// given as three letter names. The macros @yearly, @monthly, @weekly, @daily
+// and @hourly are also accepted.
+func ParseCron(spec string) (*CronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if macro, ok := cronMacros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, found %d", spec, len(fields))
+	}
+
+	ret := &CronSchedule{
+		anyDay:     isCronWildcard(fields[2]),
+		anyWeekday: isCronWildcard(fields[4]),
+	}
+
+	var err error
+	if ret.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, err
+	}
+	if ret.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, err
+	}
+	if ret.day, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, err
+	}
+	if ret.month, err = parseCronField(fields[3], 1, 12, cronMonthNames); err != nil {
+		return nil, err
+	}
+	if ret.weekday, err = parseCronField(fields[4], 0, 7, cronWeekdayNames); err != nil {
+		return nil, err
+	}
+
+	// 7 is also Sunday
+	if ret.weekday&(1<<7) != 0 {
+		ret.weekday |= 1
+	}
+
+	return ret, nil
+}
+
+// isCronWildcard returns true if the field starts with a wildcard, such as
+// "*" or "*/2". As in standard cron, a day field that starts with a wildcard
+// is unrestricted, so "0 0 */2 * mon" runs on the odd days that are Mondays
+// rather than on odd days and Mondays.
+func isCronWildcard(field string) bool {
+	return strings.HasPrefix(field, "*")
+}
+
+// parseCronField returns the values matched by the field as a bitset.
+func parseCronField(field string, min int, max int, names map[string]int) (uint64, error) {
+	var ret uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart := part
+		step := 1
+		if i := strings.Index(part, "/"); i != -1 {
+			var err error
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step in cron field %q", field)
+			}
+			rangePart = part[:i]
+		}
+
+		start, end := min, max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if start, err = parseCronValue(bounds[0], min, max, names); err != nil {
+				return 0, err
+			}
+			end = start
+			if len(bounds) == 2 {
+				if end, err = parseCronValue(bounds[1], min, max, names); err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				// "5/15" means from 5 to the maximum every 15
+				end = max
+			}
+		}
+
+		if start > end {
+			return 0, fmt.Errorf("invalid range in cron field %q", field)
+		}
+
+		for i := start; i <= end; i += step {
+			ret |= 1 << uint(i)
+		}
+	}
+	return ret, nil
+}
+
+func parseCronValue(value string, min int, max int, names map[string]int) (int, error) {
+	if n, ok := names[strings.ToLower(value)]; ok {
+		return n, nil
+	}
+
+	ret, err := strconv.Atoi(value)
+	if err != nil || ret < min || ret > max {
+		return 0, fmt.Errorf("invalid cron value %q: must be between %d and %d", value, min, max)
+	}
+	return ret, nil
+}
+
+// Next returns the first time after t that matches the schedule, to the
+// minute. Returns the zero time if no time within the next five years
+// matches, such as for the 30th of February.
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if !hasBit(s.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !hasBit(s.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !hasBit(s.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s *CronSchedule) matchesDay(t time.Time) bool {
+	day := hasBit(s.day, t.Day())
+	weekday := hasBit(s.weekday, int(t.Weekday()))
+
+	if s.anyDay || s.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
+
+func hasBit(bits uint64, i int) bool {
+	return bits&(1<<uint(i)) != 0
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// a Wednesday
+	from := time.Date(2020, time.January, 15, 10, 30, 45, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2020, time.January, 15, 10, 31, 0, 0, time.UTC)},
+		{"@daily", time.Date(2020, time.January, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2020, time.January, 16, 3, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2020, time.January, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 2 * * sun", time.Date(2020, time.January, 19, 2, 0, 0, 0, time.UTC)},
+		{"0 2 * * 7", time.Date(2020, time.January, 19, 2, 0, 0, 0, time.UTC)},
+		{"0 0 1 feb-mar *", time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		// day of month or day of week
+		{"0 0 20 * 4", time.Date(2020, time.January, 16, 0, 0, 0, 0, time.UTC)},
+		// a stepped wildcard day is unrestricted, so both days must match
+		{"0 0 */2 * mon", time.Date(2020, time.January, 27, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1-31/2 * mon", time.Date(2020, time.January, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, test := range tests {
+		schedule, err := ParseCron(test.spec)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", test.spec, err.Error())
+			continue
+		}
+
+		if got := schedule.Next(from); !got.Equal(test.want) {
+			t.Errorf("%q: expected %s, found %s", test.spec, test.want, got)
+		}
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	invalid := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	}
+
+	for _, spec := range invalid {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("%q: expected error", spec)
+		}
+	}
+}
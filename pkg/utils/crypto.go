@@ -2,6 +2,7 @@ package utils
 
 import (
 	"crypto/md5"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"os"
@@ -31,3 +32,59 @@ func MD5FromFilePath(filePath string) (string, error) {
 	checksum := h.Sum(nil)
 	return fmt.Sprintf("%x", checksum), nil
 }
+
+const oshashChunkSize = 64 * 1024
+
+// OSHashFromFilePath returns the OpenSubtitles hash of the file: the file
+// size added to the sums of the first and last 64KB of the file, read as
+// little-endian 64-bit words. Only the start and end of the file are read,
+// so it is much faster than MD5FromFilePath for large files.
+func OSHashFromFilePath(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	fileSize := fi.Size()
+	chunkSize := int64(oshashChunkSize)
+	if fileSize < chunkSize {
+		chunkSize = fileSize
+	}
+
+	head := make([]byte, chunkSize)
+	if _, err := f.ReadAt(head, 0); err != nil {
+		return "", err
+	}
+
+	tail := make([]byte, chunkSize)
+	if _, err := f.ReadAt(tail, fileSize-chunkSize); err != nil {
+		return "", err
+	}
+
+	hash := uint64(fileSize) + sumUint64LE(head) + sumUint64LE(tail)
+	return oshashString(hash), nil
+}
+
+// oshashString formats an oshash as a zero-padded hexadecimal string.
+func oshashString(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+// sumUint64LE returns the sum of the data read as little-endian 64-bit
+// words. A trailing partial word is padded with zeros.
+func sumUint64LE(data []byte) uint64 {
+	var sum uint64
+	for len(data) > 0 {
+		var word [8]byte
+		n := copy(word[:], data)
+		sum += binary.LittleEndian.Uint64(word[:])
+		data = data[n:]
+	}
+	return sum
+}
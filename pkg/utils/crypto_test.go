@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestOSHashFromFilePath(t *testing.T) {
+	f, err := ioutil.TempFile("", "oshash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	// 256KB file of little-endian words: head words are 1, tail words are
+	// 2 and the middle is ignored
+	const size = 256 * 1024
+	data := make([]byte, size)
+	for i := 0; i < size; i += 8 {
+		var word uint64 = 3
+		if i < oshashChunkSize {
+			word = 1
+		} else if i >= size-oshashChunkSize {
+			word = 2
+		}
+		binary.LittleEndian.PutUint64(data[i:], word)
+	}
+	if _, err := f.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	hash, err := OSHashFromFilePath(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	words := uint64(oshashChunkSize / 8)
+	expected := uint64(size) + words*1 + words*2
+	if hash != oshashString(expected) {
+		t.Errorf("Was expecting %016x, found %s", expected, hash)
+	}
+}
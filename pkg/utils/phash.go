@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/disintegration/imaging"
+)
+
+const phashSize = 32
+const phashLowSize = 8
+
+// PerceptualHash returns a 64-bit DCT based perceptual hash of the image.
+// Similar looking images produce hashes with a small Hamming distance,
+// even if they have been scaled or re-encoded.
+func PerceptualHash(img image.Image) uint64 {
+	resized := imaging.Resize(img, phashSize, phashSize, imaging.Lanczos)
+	gray := imaging.Grayscale(resized)
+
+	pixels := make([][]float64, phashSize)
+	for y := 0; y < phashSize; y++ {
+		pixels[y] = make([]float64, phashSize)
+		for x := 0; x < phashSize; x++ {
+			// grayscale images have equal red, green and blue values
+			r, _, _, _ := gray.At(x, y).RGBA()
+			pixels[y][x] = float64(r >> 8)
+		}
+	}
+
+	dct := dct2D(pixels)
+
+	// use the lowest frequencies, which describe the overall structure of
+	// the image
+	low := make([]float64, 0, phashLowSize*phashLowSize)
+	for y := 0; y < phashLowSize; y++ {
+		for x := 0; x < phashLowSize; x++ {
+			low = append(low, dct[y][x])
+		}
+	}
+
+	sorted := make([]float64, len(low))
+	copy(sorted, low)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var hash uint64
+	for i, v := range low {
+		if v > median {
+			hash |= 1 << uint(len(low)-1-i)
+		}
+	}
+
+	return hash
+}
+
+// PerceptualHashString formats a perceptual hash as a hexadecimal string.
+func PerceptualHashString(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+// ParsePerceptualHash parses a hash formatted by PerceptualHashString.
+func ParsePerceptualHash(s string) (uint64, error) {
+	var hash uint64
+	_, err := fmt.Sscanf(s, "%x", &hash)
+	return hash, err
+}
+
+// HammingDistance returns the number of bits that differ between two
+// hashes.
+func HammingDistance(a uint64, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+func dct1D(input []float64) []float64 {
+	n := len(input)
+	output := make([]float64, n)
+	for k := 0; k < n; k++ {
+		var sum float64
+		for i, v := range input {
+			sum += v * math.Cos(math.Pi/float64(n)*(float64(i)+0.5)*float64(k))
+		}
+		output[k] = sum
+	}
+	return output
+}
+
+func dct2D(input [][]float64) [][]float64 {
+	rows := make([][]float64, len(input))
+	for y, row := range input {
+		rows[y] = dct1D(row)
+	}
+
+	output := make([][]float64, len(input))
+	for y := range output {
+		output[y] = make([]float64, len(input[0]))
+	}
+
+	column := make([]float64, len(input))
+	for x := range input[0] {
+		for y := range rows {
+			column[y] = rows[y][x]
+		}
+		transformed := dct1D(column)
+		for y := range output {
+			output[y][x] = transformed[y]
+		}
+	}
+
+	return output
+}
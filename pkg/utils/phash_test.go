@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+func makeTestImage(width int, height int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// a horizontal gradient with a dark rectangle in the top left
+			v := uint8(40 + x*160/width)
+			if x < width/3 && y < height/2 {
+				v = 20
+			}
+			img.Set(x, y, color.NRGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	return img
+}
+
+func TestPerceptualHash(t *testing.T) {
+	original := makeTestImage(320, 240)
+	scaled := imaging.Resize(original, 160, 120, imaging.Linear)
+	brighter := imaging.AdjustBrightness(original, 10)
+	different := imaging.FlipH(imaging.Rotate90(original))
+
+	hash := PerceptualHash(original)
+
+	if d := HammingDistance(hash, PerceptualHash(scaled)); d > 4 {
+		t.Errorf("Scaled image distance should be small, found %d", d)
+	}
+	if d := HammingDistance(hash, PerceptualHash(brighter)); d > 4 {
+		t.Errorf("Brightened image distance should be small, found %d", d)
+	}
+	if d := HammingDistance(hash, PerceptualHash(different)); d < 10 {
+		t.Errorf("Different image distance should be large, found %d", d)
+	}
+
+	parsed, err := ParsePerceptualHash(PerceptualHashString(hash))
+	if err != nil || parsed != hash {
+		t.Errorf("Was expecting %d, found %d (%v)", hash, parsed, err)
+	}
+}
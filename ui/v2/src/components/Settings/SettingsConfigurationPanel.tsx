@@ -11,6 +11,7 @@ import {
   Tag,
   Checkbox,
   HTMLSelect,
+  NumericInput,
 } from "@blueprintjs/core";
 import React, { FunctionComponent, useEffect, useState } from "react";
 import * as GQL from "../../core/generated-graphql";
@@ -23,11 +24,20 @@ interface IProps {}
 
 export const SettingsConfigurationPanel: FunctionComponent<IProps> = (props: IProps) => {
   // Editing config state
-  const [stashes, setStashes] = useState<string[]>([]);
+  const [stashes, setStashes] = useState<GQL.StashConfigInput[]>([]);
   const [databasePath, setDatabasePath] = useState<string | undefined>(undefined);
   const [generatedPath, setGeneratedPath] = useState<string | undefined>(undefined);
   const [maxTranscodeSize, setMaxTranscodeSize] = useState<GQL.StreamingResolutionEnum | undefined>(undefined);
   const [maxStreamingTranscodeSize, setMaxStreamingTranscodeSize] = useState<GQL.StreamingResolutionEnum | undefined>(undefined);
+  const [transcodeProfile, setTranscodeProfile] = useState<string | undefined>(undefined);
+  const [streamingTranscodeProfile, setStreamingTranscodeProfile] = useState<string | undefined>(undefined);
+  const [hlsTranscodeProfile, setHlsTranscodeProfile] = useState<string | undefined>(undefined);
+  const [previewSegments, setPreviewSegments] = useState<number>(12);
+  const [previewSegmentDuration, setPreviewSegmentDuration] = useState<number>(0.75);
+  const [previewExcludeStart, setPreviewExcludeStart] = useState<number>(0);
+  const [previewExcludeEnd, setPreviewExcludeEnd] = useState<number>(0);
+  const [previewPreset, setPreviewPreset] = useState<GQL.PreviewPreset | undefined>(undefined);
+  const [previewAudio, setPreviewAudio] = useState<boolean>(true);
   const [username, setUsername] = useState<string | undefined>(undefined);
   const [password, setPassword] = useState<string | undefined>(undefined);
   const [logFile, setLogFile] = useState<string | undefined>();
@@ -44,6 +54,15 @@ export const SettingsConfigurationPanel: FunctionComponent<IProps> = (props: IPr
     generatedPath,
     maxTranscodeSize,
     maxStreamingTranscodeSize,
+    transcodeProfile,
+    streamingTranscodeProfile,
+    hlsTranscodeProfile,
+    previewSegments,
+    previewSegmentDuration,
+    previewExcludeStart,
+    previewExcludeEnd,
+    previewPreset,
+    previewAudio,
     username,
     password,
     logFile,
@@ -58,11 +77,27 @@ export const SettingsConfigurationPanel: FunctionComponent<IProps> = (props: IPr
     if (!data || !data.configuration || !!error) { return; }
     const conf = StashService.nullToUndefined(data.configuration) as GQL.ConfigDataFragment;
     if (!!conf.general) {
-      setStashes(conf.general.stashes || []);
+      setStashes((conf.general.stashes || []).map((stash) => ({
+        path: stash.path,
+        excludeVideo: stash.excludeVideo,
+        excludeImage: stash.excludeImage,
+        skipGenerate: stash.skipGenerate,
+        readOnly: stash.readOnly,
+        scanSchedule: stash.scanSchedule,
+      })));
       setDatabasePath(conf.general.databasePath);
       setGeneratedPath(conf.general.generatedPath);
       setMaxTranscodeSize(conf.general.maxTranscodeSize);
       setMaxStreamingTranscodeSize(conf.general.maxStreamingTranscodeSize);
+      setTranscodeProfile(conf.general.transcodeProfile);
+      setStreamingTranscodeProfile(conf.general.streamingTranscodeProfile);
+      setHlsTranscodeProfile(conf.general.hlsTranscodeProfile);
+      setPreviewSegments(conf.general.previewSegments);
+      setPreviewSegmentDuration(conf.general.previewSegmentDuration);
+      setPreviewExcludeStart(conf.general.previewExcludeStart);
+      setPreviewExcludeEnd(conf.general.previewExcludeEnd);
+      setPreviewPreset(conf.general.previewPreset);
+      setPreviewAudio(conf.general.previewAudio);
       setUsername(conf.general.username);
       setPassword(conf.general.password);
       setLogFile(conf.general.logFile);
@@ -74,7 +109,46 @@ export const SettingsConfigurationPanel: FunctionComponent<IProps> = (props: IPr
   }, [data]);
 
   function onStashesChanged(directories: string[]) {
-    setStashes(directories);
+    // keep the options of the paths that were not removed
+    setStashes(directories.map((path) => {
+      const existing = stashes.find((stash) => stash.path === path);
+      return existing || { path };
+    }));
+  }
+
+  function onStashOptionChanged(idx: number, option: Partial<GQL.StashConfigInput>) {
+    setStashes(stashes.map((stash, i) => i === idx ? { ...stash, ...option } : stash));
+  }
+
+  function renderStashOptions() {
+    return stashes.map((stash, i) => (
+      <FormGroup key={stash.path} label={stash.path} inline={true}>
+        <Checkbox
+          inline={true}
+          checked={!!stash.excludeVideo}
+          label="Exclude videos"
+          onChange={() => onStashOptionChanged(i, { excludeVideo: !stash.excludeVideo })}
+        />
+        <Checkbox
+          inline={true}
+          checked={!!stash.excludeImage}
+          label="Exclude galleries"
+          onChange={() => onStashOptionChanged(i, { excludeImage: !stash.excludeImage })}
+        />
+        <Checkbox
+          inline={true}
+          checked={!!stash.skipGenerate}
+          label="Skip generation"
+          onChange={() => onStashOptionChanged(i, { skipGenerate: !stash.skipGenerate })}
+        />
+        <Checkbox
+          inline={true}
+          checked={!!stash.readOnly}
+          label="Read-only"
+          onChange={() => onStashOptionChanged(i, { readOnly: !stash.readOnly })}
+        />
+      </FormGroup>
+    ));
   }
 
   function excludeRegexChanged(idx: number, value: string) {
@@ -118,6 +192,11 @@ export const SettingsConfigurationPanel: FunctionComponent<IProps> = (props: IPr
     GQL.StreamingResolutionEnum.Original
   ].map(resolutionToString);
 
+  function transcodeProfileNames() {
+    if (!data || !data.configuration) { return []; }
+    return data.configuration.general.transcodeProfiles.map((profile) => profile.name);
+  }
+
   function resolutionToString(r : GQL.StreamingResolutionEnum | undefined) {
     switch (r) {
       case GQL.StreamingResolutionEnum.Low: return "240p";
@@ -156,9 +235,10 @@ export const SettingsConfigurationPanel: FunctionComponent<IProps> = (props: IPr
             helperText="Directory locations to your content"
           >
             <FolderSelect
-              directories={stashes}
+              directories={stashes.map((stash) => stash.path)}
               onDirectoriesChanged={onStashesChanged}
             />
+            {renderStashOptions()}
           </FormGroup>
         </FormGroup>
         
@@ -229,7 +309,103 @@ export const SettingsConfigurationPanel: FunctionComponent<IProps> = (props: IPr
               value={resolutionToString(maxStreamingTranscodeSize)}
             />
           </FormGroup>
+          <FormGroup
+            label="Transcode profile"
+            helperText="Encoder settings for generated transcodes"
+          >
+            <HTMLSelect
+              options={transcodeProfileNames()}
+              onChange={(event) => setTranscodeProfile(event.target.value)}
+              value={transcodeProfile}
+            />
+          </FormGroup>
+          <FormGroup
+            label="Streaming transcode profile"
+            helperText="Encoder settings for transcoded streams"
+          >
+            <HTMLSelect
+              options={transcodeProfileNames()}
+              onChange={(event) => setStreamingTranscodeProfile(event.target.value)}
+              value={streamingTranscodeProfile}
+            />
+          </FormGroup>
+          <FormGroup
+            label="HLS transcode profile"
+            helperText="Encoder settings for HLS streams. The profile must encode H.264"
+          >
+            <HTMLSelect
+              options={transcodeProfileNames()}
+              onChange={(event) => setHlsTranscodeProfile(event.target.value)}
+              value={hlsTranscodeProfile}
+            />
+          </FormGroup>
+        </FormGroup>
+      <Divider />
+
+      <FormGroup>
+        <H4>Previews</H4>
+        <FormGroup
+          label="Number of segments"
+          helperText="Number of segments in generated previews"
+        >
+          <NumericInput
+            value={previewSegments}
+            onValueChange={(value: number) => setPreviewSegments(value)}
+            min={1}
+            minorStepSize={1}
+          />
+        </FormGroup>
+        <FormGroup
+          label="Segment duration"
+          helperText="Length of each preview segment, in seconds"
+        >
+          <NumericInput
+            value={previewSegmentDuration}
+            onValueChange={(value: number) => setPreviewSegmentDuration(value)}
+            min={0}
+            stepSize={0.25}
+            minorStepSize={0.05}
+          />
         </FormGroup>
+        <FormGroup
+          label="Exclude start"
+          helperText="Percentage of the video to skip at the start of previews, to avoid intros"
+        >
+          <NumericInput
+            value={previewExcludeStart}
+            onValueChange={(value: number) => setPreviewExcludeStart(value)}
+            min={0}
+            max={99}
+          />
+        </FormGroup>
+        <FormGroup
+          label="Exclude end"
+          helperText="Percentage of the video to skip at the end of previews, to avoid credits"
+        >
+          <NumericInput
+            value={previewExcludeEnd}
+            onValueChange={(value: number) => setPreviewExcludeEnd(value)}
+            min={0}
+            max={99}
+          />
+        </FormGroup>
+        <FormGroup
+          label="Encoding preset"
+          helperText="Slower presets make smaller previews but take longer to generate"
+        >
+          <HTMLSelect
+            options={Object.values(GQL.PreviewPreset)}
+            onChange={(event) => setPreviewPreset(event.target.value as GQL.PreviewPreset)}
+            value={previewPreset}
+          />
+        </FormGroup>
+        <Checkbox
+          checked={previewAudio}
+          label="Include audio in previews"
+          onChange={() => setPreviewAudio(!previewAudio)}
+        />
+        <p>Existing previews are regenerated with the new options the next time previews are generated.</p>
+      </FormGroup>
       <Divider />
 
       <FormGroup>
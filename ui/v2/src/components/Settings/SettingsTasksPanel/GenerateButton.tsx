@@ -15,10 +15,11 @@ export const GenerateButton: FunctionComponent<IProps> = () => {
   const [previews, setPreviews] = useState<boolean>(true);
   const [markers, setMarkers] = useState<boolean>(true);
   const [transcodes, setTranscodes] = useState<boolean>(true);
+  const [galleryThumbnails, setGalleryThumbnails] = useState<boolean>(true);
 
   async function onGenerate() {
     try {
-      await StashService.queryMetadataGenerate({sprites, previews, markers, transcodes});
+      await StashService.queryMetadataGenerate({sprites, previews, markers, transcodes, galleryThumbnails});
       ToastUtils.success("Started generating");
     } catch (e) {
       ErrorUtils.handle(e);
@@ -47,6 +48,11 @@ export const GenerateButton: FunctionComponent<IProps> = () => {
         label="Transcodes (MP4 conversions of unsupported video formats)"
         onChange={() => setTranscodes(!transcodes)}
       />
+      <Checkbox
+        checked={galleryThumbnails}
+        label="Gallery thumbnails (thumbnails of the gallery images)"
+        onChange={() => setGalleryThumbnails(!galleryThumbnails)}
+      />
       <Button id="generate" text="Generate" onClick={() => onGenerate()} />
     </FormGroup>
   );
@@ -24,6 +24,9 @@ export const SettingsTasksPanel: FunctionComponent<IProps> = (props: IProps) =>
   const [useFileMetadata, setUseFileMetadata] = useState<boolean>(false);
   const [status, setStatus] = useState<string>("");
   const [progress, setProgress] = useState<number | undefined>(undefined);
+  const [currentFile, setCurrentFile] = useState<string | undefined>(undefined);
+  const [subProgress, setSubProgress] = useState<number | undefined>(undefined);
+  const [eta, setEta] = useState<number | undefined>(undefined);
 
   const [autoTagPerformers, setAutoTagPerformers] = useState<boolean>(true);
   const [autoTagStudios, setAutoTagStudios] = useState<boolean>(true);
@@ -74,6 +77,9 @@ export const SettingsTasksPanel: FunctionComponent<IProps> = (props: IProps) =>
       } else {
         setProgress(newProgress);
       }
+      setCurrentFile(metadataUpdate.data.metadataUpdate.currentFile || undefined);
+      setSubProgress(metadataUpdate.data.metadataUpdate.subProgress === null ? undefined : metadataUpdate.data.metadataUpdate.subProgress);
+      setEta(metadataUpdate.data.metadataUpdate.eta === null ? undefined : metadataUpdate.data.metadataUpdate.eta);
     }
   }, [metadataUpdate.data]);
 
@@ -155,6 +161,26 @@ export const SettingsTasksPanel: FunctionComponent<IProps> = (props: IProps) =>
     }
   }
 
+  async function onAutoLinkGalleries() {
+    try {
+      await StashService.queryMetadataAutoLinkGalleries();
+      ToastUtils.success("Started linking galleries");
+      jobStatus.refetch();
+    } catch (e) {
+      ErrorUtils.handle(e);
+    }
+  }
+
+  async function onMigrateHash() {
+    try {
+      await StashService.queryMetadataMigrateHash();
+      ToastUtils.success("Started renaming generated files");
+      jobStatus.refetch();
+    } catch (e) {
+      ErrorUtils.handle(e);
+    }
+  }
+
   function maybeRenderStop() {
     if (!status || status === "Idle") {
       return undefined;
@@ -169,6 +195,16 @@ export const SettingsTasksPanel: FunctionComponent<IProps> = (props: IProps) =>
     );
   }
 
+  function maybeRenderFileProgress() {
+    if (!status || status === "Idle" || !currentFile || subProgress === undefined) { return; }
+    const remaining = eta !== undefined ? ` (${Math.ceil(eta)}s remaining)` : "";
+    return (
+      <FormGroup helperText={currentFile + remaining}>
+        <ProgressBar value={subProgress} intent="primary" />
+      </FormGroup>
+    );
+  }
+
   function renderJobStatus() {
     return (
       <>
@@ -176,6 +212,7 @@ export const SettingsTasksPanel: FunctionComponent<IProps> = (props: IProps) =>
         <H5>Status: {status}</H5>
         {!!status && status !== "Idle" ? <ProgressBar value={progress}/> : undefined}
       </FormGroup>
+      {maybeRenderFileProgress()}
       {maybeRenderStop()}
       </>
     );
@@ -233,6 +270,22 @@ export const SettingsTasksPanel: FunctionComponent<IProps> = (props: IProps) =>
         <Button id="autoTag" text="Auto Tag" onClick={() => onAutoTag()} />
       </FormGroup>
 
+      <FormGroup
+        helperText="Link galleries to the scenes with the same name, or within the gallery folder."
+        labelFor="autoLinkGalleries"
+        inline={true}
+      >
+        <Button id="autoLinkGalleries" text="Link Galleries" onClick={() => onAutoLinkGalleries()} />
+      </FormGroup>
+
+      <FormGroup
+        helperText="Rename generated scene files to the hash chosen in the settings. This runs automatically when the hash is changed."
+        labelFor="migrateHash"
+        inline={true}
+      >
+        <Button id="migrateHash" text="Rename Generated Files" onClick={() => onMigrateHash()} />
+      </FormGroup>
+
       <FormGroup>
         <Link className="bp3-button" to={"/sceneFilenameParser"}>
           Scene Filename Parser
@@ -484,9 +484,24 @@ export class StashService {
     });
   }
 
-  public static queryMetadataClean() {
+  public static queryMetadataClean(input?: GQL.CleanMetadataInput) {
     return StashService.client.query<GQL.MetadataCleanQuery>({
       query: GQL.MetadataCleanDocument,
+      variables: { input },
+      fetchPolicy: "network-only",
+    });
+  }
+
+  public static queryMetadataAutoLinkGalleries() {
+    return StashService.client.query<GQL.MetadataAutoLinkGalleriesQuery>({
+      query: GQL.MetadataAutoLinkGalleriesDocument,
+      fetchPolicy: "network-only",
+    });
+  }
+
+  public static queryMetadataMigrateHash() {
+    return StashService.client.query<GQL.MetadataMigrateHashQuery>({
+      query: GQL.MetadataMigrateHashDocument,
       fetchPolicy: "network-only",
     });
   }
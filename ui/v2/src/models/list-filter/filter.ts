@@ -111,7 +111,7 @@ export class ListFilterModel {
         break;
       case FilterMode.Galleries:
         if (!!this.sortBy === false) { this.sortBy = "path"; }
-        this.sortByOptions = ["path"];
+        this.sortByOptions = ["path", "title", "date", "rating", "created_at", "updated_at"];
         this.displayModeOptions = [
           DisplayMode.List,
         ];